@@ -1,6 +1,8 @@
 package gmodel
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -8,18 +10,41 @@ import (
 )
 
 type Article struct {
-	Id     uint64   `json:"id"`      // 文章ID，从1开始自增，唯一标识，不允许修改
-	TagIds []uint64 `json:"tag_ids"` // 文章分类，多个分类ID
-	Data   string   `json:"data"`    // 文章数据，由上层解析
+	Id     uint64              `json:"id"`              // 文章ID，从1开始自增，唯一标识，不允许修改
+	TagIds []uint64            `json:"tag_ids"`         // 文章分类，多个分类ID
+	Terms  map[string][]uint64 `json:"terms,omitempty"` // 其他分类法下的分类，key为分类法名称，value为分类ID
+	Data   string              `json:"data"`            // 文章数据，由上层解析
+}
+
+// 返回文章内容的哈希，包括分类ID、其他分类法下的分类ID、文章数据，不包括文章ID
+// 分类ID的顺序不同也认为内容不同，因为分类的顺序会影响显示
+func (this *Article) ContentHash() string {
+	// nil 和空的都当作没有，保证编码结果一样
+	content := &Article{Data: this.Data}
+	if len(this.TagIds) > 0 {
+		content.TagIds = this.TagIds
+	}
+	if len(this.Terms) > 0 {
+		content.Terms = this.Terms
+	}
+	value, _ := json.Marshal(content)
+	sum := sha256.Sum256(value)
+	return hex.EncodeToString(sum[:])
 }
 
 type ArticleMgr struct {
 	db    *KVStore
 	mutex sync.RWMutex
+
+	// 游标签名的密钥，GModel 中和其他列表共用，单独使用时打开时随机生成
+	cursors *cursorSigner
 }
 
 // 打开数据库文件
 func (this *ArticleMgr) Open(path string) error {
+	if this.cursors == nil {
+		this.cursors = newCursorSigner()
+	}
 	this.db = &KVStore{}
 	return this.db.Open(path)
 }
@@ -30,6 +55,8 @@ func (this *ArticleMgr) Close() error {
 
 // 返回指定文章ID的后N篇文章（不包括当前id）
 // 如果id等于0，则表示获取最旧的N篇文章
+//
+// Deprecated: 使用 List 代替
 func (this *ArticleMgr) Next(id uint64, n int) []*Article {
 	this.mutex.RLock()
 	defer this.mutex.RUnlock()
@@ -58,6 +85,8 @@ func (this *ArticleMgr) Next(id uint64, n int) []*Article {
 
 // 返回指定文章ID的前N篇文章（不包括当前id）
 // 如果id大于CurrentSequence，则表示获取最新的N篇文章
+//
+// Deprecated: 使用 List 代替
 func (this *ArticleMgr) Prev(id uint64, n int) []*Article {
 	this.mutex.RLock()
 	defer this.mutex.RUnlock()
@@ -96,6 +125,50 @@ func (this *ArticleMgr) Prev(id uint64, n int) []*Article {
 	return articles
 }
 
+// 按文章ID分页，cursor 为空表示第一页，下一页、上一页传入返回的 NextCursor、PrevCursor
+// OrderDesc 表示最新的文章在前面
+func (this *ArticleMgr) List(cursor string, n int, order Order) (*Page[*Article], error) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	return scanPage(this.cursors, this.db, nil, "article", cursor, n, order, func(key, value []byte) (*Article, bool) {
+		article := &Article{}
+		if err := json.Unmarshal(value, article); err != nil {
+			return nil, false
+		}
+		return article, true
+	})
+}
+
+// 按文章ID从小到大遍历全部文章，f 返回 false 时停止遍历
+// 遇到无法解析的文章时停止遍历并返回错误
+func (this *ArticleMgr) Scan(f func(article *Article) bool) error {
+	return this.ScanAfter(0, f)
+}
+
+// 和 Scan 一样，但是从 afterId 后面的文章开始遍历（不包括 afterId，afterId 也可以不存在），用于分批遍历
+// afterId 为0表示从头开始遍历
+func (this *ArticleMgr) ScanAfter(afterId uint64, f func(article *Article) bool) error {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	var afterKey []byte
+	if afterId > 0 {
+		afterKey = []byte(this.getKeyFromId(afterId))
+	}
+
+	var err error
+	this.db.ScanAfter(nil, afterKey, func(key, value []byte) bool {
+		article := &Article{}
+		if err = json.Unmarshal(value, article); err != nil {
+			err = errors.New(fmt.Sprintf("Article key[%s] unmarshal failed: %v", key, err))
+			return false
+		}
+		return f(article)
+	})
+	return err
+}
+
 // 获取文章数量
 func (this *ArticleMgr) Count() uint64 {
 	this.mutex.RLock()
@@ -125,6 +198,84 @@ func (this *ArticleMgr) Add(article *Article) (uint64, error) {
 	return article.Id, this.putArticle(article)
 }
 
+// 批量增加文章，一次分配全部文章ID并且一次写入，会设置每篇文章的ID
+// 要么全部成功，要么全部失败（失败时已经分配的文章ID不会再使用）
+func (this *ArticleMgr) AddBatch(articles []*Article) error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if len(articles) == 0 {
+		return nil
+	}
+
+	firstId, err := this.db.NextSequences(uint64(len(articles)))
+	if err != nil {
+		return err
+	}
+
+	batch := &KVBatch{}
+	for i, article := range articles {
+		article.Id = firstId + uint64(i)
+		value, err := json.Marshal(article)
+		if err != nil {
+			return err
+		}
+		batch.Put(this.getKeyFromId(article.Id), value)
+	}
+
+	return this.db.Write(batch)
+}
+
+// 批量增加文章，使用文章原来的ID，用于导入时保留文章ID
+// 文章ID不能为0，也不能已经存在或者重复，否则全部失败
+// 文章ID大于当前最大ID时会更新最大ID，之后新增的文章ID从这里继续
+func (this *ArticleMgr) AddBatchWithIds(articles []*Article) error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	ids := make([]uint64, len(articles))
+	for i, article := range articles {
+		ids[i] = article.Id
+	}
+	if err := this.checkBatchIds(ids); err != nil {
+		return err
+	}
+
+	maxId := uint64(0)
+	batch := &KVBatch{}
+	for _, article := range articles {
+		if article.Id > maxId {
+			maxId = article.Id
+		}
+
+		value, err := json.Marshal(article)
+		if err != nil {
+			return err
+		}
+		batch.Put(this.getKeyFromId(article.Id), value)
+	}
+
+	if err := this.db.AdvanceSequence(maxId); err != nil {
+		return err
+	}
+	return this.db.Write(batch)
+}
+
+// 检查 AddBatchWithIds 的文章ID，不能为0，也不能已经存在或者重复
+func (this *ArticleMgr) checkBatchIds(ids []uint64) error {
+	mark := make(map[uint64]bool)
+	for _, id := range ids {
+		if id == 0 {
+			return newError(ErrInvalidArgument, "Article ID must not be 0")
+		}
+		if mark[id] || this.has(id) {
+			return newError(ErrExists, fmt.Sprintf("Article ID[%v] is exist", id))
+		}
+		mark[id] = true
+	}
+	return nil
+}
+
 // 保存文章
 func (this *ArticleMgr) putArticle(article *Article) error {
 	key := this.getKeyFromId(article.Id)
@@ -150,7 +301,7 @@ func (this *ArticleMgr) Update(article *Article) error {
 
 	exist := this.has(article.Id)
 	if !exist {
-		return errors.New(fmt.Sprintf("Article ID[%v] not found", article.Id))
+		return newError(ErrNotFound, fmt.Sprintf("Article ID[%v] not found", article.Id))
 	}
 
 	return this.putArticle(article)
@@ -164,10 +315,27 @@ func (this *ArticleMgr) GetById(id uint64) (*Article, error) {
 	return this.getById(id)
 }
 
+// 批量获取文章，返回结果和 ids 一一对应，获取失败时文章为nil，错误信息在 errs 中
+func (this *ArticleMgr) GetByIds(ids []uint64) ([]*Article, []error) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	articles := make([]*Article, len(ids))
+	errs := make([]error, len(ids))
+	for i, id := range ids {
+		if article, err := this.getById(id); err == nil {
+			articles[i] = article
+		} else {
+			errs[i] = err
+		}
+	}
+	return articles, errs
+}
+
 func (this *ArticleMgr) getById(id uint64) (*Article, error) {
 	value, err := this.db.Get(this.getKeyFromId(id))
 	if err != nil {
-		return nil, errors.New(fmt.Sprintf("Article ID[%v] not found", id))
+		return nil, newError(ErrNotFound, fmt.Sprintf("Article ID[%v] not found", id))
 	}
 
 	article := &Article{}
@@ -0,0 +1,136 @@
+package gmodel
+
+import (
+	"strconv"
+)
+
+// 批量增加的文章，字段含义同 AddArticleWithTerms 的参数
+type NewArticle struct {
+	Tags  []string            `json:"tags"`
+	Terms map[string][]string `json:"terms,omitempty"`
+	Data  string              `json:"data"`
+}
+
+// 批量增加文章，适合大量导入，比逐篇调用 AddArticle 快很多：
+// 文章ID一次分配，文章和索引都是批量写入，每个分类的文章数也只更新一次
+// 返回的文章ID和错误都和 articles 一一对应，失败的项文章ID为0，某一项失败（比如数据为空）不影响其他项
+// 文章已经保存、但是分类的文章数或者索引写入失败时，所有项都返回错误，文章ID仍然返回
+// 建议每次最多几千篇，分多次调用，因为批处理的数据都在内存中
+func (this *GModel) AddArticles(articles []NewArticle) ([]uint64, []error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	return this.addArticles(articles, nil)
+}
+
+// ids 不为nil时和 articles 一一对应，使用指定的文章ID，详见 ArticleMgr.AddBatchWithIds
+func (this *GModel) addArticles(articles []NewArticle, ids []uint64) ([]uint64, []error) {
+	articleIds := make([]uint64, len(articles))
+	errs := make([]error, len(articles))
+
+	// 先检查参数，全部通过之后再增加分类，避免失败时留下没有文章的分类
+	validIndexes := make([]int, 0, len(articles))
+	for i, newArticle := range articles {
+		if len(newArticle.Data) == 0 {
+			errs[i] = newError(ErrInvalidArgument, "GModel AddArticle data must not empty!")
+			continue
+		}
+		for name := range newArticle.Terms {
+			if _, err := this.getTaxonomy(name); err != nil {
+				errs[i] = err
+				break
+			}
+		}
+		if errs[i] != nil {
+			continue
+		}
+		validIndexes = append(validIndexes, i)
+	}
+
+	fail := func(err error) ([]uint64, []error) {
+		for _, i := range validIndexes {
+			errs[i] = err
+		}
+		return articleIds, errs
+	}
+	if ids != nil {
+		validIds := make([]uint64, len(validIndexes))
+		for j, i := range validIndexes {
+			validIds[j] = ids[i]
+		}
+		if err := this.articleMgr.checkBatchIds(validIds); err != nil {
+			return fail(err)
+		}
+	}
+
+	// 写入失败时删除新增加的分类
+	sequences := this.tagSequences()
+
+	valid := make([]*Article, 0, len(validIndexes))
+	for _, i := range validIndexes {
+		newArticle := articles[i]
+		article := &Article{
+			TagIds: this.addTags(newArticle.Tags),
+			Data:   newArticle.Data,
+		}
+		if ids != nil {
+			article.Id = ids[i]
+		}
+		for name, names := range newArticle.Terms {
+			tax := this.taxonomies[name]
+			tax.setTermIds(article, this.addTaxonomyTerms(tax, names))
+		}
+
+		valid = append(valid, article)
+	}
+
+	// 批量增加文章
+	addBatch := this.articleMgr.AddBatch
+	if ids != nil {
+		addBatch = this.articleMgr.AddBatchWithIds
+	}
+	if err := addBatch(valid); err != nil {
+		this.deleteNewEmptyTags(sequences)
+		return fail(err)
+	}
+	for j, i := range validIndexes {
+		articleIds[i] = valid[j].Id
+	}
+
+	// 文章已经保存，写入失败时仍然返回文章ID
+	for _, tax := range this.allTaxonomies() {
+		if err := this.addIndexBatch(tax, valid); err != nil {
+			return fail(err)
+		}
+	}
+
+	return articleIds, errs
+}
+
+// 批量修改分类下的文章数，并且批量增加索引，文章数和索引各自一次写入
+func (this *GModel) addIndexBatch(tax *taxonomy, articles []*Article) error {
+	counts := make(map[uint64]int64)
+	bucketCounts := make(map[string]uint64)
+	batch := &KVBatch{}
+
+	for _, article := range articles {
+		value := []byte(strconv.FormatUint(article.Id, 10))
+		for _, tagId := range tax.getTermIds(article) {
+			counts[tagId]++
+			bucketCounts[string(this.getBucketKey(tagId, article.Id))]++
+			batch.Put(this.getIndexKey(tagId, article.Id), value)
+		}
+	}
+
+	if err := tax.tagMgr.AddArticleCounts(counts); err != nil {
+		return err
+	}
+
+	// 分段文章数需要加上已有的
+	for key, count := range bucketCounts {
+		count += this.getBucketCount(tax.indexDB, []byte(key))
+		batch.Put([]byte(key), []byte(strconv.FormatUint(count, 10)))
+	}
+
+	return tax.indexDB.Write(batch)
+}
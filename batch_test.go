@@ -0,0 +1,122 @@
+package gmodel
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+)
+
+func TestAddArticles(t *testing.T) {
+	paths := []string{"test_article.db", "test_tag.db", "test_index.db", "test_keyword_tag.db", "test_keyword_index.db"}
+	defer func() {
+		for _, path := range paths {
+			os.RemoveAll(path)
+		}
+	}()
+
+	gmodel := &GModel{}
+	err := gmodel.Open(paths[0], paths[1], paths[2])
+	if err != nil {
+		t.Fatal()
+	}
+	defer gmodel.Close()
+	if err = gmodel.OpenTaxonomy("keyword", paths[3], paths[4]); err != nil {
+		t.Fatal(err)
+	}
+
+	gmodel.AddArticle([]string{"tag1"}, "data_id_1")
+
+	articles := make([]NewArticle, 0)
+	for i := 2; i <= 3000; i++ {
+		articles = append(articles, NewArticle{
+			Tags:  []string{"tag1", "tag" + strconv.Itoa(i%3)},
+			Terms: map[string][]string{"keyword": {"go"}},
+			Data:  "data_id_" + strconv.Itoa(i),
+		})
+	}
+	// 失败的项不影响其他项
+	articles = append(articles, NewArticle{Data: ""}, NewArticle{Terms: map[string][]string{"none": {"a"}}, Data: "x"}, NewArticle{Data: "last"})
+
+	ids, errs := gmodel.AddArticles(articles)
+	if len(ids) != len(articles) || len(errs) != len(articles) {
+		t.Fatal()
+	}
+	for i := 0; i < 2999; i++ {
+		if errs[i] != nil || ids[i] != uint64(i+2) {
+			t.Fatal(i, errs[i])
+		}
+	}
+	if errs[2999] == nil || errs[3000] == nil || ids[2999] != 0 || ids[3000] != 0 {
+		t.Fatal()
+	}
+	if errs[3001] != nil || ids[3001] != 3001 {
+		t.Fatal(errs[3001])
+	}
+
+	if gmodel.GetArticleCount() != 3001 || gmodel.GetMaxArticleId() != 3001 {
+		t.Fatal(gmodel.GetArticleCount())
+	}
+	if gmodel.GetArticleCountByTag("tag1") != 3000 || gmodel.GetArticleCountByTag("tag2") != 1000 {
+		t.Fatal(gmodel.GetArticleCountByTag("tag1"))
+	}
+	if gmodel.GetArticleCountByTag("") != 1 || gmodel.GetArticleCountByTerm("keyword", "go") != 2999 {
+		t.Fatal()
+	}
+
+	article, err := gmodel.GetArticle(1500)
+	if err != nil || article.Data != "data_id_1500" || len(article.Terms["keyword"]) != 1 {
+		t.Fatal(err)
+	}
+
+	// 索引和分段文章数
+	if articles := gmodel.GetNextArticlesByTag("tag2", 0, 2); len(articles) != 2 || articles[0].Id != 2 || articles[1].Id != 5 {
+		t.Fatal()
+	}
+	page, err := gmodel.GetArticlesByTagPage("tag1", 2, 1000, OrderAsc)
+	if err != nil || len(page.Articles) != 1000 || page.Articles[0].Id != 1001 {
+		t.Fatal(err)
+	}
+	page, err = gmodel.GetArticlesByTermPage("keyword", "go", 3, 1000, OrderDesc)
+	if err != nil || len(page.Articles) != 999 || page.Articles[0].Id != 1000 {
+		t.Fatal(err)
+	}
+
+	// 之后逐篇增加的文章ID接着分配
+	if articleId, _ := gmodel.AddArticle([]string{"tag1"}, "data"); articleId != 3002 {
+		t.Fatal(articleId)
+	}
+	if ids, errs = gmodel.AddArticles(nil); len(ids) != 0 || len(errs) != 0 {
+		t.Fatal()
+	}
+
+	// 文章ID冲突时全部失败，不会增加分类
+	ids, errs = gmodel.addArticles([]NewArticle{{Tags: []string{"new_tag"}, Terms: map[string][]string{"keyword": {"new_term"}}, Data: "x"}}, []uint64{1})
+	if !errors.Is(errs[0], ErrExists) || ids[0] != 0 {
+		t.Fatal(errs[0])
+	}
+	if _, err = gmodel.GetTagByName("new_tag"); !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+	if _, err = gmodel.GetTermByName("keyword", "new_term"); !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+
+	// 写入失败时删除新增加的、没有文章的分类，已有的分类保留
+	sequence := gmodel.tagMgr.db.CurrentSequence()
+	gmodel.tagMgr.Add("new_tag")
+	gmodel.tagMgr.deleteEmptyTagsAfter(sequence)
+	if _, err = gmodel.GetTagByName("new_tag"); !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+	if _, err = gmodel.GetTagByName("tag1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 索引写入失败时返回错误，文章已经保存，仍然返回文章ID
+	gmodel.taxonomies["keyword"].indexDB.Close()
+	ids, errs = gmodel.AddArticles([]NewArticle{{Terms: map[string][]string{"keyword": {"go"}}, Data: "x"}})
+	if errs[0] == nil || ids[0] == 0 {
+		t.Fatal(errs[0], ids[0])
+	}
+}
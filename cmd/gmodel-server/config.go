@@ -0,0 +1,364 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gansidui/gmodel"
+	"github.com/gansidui/gmodel/remote"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v2"
+)
+
+// 环境变量的前缀，比如 GMODEL_LISTEN_ADDR 会覆盖配置文件中的 listen_addr
+const envPrefix = "GMODEL_"
+
+// 配置文件，支持 JSON、YAML、TOML，根据扩展名判断格式
+// 字段名在三种格式中都一样，时间使用 "30s"、"1m" 这样的格式
+type Config struct {
+	ArticleDBPath string `json:"article_db_path" yaml:"article_db_path" toml:"article_db_path"`
+	TagDBPath     string `json:"tag_db_path" yaml:"tag_db_path" toml:"tag_db_path"`
+	IndexDBPath   string `json:"index_db_path" yaml:"index_db_path" toml:"index_db_path"`
+	IdDBPath      string `json:"id_db_path" yaml:"id_db_path" toml:"id_db_path"` // 设置了 id_secret 时可以为空
+
+	// 不为空时用密钥把文章ID转换成字符串ID，不需要保存映射，详见 gmodel.IdCodec
+	// 一旦使用就不能修改，可以用 GMODEL_ID_SECRET 环境变量设置
+	IdSecret string `json:"id_secret" yaml:"id_secret" toml:"id_secret"`
+
+	ListenAddr        string `json:"listen_addr" yaml:"listen_addr" toml:"listen_addr"`
+	PublicListenAddr  string `json:"public_listen_addr" yaml:"public_listen_addr" toml:"public_listen_addr"` // 公开的只读API，为空时不开启
+	UseGzip           bool   `json:"use_gzip" yaml:"use_gzip" toml:"use_gzip"`
+	NormalizeTagNames bool   `json:"normalize_tag_names" yaml:"normalize_tag_names" toml:"normalize_tag_names"`
+	CursorSecret      string `json:"cursor_secret" yaml:"cursor_secret" toml:"cursor_secret"`
+	CustomIdPolicy    string `json:"custom_id_policy" yaml:"custom_id_policy" toml:"custom_id_policy"` // remove（默认）、tombstone
+
+	// 自动生成的自定义ID的长度、字符集、冲突重试次数，为空时使用默认值，详见 gmodel.IdGenConfig
+	CustomIdLength     int    `json:"custom_id_length" yaml:"custom_id_length" toml:"custom_id_length"`
+	CustomIdAlphabet   string `json:"custom_id_alphabet" yaml:"custom_id_alphabet" toml:"custom_id_alphabet"`
+	CustomIdMaxRetries int    `json:"custom_id_max_retries" yaml:"custom_id_max_retries" toml:"custom_id_max_retries"`
+
+	ReadTimeout     string `json:"read_timeout" yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout    string `json:"write_timeout" yaml:"write_timeout" toml:"write_timeout"`
+	ShutdownTimeout string `json:"shutdown_timeout" yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+
+	// 公开的只读API的缓存时间
+	PublicCacheMaxAge string `json:"public_cache_max_age" yaml:"public_cache_max_age" toml:"public_cache_max_age"`
+
+	// 签名时间戳允许的误差
+	AuthMaxSkew string `json:"auth_max_skew" yaml:"auth_max_skew" toml:"auth_max_skew"`
+
+	// 鉴权时读取的请求体的最大字节数，超过时返回413
+	AuthMaxBodySize int `json:"auth_max_body_size" yaml:"auth_max_body_size" toml:"auth_max_body_size"`
+
+	// 修改自定义ID之后旧的自定义ID重定向的时间
+	CustomIdRedirectTTL string `json:"custom_id_redirect_ttl" yaml:"custom_id_redirect_ttl" toml:"custom_id_redirect_ttl"`
+
+	// 分类法和密钥不能用环境变量覆盖，但是密钥可以用 secret_env 从环境变量读取
+	Taxonomies []TaxonomyConfig `json:"taxonomies" yaml:"taxonomies" toml:"taxonomies"`
+	APIKeys    []APIKeyConfig   `json:"api_keys" yaml:"api_keys" toml:"api_keys"`
+}
+
+// 访问密钥，详见 remote.APIKeyConfig
+type APIKeyConfig struct {
+	Id          string `json:"id" yaml:"id" toml:"id"`
+	Secret      string `json:"secret" yaml:"secret" toml:"secret"`
+	SecretEnv   string `json:"secret_env" yaml:"secret_env" toml:"secret_env"` // secret 为空时从这个环境变量读取，避免密钥写在配置文件中
+	Role        string `json:"role" yaml:"role" toml:"role"`                   // reader、writer、admin
+	AllowBearer bool   `json:"allow_bearer" yaml:"allow_bearer" toml:"allow_bearer"`
+}
+
+type TaxonomyConfig struct {
+	Name        string `json:"name" yaml:"name" toml:"name"`
+	TagDBPath   string `json:"tag_db_path" yaml:"tag_db_path" toml:"tag_db_path"`
+	IndexDBPath string `json:"index_db_path" yaml:"index_db_path" toml:"index_db_path"`
+}
+
+// 读取配置文件，再用环境变量覆盖，最后检查配置
+// path 为空时只使用环境变量
+func LoadConfig(path string) (*Config, error) {
+	config := &Config{}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("read config [%v] failed: %v", path, err))
+		}
+		if err = parseConfig(path, data, config); err != nil {
+			return nil, errors.New(fmt.Sprintf("parse config [%v] failed: %v", path, err))
+		}
+	}
+
+	if err := config.applyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	for i := range config.APIKeys {
+		if key := &config.APIKeys[i]; key.Secret == "" && key.SecretEnv != "" {
+			key.Secret = os.Getenv(key.SecretEnv)
+		}
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// 未知的字段当成错误，避免字段名写错了没有发现
+func parseConfig(path string, data []byte, config *Config) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		return decoder.Decode(config)
+	case ".yaml", ".yml":
+		return yaml.UnmarshalStrict(data, config)
+	case ".toml":
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		return decoder.Decode(config)
+	}
+	return errors.New("unknown config format, want .json, .yaml, .yml or .toml")
+}
+
+// 用环境变量覆盖配置，环境变量名为前缀加上大写的字段名，比如 GMODEL_USE_GZIP=true
+func (this *Config) applyEnv(lookup func(key string) (string, bool)) error {
+	v := reflect.ValueOf(this).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		name := envPrefix + strings.ToUpper(t.Field(i).Tag.Get("json"))
+		value, ok := lookup(name)
+		if !ok {
+			continue
+		}
+
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(value)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return errors.New(fmt.Sprintf("invalid env %v=%v: want true or false", name, value))
+			}
+			field.SetBool(b)
+		case reflect.Int:
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return errors.New(fmt.Sprintf("invalid env %v=%v: want an integer", name, value))
+			}
+			field.SetInt(int64(n))
+		}
+	}
+	return nil
+}
+
+// 检查配置，返回的错误中带有字段名
+func (this *Config) Validate() error {
+	required := map[string]string{
+		"article_db_path": this.ArticleDBPath,
+		"tag_db_path":     this.TagDBPath,
+		"index_db_path":   this.IndexDBPath,
+		"id_db_path":      this.IdDBPath,
+		"listen_addr":     this.ListenAddr,
+	}
+	for _, name := range []string{"article_db_path", "tag_db_path", "index_db_path", "id_db_path", "listen_addr"} {
+		if name == "id_db_path" && this.IdSecret != "" {
+			continue
+		}
+		if required[name] == "" {
+			return errors.New(fmt.Sprintf("config %v is required", name))
+		}
+	}
+
+	for name, value := range map[string]string{
+		"read_timeout":     this.ReadTimeout,
+		"write_timeout":    this.WriteTimeout,
+		"shutdown_timeout": this.ShutdownTimeout,
+		"auth_max_skew":    this.AuthMaxSkew,
+
+		"custom_id_redirect_ttl": this.CustomIdRedirectTTL,
+
+		"public_cache_max_age": this.PublicCacheMaxAge,
+	} {
+		if _, err := parseDuration(value); err != nil {
+			return errors.New(fmt.Sprintf("config %v: %v", name, err))
+		}
+	}
+
+	switch remote.CustomIdPolicy(this.CustomIdPolicy) {
+	case "", remote.CustomIdRemove, remote.CustomIdTombstone:
+	default:
+		return errors.New(fmt.Sprintf("config custom_id_policy [%v] is invalid, want remove or tombstone", this.CustomIdPolicy))
+	}
+
+	if err := gmodel.CheckIdGenConfig(this.idGenConfig()); err != nil {
+		return errors.New(fmt.Sprintf("config custom_id_*: %v", err))
+	}
+
+	if this.IdSecret != "" {
+		if _, err := gmodel.NewIdCodec([]byte(this.IdSecret), this.CustomIdAlphabet); err != nil {
+			return errors.New(fmt.Sprintf("config id_secret: %v", err))
+		}
+	}
+
+	if this.PublicListenAddr != "" && this.PublicListenAddr == this.ListenAddr {
+		return errors.New("config public_listen_addr must be different from listen_addr")
+	}
+
+	// 每个数据库只能用一次
+	paths := map[string]string{}
+	addPath := func(name, path string) error {
+		path = filepath.Clean(path)
+		if other, exist := paths[path]; exist {
+			return errors.New(fmt.Sprintf("config %v and %v use the same path [%v]", other, name, path))
+		}
+		paths[path] = name
+		return nil
+	}
+	for _, name := range []string{"article_db_path", "tag_db_path", "index_db_path", "id_db_path"} {
+		if required[name] == "" {
+			continue
+		}
+		if err := addPath(name, required[name]); err != nil {
+			return err
+		}
+	}
+
+	names := map[string]bool{}
+	for i, taxonomy := range this.Taxonomies {
+		prefix := fmt.Sprintf("taxonomies[%v]", i)
+		if taxonomy.Name == "" {
+			return errors.New(fmt.Sprintf("config %v.name is required", prefix))
+		}
+		if names[taxonomy.Name] {
+			return errors.New(fmt.Sprintf("config %v.name [%v] is duplicated", prefix, taxonomy.Name))
+		}
+		names[taxonomy.Name] = true
+
+		if taxonomy.TagDBPath == "" || taxonomy.IndexDBPath == "" {
+			return errors.New(fmt.Sprintf("config %v.tag_db_path and index_db_path are required", prefix))
+		}
+		if err := addPath(prefix+".tag_db_path", taxonomy.TagDBPath); err != nil {
+			return err
+		}
+		if err := addPath(prefix+".index_db_path", taxonomy.IndexDBPath); err != nil {
+			return err
+		}
+	}
+
+	keyIds := map[string]bool{}
+	for i, key := range this.APIKeys {
+		prefix := fmt.Sprintf("api_keys[%v]", i)
+		if key.Id == "" {
+			return errors.New(fmt.Sprintf("config %v.id is required", prefix))
+		}
+		if keyIds[key.Id] {
+			return errors.New(fmt.Sprintf("config %v.id [%v] is duplicated", prefix, key.Id))
+		}
+		keyIds[key.Id] = true
+
+		if key.Secret == "" {
+			return errors.New(fmt.Sprintf("config %v.secret is required (or secret_env is not set)", prefix))
+		}
+		switch remote.Role(key.Role) {
+		case remote.RoleReader, remote.RoleWriter, remote.RoleAdmin:
+		default:
+			return errors.New(fmt.Sprintf("config %v.role [%v] is invalid, want reader, writer or admin", prefix, key.Role))
+		}
+	}
+
+	return nil
+}
+
+// 空字符串表示使用默认值
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, errors.New("must not be negative")
+	}
+	return d, nil
+}
+
+func (this *Config) idGenConfig() gmodel.IdGenConfig {
+	return gmodel.IdGenConfig{
+		Length:     this.CustomIdLength,
+		Alphabet:   this.CustomIdAlphabet,
+		MaxRetries: this.CustomIdMaxRetries,
+	}
+}
+
+// 转换成 remote.APIServerConfig，需要先 Validate
+func (this *Config) ServerConfig() *remote.APIServerConfig {
+	config := &remote.APIServerConfig{
+		ArticleDBPath:       this.ArticleDBPath,
+		TagDBPath:           this.TagDBPath,
+		IndexDBPath:         this.IndexDBPath,
+		IdDBPath:            this.IdDBPath,
+		IdSecret:            this.IdSecret,
+		ListeningAddr:       this.ListenAddr,
+		PublicListeningAddr: this.PublicListenAddr,
+		UseGzip:             this.UseGzip,
+		NormalizeTagNames:   this.NormalizeTagNames,
+		CursorSecret:        this.CursorSecret,
+		CustomIdPolicy:      remote.CustomIdPolicy(this.CustomIdPolicy),
+		CustomIdGen:         this.idGenConfig(),
+		AuthMaxBodySize:     int64(this.AuthMaxBodySize),
+	}
+	config.ReadTimeout, _ = parseDuration(this.ReadTimeout)
+	config.WriteTimeout, _ = parseDuration(this.WriteTimeout)
+	config.ShutdownTimeout, _ = parseDuration(this.ShutdownTimeout)
+	config.AuthMaxSkew, _ = parseDuration(this.AuthMaxSkew)
+	config.PublicCacheMaxAge, _ = parseDuration(this.PublicCacheMaxAge)
+	config.CustomIdRedirectTTL, _ = parseDuration(this.CustomIdRedirectTTL)
+
+	for _, taxonomy := range this.Taxonomies {
+		config.Taxonomies = append(config.Taxonomies, remote.TaxonomyConfig{
+			Name:        taxonomy.Name,
+			TagDBPath:   taxonomy.TagDBPath,
+			IndexDBPath: taxonomy.IndexDBPath,
+		})
+	}
+	for _, key := range this.APIKeys {
+		config.APIKeys = append(config.APIKeys, remote.APIKeyConfig{
+			Id:          key.Id,
+			Secret:      key.Secret,
+			Role:        remote.Role(key.Role),
+			AllowBearer: key.AllowBearer,
+		})
+	}
+	return config
+}
+
+// 返回 newConfig 中修改了、但是需要重启才能生效的配置名称
+// 只有 use_gzip、cursor_secret、auth_max_skew、auth_max_body_size、api_keys 可以在运行中修改，详见 remote.APIServer.Reload
+func (this *Config) structuralChanges(newConfig *Config) []string {
+	oldValue := *this
+	newValue := *newConfig
+	oldValue.UseGzip, newValue.UseGzip = false, false
+	oldValue.CursorSecret, newValue.CursorSecret = "", ""
+	oldValue.AuthMaxSkew, newValue.AuthMaxSkew = "", ""
+	oldValue.AuthMaxBodySize, newValue.AuthMaxBodySize = 0, 0
+	oldValue.APIKeys, newValue.APIKeys = nil, nil
+
+	changes := make([]string, 0)
+	v1 := reflect.ValueOf(oldValue)
+	v2 := reflect.ValueOf(newValue)
+	for i := 0; i < v1.NumField(); i++ {
+		if !reflect.DeepEqual(v1.Field(i).Interface(), v2.Field(i).Interface()) {
+			changes = append(changes, v1.Type().Field(i).Tag.Get("json"))
+		}
+	}
+	return changes
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	files := map[string]string{
+		"test_config.json": `{"article_db_path": "article.db", "tag_db_path": "tag.db", "index_db_path": "index.db",
+			"id_db_path": "id.db", "listen_addr": ":8080", "read_timeout": "10s",
+			"taxonomies": [{"name": "keyword", "tag_db_path": "keyword_tag.db", "index_db_path": "keyword_index.db"}]}`,
+		"test_config.yaml": `
+article_db_path: article.db
+tag_db_path: tag.db
+index_db_path: index.db
+id_db_path: id.db
+listen_addr: ":8080"
+read_timeout: 10s
+taxonomies:
+  - name: keyword
+    tag_db_path: keyword_tag.db
+    index_db_path: keyword_index.db
+`,
+		"test_config.toml": `
+article_db_path = "article.db"
+tag_db_path = "tag.db"
+index_db_path = "index.db"
+id_db_path = "id.db"
+listen_addr = ":8080"
+read_timeout = "10s"
+
+[[taxonomies]]
+name = "keyword"
+tag_db_path = "keyword_tag.db"
+index_db_path = "keyword_index.db"
+`,
+	}
+
+	for path, content := range files {
+		os.WriteFile(path, []byte(content), 0644)
+		defer os.Remove(path)
+
+		config, err := LoadConfig(path)
+		if err != nil {
+			t.Fatal(path, err)
+		}
+		serverConfig := config.ServerConfig()
+		if serverConfig.ListeningAddr != ":8080" || serverConfig.ReadTimeout != 10*time.Second ||
+			len(serverConfig.Taxonomies) != 1 || serverConfig.Taxonomies[0].IndexDBPath != "keyword_index.db" {
+			t.Fatal(path)
+		}
+	}
+
+	// 环境变量覆盖
+	os.Setenv("GMODEL_LISTEN_ADDR", ":9090")
+	os.Setenv("GMODEL_USE_GZIP", "true")
+	os.Setenv("GMODEL_CUSTOM_ID_LENGTH", "12")
+	config, err := LoadConfig("test_config.json")
+	if err != nil || config.ListenAddr != ":9090" || !config.UseGzip || config.ServerConfig().CustomIdGen.Length != 12 {
+		t.Fatal(err)
+	}
+	os.Setenv("GMODEL_USE_GZIP", "yes")
+	if _, err = LoadConfig("test_config.json"); err == nil || !strings.Contains(err.Error(), "GMODEL_USE_GZIP") {
+		t.Fatal(err)
+	}
+	os.Setenv("GMODEL_USE_GZIP", "true")
+	os.Setenv("GMODEL_CUSTOM_ID_LENGTH", "long")
+	if _, err = LoadConfig("test_config.json"); err == nil || !strings.Contains(err.Error(), "GMODEL_CUSTOM_ID_LENGTH") {
+		t.Fatal(err)
+	}
+	os.Unsetenv("GMODEL_LISTEN_ADDR")
+	os.Unsetenv("GMODEL_USE_GZIP")
+	os.Unsetenv("GMODEL_CUSTOM_ID_LENGTH")
+
+	// 只有 use_gzip 和 cursor_secret 可以在运行中修改
+	newConfig := *config
+	newConfig.UseGzip = false
+	newConfig.CursorSecret = "secret"
+	newConfig.AuthMaxBodySize = 1 << 20
+	if changes := config.structuralChanges(&newConfig); len(changes) != 0 {
+		t.Fatal(changes)
+	}
+	newConfig.ListenAddr = ":8081"
+	if changes := config.structuralChanges(&newConfig); len(changes) != 1 || changes[0] != "listen_addr" {
+		t.Fatal(changes)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	valid := Config{
+		ArticleDBPath: "article.db",
+		TagDBPath:     "tag.db",
+		IndexDBPath:   "index.db",
+		IdDBPath:      "id.db",
+		ListenAddr:    ":8080",
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := map[string]func(config *Config){
+		"id_db_path is required":    func(config *Config) { config.IdDBPath = "" },
+		"read_timeout":              func(config *Config) { config.ReadTimeout = "10" },
+		"use the same path":         func(config *Config) { config.TagDBPath = "./article.db" },
+		"taxonomies[0].name":        func(config *Config) { config.Taxonomies = []TaxonomyConfig{{TagDBPath: "a", IndexDBPath: "b"}} },
+		"taxonomies[1].name [k] is": func(config *Config) { config.Taxonomies = []TaxonomyConfig{{"k", "a", "b"}, {"k", "c", "d"}} },
+		"api_keys[0].secret": func(config *Config) {
+			config.APIKeys = []APIKeyConfig{{Id: "k", SecretEnv: "GMODEL_NOT_SET", Role: "admin"}}
+		},
+		"api_keys[0].role [root]":  func(config *Config) { config.APIKeys = []APIKeyConfig{{Id: "k", Secret: "s", Role: "root"}} },
+		"custom_id_policy [keep]":  func(config *Config) { config.CustomIdPolicy = "keep" },
+		"Length [2]":               func(config *Config) { config.CustomIdLength = 2 },
+		"duplicated character [a]": func(config *Config) { config.CustomIdAlphabet = "abca" },
+		"id_secret":                func(config *Config) { config.IdSecret = "short" },
+	}
+	for message, modify := range invalid {
+		config := valid
+		modify(&config)
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), message) {
+			t.Fatal(message, err)
+		}
+	}
+
+	// 设置了 id_secret 时可以没有 id_db_path
+	stateless := valid
+	stateless.IdDBPath = ""
+	stateless.IdSecret = "0123456789abcdef"
+	if err := stateless.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if config := stateless.ServerConfig(); config.IdSecret != stateless.IdSecret || config.IdDBPath != "" {
+		t.Fatal(config)
+	}
+
+	if _, err := LoadConfig("test_config.ini"); err == nil {
+		t.Fatal()
+	}
+	os.WriteFile("test_config.json", []byte(`{"listen": ":8080"}`), 0644)
+	defer os.Remove("test_config.json")
+	if _, err := LoadConfig("test_config.json"); err == nil || !strings.Contains(err.Error(), "listen") {
+		t.Fatal(err)
+	}
+}
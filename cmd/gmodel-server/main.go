@@ -0,0 +1,125 @@
+// gmodel-server 独立运行的API服务器
+//
+//	gmodel-server -config gmodel.yaml
+//
+// 配置文件支持 JSON、YAML、TOML，每个配置都可以用环境变量覆盖，比如 GMODEL_LISTEN_ADDR=:8080
+// 收到 SIGHUP 时重新读取配置，只有 use_gzip、cursor_secret、auth_max_skew、api_keys 马上生效，其他配置需要重启
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/gansidui/gmodel/remote"
+)
+
+func main() {
+	configPath := flag.String("config", "", "config file (.json, .yaml, .yml or .toml), env only when empty")
+	check := flag.Bool("check", false, "validate the config and exit")
+	flag.Parse()
+
+	config, err := LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gmodel-server:", err)
+		os.Exit(1)
+	}
+	if *check {
+		fmt.Println("config ok")
+		return
+	}
+
+	if err = run(*configPath, config); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// 收到 SIGINT、SIGTERM 时等待正在处理的请求完成后退出
+func run(configPath string, config *Config) error {
+	server, err := remote.NewAPIServer(config.ServerConfig())
+	if err != nil {
+		return err
+	}
+	defer server.Close()
+
+	listener, err := net.Listen("tcp", config.ListenAddr)
+	if err != nil {
+		return err
+	}
+	log.Println("start listening:", config.ListenAddr)
+
+	var publicListener net.Listener
+	if config.PublicListenAddr != "" {
+		if publicListener, err = net.Listen("tcp", config.PublicListenAddr); err != nil {
+			listener.Close()
+			return err
+		}
+		log.Println("start public listening:", config.PublicListenAddr)
+	}
+
+	go reloadOnSignal(server, configPath, config)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// 任何一个出错都退出，关闭数据库之前等两个都退出
+	errs := make(chan error, 2)
+	count := 1
+	go func() {
+		errs <- server.Serve(ctx, listener)
+	}()
+	if publicListener != nil {
+		count++
+		go func() {
+			errs <- server.ServePublic(ctx, publicListener)
+		}()
+	}
+
+	for i := 0; i < count; i++ {
+		if serveErr := <-errs; serveErr != nil && err == nil {
+			err = serveErr
+			stop()
+		}
+	}
+	if err != nil {
+		return err
+	}
+	log.Println("server exiting")
+	return nil
+}
+
+// 收到 SIGHUP 时重新读取配置，配置有错误时保持原来的配置
+func reloadOnSignal(server *remote.APIServer, configPath string, config *Config) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	for range hup {
+		newConfig, err := LoadConfig(configPath)
+		if err != nil {
+			log.Println("reload config failed:", err)
+			continue
+		}
+
+		if changes := config.structuralChanges(newConfig); len(changes) > 0 {
+			log.Println("reload config: need restart to apply", changes)
+		}
+
+		reloaded := *config
+		reloaded.UseGzip = newConfig.UseGzip
+		reloaded.CursorSecret = newConfig.CursorSecret
+		reloaded.AuthMaxSkew = newConfig.AuthMaxSkew
+		reloaded.AuthMaxBodySize = newConfig.AuthMaxBodySize
+		reloaded.APIKeys = newConfig.APIKeys
+		if err = server.Reload(reloaded.ServerConfig()); err != nil {
+			log.Println("reload config failed:", err)
+			continue
+		}
+		*config = reloaded
+		log.Println("reload config success")
+	}
+}
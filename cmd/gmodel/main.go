@@ -0,0 +1,209 @@
+// gmodel 命令行工具，直接操作 leveldb 数据库目录（使用时服务器需要先停止，leveldb 不允许多个进程同时打开）
+//
+// 导出：
+//
+//	gmodel export -article article.db -tag tag.db -index index.db -id id.db -o articles.jsonl
+//
+// 导入：
+//
+//	gmodel import -article article.db -tag tag.db -index index.db -id id.db -keep-ids -i articles.jsonl
+//
+// 其他分类法使用 -taxonomy 指定，可以有多个，格式为 名称=分类数据库,索引数据库，比如：
+//
+//	-taxonomy keyword=keyword_tag.db,keyword_index.db
+//
+// 把保存的ID映射迁移到 gmodel.IdCodec（密钥从环境变量读取，默认为 GMODEL_ID_SECRET），旧的字符串ID会重定向到新的：
+//
+//	GMODEL_ID_SECRET=... gmodel migrate-ids -id id.db -redirect-ttl 720h
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/gansidui/gmodel"
+	"github.com/gansidui/gmodel/cmd/internal/cmdflag"
+)
+
+// 数据库相关的参数，export 和 import 共用
+type dbFlags struct {
+	articleDBPath string
+	tagDBPath     string
+	indexDBPath   string
+	idDBPath      string
+	normalize     bool
+	taxonomies    cmdflag.Taxonomies
+}
+
+func (this *dbFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&this.articleDBPath, "article", "", "article db path (required)")
+	fs.StringVar(&this.tagDBPath, "tag", "", "tag db path (required)")
+	fs.StringVar(&this.indexDBPath, "index", "", "index db path (required)")
+	fs.StringVar(&this.idDBPath, "id", "", "id db path, export/import custom ids when set")
+	fs.BoolVar(&this.normalize, "normalize", false, "use the default tag normalizer")
+	fs.Var(&this.taxonomies, "taxonomy", "taxonomy as name=tagdb,indexdb, can be repeated")
+}
+
+// 打开数据库，idMgr 在没有指定 -id 时为nil
+func (this *dbFlags) open() (*gmodel.GModel, *gmodel.IdMgr, error) {
+	if this.articleDBPath == "" || this.tagDBPath == "" || this.indexDBPath == "" {
+		return nil, nil, errors.New("-article, -tag and -index are required")
+	}
+
+	model := &gmodel.GModel{}
+	if err := model.Open(this.articleDBPath, this.tagDBPath, this.indexDBPath); err != nil {
+		return nil, nil, err
+	}
+	if this.normalize {
+		model.SetTagNormalizer(gmodel.DefaultTagNormalizer)
+	}
+	if err := this.taxonomies.Open(model); err != nil {
+		model.Close()
+		return nil, nil, err
+	}
+
+	if this.idDBPath == "" {
+		return model, nil, nil
+	}
+	idMgr := &gmodel.IdMgr{}
+	if err := idMgr.Open(this.idDBPath); err != nil {
+		model.Close()
+		return nil, nil, err
+	}
+	return model, idMgr, nil
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: gmodel <command> [flags]\n\n")
+	fmt.Fprintf(os.Stderr, "Commands:\n")
+	fmt.Fprintf(os.Stderr, "  export       export articles to JSON Lines\n")
+	fmt.Fprintf(os.Stderr, "  import       import articles from JSON Lines\n")
+	fmt.Fprintf(os.Stderr, "  migrate-ids  migrate stored id mappings to the id codec\n\n")
+	fmt.Fprintf(os.Stderr, "Run 'gmodel <command> -h' for the flags of a command.\n")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "export":
+		err = runExport(os.Args[2:])
+	case "import":
+		err = runImport(os.Args[2:])
+	case "migrate-ids":
+		err = runMigrateIds(os.Args[2:])
+	case "-h", "-help", "--help", "help":
+		usage()
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "gmodel: unknown command %q\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	var db dbFlags
+	db.register(fs)
+	output := fs.String("o", "", "output file, default stdout")
+	fs.Parse(args)
+
+	model, idMgr, err := db.open()
+	if err != nil {
+		return err
+	}
+	defer model.Close()
+	if idMgr != nil {
+		defer idMgr.Close()
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	count, err := model.Export(w, idMgr)
+	log.Printf("export %v articles\n", count)
+	return err
+}
+
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	var db dbFlags
+	db.register(fs)
+	input := fs.String("i", "", "input file, default stdin")
+	keepIds := fs.Bool("keep-ids", false, "keep the original article ids")
+	fs.Parse(args)
+
+	model, idMgr, err := db.open()
+	if err != nil {
+		return err
+	}
+	defer model.Close()
+	if idMgr != nil {
+		defer idMgr.Close()
+	}
+
+	var r io.Reader = os.Stdin
+	if *input != "" {
+		f, err := os.Open(*input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	count, err := model.Import(r, idMgr, *keepIds)
+	log.Printf("import %v articles\n", count)
+	return err
+}
+
+func runMigrateIds(args []string) error {
+	fs := flag.NewFlagSet("migrate-ids", flag.ExitOnError)
+	idDBPath := fs.String("id", "", "id db path (required)")
+	secretEnv := fs.String("secret-env", "GMODEL_ID_SECRET", "environment variable of the id secret")
+	alphabet := fs.String("alphabet", "", "alphabet of the id codec, default gmodel.DefaultIdAlphabet")
+	redirectTTL := fs.Duration("redirect-ttl", 0, "redirect old string ids for this long, default gmodel.DefaultIdRedirectTTL, negative for no redirect")
+	fs.Parse(args)
+
+	if *idDBPath == "" {
+		return errors.New("-id is required")
+	}
+	codec, err := gmodel.NewIdCodec([]byte(os.Getenv(*secretEnv)), *alphabet)
+	if err != nil {
+		return errors.New(fmt.Sprintf("%v: %v", *secretEnv, err))
+	}
+
+	idMgr := &gmodel.IdMgr{}
+	if err := idMgr.Open(*idDBPath); err != nil {
+		return err
+	}
+	defer idMgr.Close()
+	idMgr.SetCodec(codec)
+	if *redirectTTL != 0 {
+		idMgr.SetRedirectTTL(*redirectTTL)
+	}
+
+	count, err := idMgr.MigrateToCodec()
+	log.Printf("migrate %v ids\n", count)
+	return err
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gansidui/gmodel"
+	"github.com/gansidui/gmodel/remote"
+)
+
+// 模型的基本信息
+type modelInfo struct {
+	ArticleCount uint64   `json:"article_count"`
+	TagCount     uint64   `json:"tag_count"`
+	MaxArticleId uint64   `json:"max_article_id"`
+	Taxonomies   []string `json:"taxonomies,omitempty"` // 只有直接打开数据库时才有
+	IdCount      uint64   `json:"id_count,omitempty"`   // 只有直接打开数据库时才有
+}
+
+// gmodelctl 的操作，可以直接打开数据库目录（localBackend），也可以通过 remote.APIClient 访问服务器（remoteBackend）
+// 文章的操作都使用 remote.Model，这样两种方式的输出是一样的
+type backend interface {
+	remote.Model
+	Info() (*modelInfo, error)
+	Close() error
+}
+
+type localBackend struct {
+	*remote.LocalModel
+	model *gmodel.GModel
+	idMgr *gmodel.IdMgr
+}
+
+func newLocalBackend(model *gmodel.GModel, idMgr *gmodel.IdMgr) *localBackend {
+	return &localBackend{
+		LocalModel: remote.NewLocalModel(model, idMgr),
+		model:      model,
+		idMgr:      idMgr,
+	}
+}
+
+func (this *localBackend) Close() error {
+	this.model.Close()
+	return this.idMgr.Close()
+}
+
+func (this *localBackend) Info() (*modelInfo, error) {
+	return &modelInfo{
+		ArticleCount: this.model.GetArticleCount(),
+		TagCount:     this.model.GetTagCount(),
+		MaxArticleId: this.model.GetMaxArticleId(),
+		Taxonomies:   this.model.GetTaxonomyNames(),
+		IdCount:      this.idMgr.Count(),
+	}, nil
+}
+
+type remoteBackend struct {
+	*remote.APIClient
+}
+
+func (this *remoteBackend) Close() error {
+	return nil
+}
+
+func (this *remoteBackend) Info() (*modelInfo, error) {
+	articleCount, tagCount, maxArticleId, err := this.GetModelInfo()
+	if err != nil {
+		return nil, err
+	}
+	return &modelInfo{
+		ArticleCount: articleCount,
+		TagCount:     tagCount,
+		MaxArticleId: maxArticleId,
+	}, nil
+}
+
+func parseOrder(order string) (gmodel.Order, error) {
+	switch order {
+	case "", "desc":
+		return gmodel.OrderDesc, nil
+	case "asc":
+		return gmodel.OrderAsc, nil
+	}
+	return gmodel.OrderDesc, errors.New(fmt.Sprintf("invalid order [%v], want asc or desc", order))
+}
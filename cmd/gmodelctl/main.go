@@ -0,0 +1,515 @@
+// gmodelctl 管理工具，可以直接打开数据库目录（服务器需要先停止），也可以用 -remote 访问正在运行的服务器
+//
+//	gmodelctl -article article.db -tag tag.db -index index.db -id id.db info
+//	gmodelctl -remote http://127.0.0.1:8080 get 1
+//	gmodelctl -remote http://127.0.0.1:8080 -json list-by-tag -n 10 golang
+//
+// 其他分类法使用 -taxonomy 指定，可以有多个，格式为 名称=分类数据库,索引数据库
+// 加上 -json 时输出 JSON，方便脚本处理
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gansidui/gmodel"
+	"github.com/gansidui/gmodel/cmd/internal/cmdflag"
+	"github.com/gansidui/gmodel/remote"
+)
+
+var (
+	remoteAddr    = flag.String("remote", "", "server address with scheme, e.g. http://127.0.0.1:8080; open the db directories when empty")
+	articleDBPath = flag.String("article", "", "article db path")
+	tagDBPath     = flag.String("tag", "", "tag db path")
+	indexDBPath   = flag.String("index", "", "index db path")
+	idDBPath      = flag.String("id", "", "id db path, can be empty when -id-secret is set")
+	idSecret      = flag.String("id-secret", os.Getenv("GMODEL_ID_SECRET"), "secret of the id codec, same as the server's id_secret, default $GMODEL_ID_SECRET")
+	idAlphabet    = flag.String("id-alphabet", "", "alphabet of the id codec, same as the server's custom_id_alphabet")
+	normalize     = flag.Bool("normalize", false, "use the default tag normalizer")
+	jsonOutput    = flag.Bool("json", false, "output JSON")
+	verbose       = flag.Bool("v", false, "show logs of opening and closing the dbs")
+	keyId         = flag.String("key-id", "", "api key id for -remote")
+	keySecret     = flag.String("key-secret", os.Getenv("GMODEL_KEY_SECRET"), "api key secret for -remote, default $GMODEL_KEY_SECRET")
+	timeout       = flag.Duration("timeout", remote.DefaultClientTimeout, "request timeout for -remote")
+	taxonomies    cmdflag.Taxonomies
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []*command{
+	{"info", "show article count, tag count and max article id", runInfo},
+	{"get", "get an article: get [-custom-id id] [article-id]", runGet},
+	{"list-by-tag", "list articles by tag: list-by-tag [-taxonomy name] [-cursor c] [-n 20] [-order desc|asc] <tag>", runListByTag},
+	{"add", "add an article: add [-tags a,b] [-term name=a,b] [-custom-id id] (-data text | -data-file file)", runAdd},
+	{"update", "update an article, unset fields are kept: update [-custom-id id] [-tags a,b] [-term name=a,b] [-data text | -data-file file] [article-id]", runUpdate},
+	{"delete", "delete an article: delete [-custom-id id] [article-id]", runDelete},
+	{"rename-tag", "rename a tag: rename-tag <old-name> <new-name>", runRenameTag},
+	{"lookup", "map between article id and custom id: lookup <custom-id> | lookup -id <article-id>", runLookup},
+	{"dump", "dump raw keys of a db directory (local only): dump [-prefix p] [-n 100] <db-path>", runDump},
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: gmodelctl [flags] <command> [args]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-12v %v\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Var(&taxonomies, "taxonomy", "taxonomy as name=tagdb,indexdb, can be repeated")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == flag.Arg(0) {
+			if err := cmd.run(flag.Args()[1:]); err != nil {
+				fmt.Fprintln(os.Stderr, "gmodelctl:", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "gmodelctl: unknown command %q\n\n", flag.Arg(0))
+	usage()
+	os.Exit(2)
+}
+
+// 根据全局参数打开数据库或者连接服务器
+func openBackend() (backend, error) {
+	if *remoteAddr != "" {
+		opts := &remote.APIClientOptions{Timeout: *timeout}
+		if *keyId != "" {
+			opts.KeyId, opts.KeySecret = *keyId, *keySecret
+		}
+		return &remoteBackend{remote.NewAPIClient(*remoteAddr, opts)}, nil
+	}
+
+	if *articleDBPath == "" || *tagDBPath == "" || *indexDBPath == "" || (*idDBPath == "" && *idSecret == "") {
+		return nil, errors.New("-remote or -article, -tag, -index and -id (or -id-secret) are required")
+	}
+
+	var codec *gmodel.IdCodec
+	if *idSecret != "" {
+		var err error
+		if codec, err = gmodel.NewIdCodec([]byte(*idSecret), *idAlphabet); err != nil {
+			return nil, err
+		}
+	}
+
+	model := &gmodel.GModel{}
+	if err := model.Open(*articleDBPath, *tagDBPath, *indexDBPath); err != nil {
+		return nil, err
+	}
+	if *normalize {
+		model.SetTagNormalizer(gmodel.DefaultTagNormalizer)
+	}
+	if err := taxonomies.Open(model); err != nil {
+		model.Close()
+		return nil, err
+	}
+
+	idMgr := &gmodel.IdMgr{}
+	if *idDBPath == "" {
+		if err := idMgr.OpenStateless(codec); err != nil {
+			model.Close()
+			return nil, err
+		}
+		return newLocalBackend(model, idMgr), nil
+	}
+	if err := idMgr.Open(*idDBPath); err != nil {
+		model.Close()
+		return nil, err
+	}
+	if codec != nil {
+		idMgr.SetCodec(codec)
+	}
+	return newLocalBackend(model, idMgr), nil
+}
+
+// 打开后端执行 f，执行完关闭
+func withBackend(f func(b backend) error) error {
+	b, err := openBackend()
+	if err != nil {
+		return err
+	}
+	defer b.Close()
+
+	return f(b)
+}
+
+func runInfo(args []string) error {
+	return withBackend(func(b backend) error {
+		info, err := b.Info()
+		if err != nil {
+			return err
+		}
+		if *jsonOutput {
+			return printJSON(info)
+		}
+
+		fmt.Printf("article_count: %v\n", info.ArticleCount)
+		fmt.Printf("tag_count: %v\n", info.TagCount)
+		fmt.Printf("max_article_id: %v\n", info.MaxArticleId)
+		if *remoteAddr == "" {
+			fmt.Printf("taxonomies: %v\n", strings.Join(info.Taxonomies, ","))
+			fmt.Printf("id_count: %v\n", info.IdCount)
+		}
+		return nil
+	})
+}
+
+func runGet(args []string) error {
+	fs := flag.NewFlagSet("get", flag.ExitOnError)
+	customId := fs.String("custom-id", "", "custom article id")
+	fs.Parse(args)
+
+	articleId, err := parseArticleId(fs, *customId)
+	if err != nil {
+		return err
+	}
+
+	return withBackend(func(b backend) error {
+		article, err := b.GetArticleContext(context.Background(), articleId, *customId)
+		if err != nil {
+			return err
+		}
+		if *jsonOutput {
+			return printJSON(article)
+		}
+
+		fmt.Printf("id: %v\n", article.Id)
+		fmt.Printf("custom_id: %v\n", article.CustomArticleId)
+		fmt.Printf("tags: %v\n", strings.Join(article.TagNameArray, ","))
+		for taxonomy, names := range article.TermNames {
+			fmt.Printf("terms.%v: %v\n", taxonomy, strings.Join(names, ","))
+		}
+		fmt.Printf("data: %v\n", article.Data)
+		return nil
+	})
+}
+
+func runListByTag(args []string) error {
+	fs := flag.NewFlagSet("list-by-tag", flag.ExitOnError)
+	taxonomy := fs.String("taxonomy", "", "taxonomy name, default tags when empty")
+	cursor := fs.String("cursor", "", "cursor from the previous output, first page when empty")
+	n := fs.Int("n", 20, "articles per page")
+	order := fs.String("order", "desc", "desc (newest first) or asc")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return errors.New("list-by-tag needs exactly one tag")
+	}
+
+	if _, err := parseOrder(*order); err != nil {
+		return err
+	}
+
+	return withBackend(func(b backend) error {
+		page, err := b.ListArticlesByTagContext(context.Background(), *taxonomy, fs.Arg(0), *cursor, *n, *order)
+		if err != nil {
+			return err
+		}
+		if *jsonOutput {
+			return printJSON(page)
+		}
+
+		// 每行一篇：文章ID、自定义ID、分类、数据
+		for _, article := range page.Items {
+			fmt.Printf("%v\t%v\t%v\t%v\n", article.Id, article.CustomArticleId, strings.Join(article.TagNameArray, ","), article.Data)
+		}
+		if page.NextCursor != "" {
+			fmt.Printf("next_cursor: %v\n", page.NextCursor)
+		}
+		if page.PrevCursor != "" {
+			fmt.Printf("prev_cursor: %v\n", page.PrevCursor)
+		}
+		return nil
+	})
+}
+
+// add 和 update 共用的文章参数
+type articleFlags struct {
+	fs       *flag.FlagSet
+	tags     *string
+	terms    termFlags
+	data     *string
+	dataFile *string
+	customId *string
+}
+
+func newArticleFlags(name string) *articleFlags {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	this := &articleFlags{
+		fs:       fs,
+		tags:     fs.String("tags", "", "comma separated tag names"),
+		data:     fs.String("data", "", "article data"),
+		dataFile: fs.String("data-file", "", "read article data from file, - for stdin"),
+		customId: fs.String("custom-id", "", "custom article id"),
+	}
+	fs.Var(&this.terms, "term", "terms of a taxonomy as name=a,b, can be repeated")
+	return this
+}
+
+// 判断参数是否设置过，用于 update 时保留没有设置的字段
+func (this *articleFlags) isSet(name string) bool {
+	set := false
+	this.fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func (this *articleFlags) getTags() []string {
+	return splitNames(*this.tags)
+}
+
+func (this *articleFlags) getData() (string, error) {
+	if *this.dataFile == "" {
+		return *this.data, nil
+	}
+
+	var data []byte
+	var err error
+	if *this.dataFile == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(*this.dataFile)
+	}
+	return string(data), err
+}
+
+func runAdd(args []string) error {
+	flags := newArticleFlags("add")
+	flags.fs.Parse(args)
+
+	data, err := flags.getData()
+	if err != nil {
+		return err
+	}
+
+	return withBackend(func(b backend) error {
+		articleId, customId, err := b.AddArticleContext(context.Background(), flags.getTags(), flags.terms, data, *flags.customId)
+		if err != nil {
+			return err
+		}
+		return printIds(articleId, customId)
+	})
+}
+
+func runUpdate(args []string) error {
+	flags := newArticleFlags("update")
+	flags.fs.Parse(args)
+
+	articleId, err := parseArticleId(flags.fs, *flags.customId)
+	if err != nil {
+		return err
+	}
+	data, err := flags.getData()
+	if err != nil {
+		return err
+	}
+
+	return withBackend(func(b backend) error {
+		// 没有设置的字段保持不变
+		article, err := b.GetArticleContext(context.Background(), articleId, *flags.customId)
+		if err != nil {
+			return err
+		}
+
+		tags := article.TagNameArray
+		if flags.isSet("tags") {
+			tags = flags.getTags()
+		}
+		if !flags.isSet("data") && !flags.isSet("data-file") {
+			data = article.Data
+		}
+
+		return b.UpdateArticleContext(context.Background(), article.Id, "", tags, flags.terms, data)
+	})
+}
+
+func runDelete(args []string) error {
+	fs := flag.NewFlagSet("delete", flag.ExitOnError)
+	customId := fs.String("custom-id", "", "custom article id")
+	fs.Parse(args)
+
+	articleId, err := parseArticleId(fs, *customId)
+	if err != nil {
+		return err
+	}
+
+	return withBackend(func(b backend) error {
+		return b.DeleteArticleContext(context.Background(), articleId, *customId)
+	})
+}
+
+func runRenameTag(args []string) error {
+	if len(args) != 2 {
+		return errors.New("rename-tag needs <old-name> <new-name>")
+	}
+
+	return withBackend(func(b backend) error {
+		return b.RenameTagContext(context.Background(), args[0], args[1])
+	})
+}
+
+func runLookup(args []string) error {
+	fs := flag.NewFlagSet("lookup", flag.ExitOnError)
+	articleId := fs.Uint64("id", 0, "look up the custom id of an article id")
+	fs.Parse(args)
+
+	customId := ""
+	if *articleId == 0 {
+		if fs.NArg() != 1 {
+			return errors.New("lookup needs a custom id or -id")
+		}
+		customId = fs.Arg(0)
+	}
+
+	// 只查ID映射，不需要文章存在；修改过的旧自定义ID返回当前的自定义ID
+	return withBackend(func(b backend) error {
+		ctx := context.Background()
+		if customId != "" {
+			id, customId, err := b.ResolveCustomIdContext(ctx, customId)
+			if err != nil {
+				return err
+			}
+			return printIds(id, customId)
+		}
+
+		customId, err := b.GetCustomIdContext(ctx, *articleId)
+		if err != nil {
+			return err
+		}
+		return printIds(*articleId, customId)
+	})
+}
+
+// 直接遍历 leveldb 数据库，不需要打开整个模型，用于排查问题
+func runDump(args []string) error {
+	fs := flag.NewFlagSet("dump", flag.ExitOnError)
+	prefix := fs.String("prefix", "", "only dump keys with this prefix")
+	n := fs.Int("n", 100, "max keys to dump, 0 for all")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return errors.New("dump needs exactly one db path")
+	}
+	if *remoteAddr != "" {
+		return errors.New("dump only works on local db directories")
+	}
+
+	db := &gmodel.KVStore{}
+	if err := db.Open(fs.Arg(0)); err != nil {
+		return err
+	}
+	defer db.Close()
+
+	type item struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetEscapeHTML(false)
+
+	count := 0
+	var err error
+	db.Scan([]byte(*prefix), func(key, value []byte) bool {
+		if *jsonOutput {
+			err = encoder.Encode(&item{Key: string(key), Value: string(value)})
+		} else {
+			_, err = fmt.Printf("%s\t%s\n", key, value)
+		}
+		count++
+		return err == nil && (*n <= 0 || count < *n)
+	})
+	return err
+}
+
+// 没有 -custom-id 时，第一个参数为文章ID
+func parseArticleId(fs *flag.FlagSet, customId string) (uint64, error) {
+	if customId != "" {
+		return 0, nil
+	}
+	if fs.NArg() != 1 {
+		return 0, errors.New(fs.Name() + " needs an article id or -custom-id")
+	}
+	return strconv.ParseUint(fs.Arg(0), 10, 64)
+}
+
+func printIds(articleId uint64, customId string) error {
+	if *jsonOutput {
+		return printJSON(map[string]interface{}{
+			"article_id":        articleId,
+			"custom_article_id": customId,
+		})
+	}
+	fmt.Printf("%v\t%v\n", articleId, customId)
+	return nil
+}
+
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func splitNames(s string) []string {
+	names := make([]string, 0)
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// 其他分类法下的分类，-term keyword=go,leveldb
+type termFlags map[string][]string
+
+func (this *termFlags) String() string {
+	items := make([]string, 0, len(*this))
+	for name, terms := range *this {
+		items = append(items, name+"="+strings.Join(terms, ","))
+	}
+	return strings.Join(items, " ")
+}
+
+func (this *termFlags) Set(value string) error {
+	name, terms, ok := strings.Cut(value, "=")
+	if !ok || name == "" {
+		return errors.New(fmt.Sprintf("invalid term [%v], want name=a,b", value))
+	}
+
+	if *this == nil {
+		*this = make(termFlags)
+	}
+	(*this)[name] = splitNames(terms)
+	return nil
+}
@@ -0,0 +1,52 @@
+// cmdflag 命令行工具（gmodel、gmodelctl）共用的参数
+package cmdflag
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gansidui/gmodel"
+)
+
+// 其他分类法，格式为 名称=分类数据库,索引数据库，比如 -taxonomy keyword=keyword_tag.db,keyword_index.db
+type Taxonomy struct {
+	Name        string
+	TagDBPath   string
+	IndexDBPath string
+}
+
+// 可以重复指定的 -taxonomy，实现了 flag.Value
+type Taxonomies []Taxonomy
+
+func (this *Taxonomies) String() string {
+	items := make([]string, 0, len(*this))
+	for _, taxonomy := range *this {
+		items = append(items, taxonomy.Name+"="+taxonomy.TagDBPath+","+taxonomy.IndexDBPath)
+	}
+	return strings.Join(items, " ")
+}
+
+func (this *Taxonomies) Set(value string) error {
+	name, paths, ok := strings.Cut(value, "=")
+	if !ok {
+		return errors.New(fmt.Sprintf("invalid taxonomy [%v], want name=tagdb,indexdb", value))
+	}
+	tagDBPath, indexDBPath, ok := strings.Cut(paths, ",")
+	if !ok || name == "" || tagDBPath == "" || indexDBPath == "" {
+		return errors.New(fmt.Sprintf("invalid taxonomy [%v], want name=tagdb,indexdb", value))
+	}
+
+	*this = append(*this, Taxonomy{Name: name, TagDBPath: tagDBPath, IndexDBPath: indexDBPath})
+	return nil
+}
+
+// 打开全部分类法，失败时不关闭 model，由调用者关闭
+func (this Taxonomies) Open(model *gmodel.GModel) error {
+	for _, taxonomy := range this {
+		if err := model.OpenTaxonomy(taxonomy.Name, taxonomy.TagDBPath, taxonomy.IndexDBPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
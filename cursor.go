@@ -0,0 +1,176 @@
+package gmodel
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"sync"
+)
+
+// 游标分页
+// 以前的 Next/Prev 接口直接用文章ID作为游标，调用方需要知道 "ID大于最大ID表示从最新开始" 之类的规则，
+// 现在统一返回 Page，下一页、上一页只需要把 NextCursor、PrevCursor 原样传回来即可
+// 游标是不透明的字符串，带有签名，被篡改或者用在其他列表上都会返回 ErrInvalidCursor
+
+// 一页数据
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor"` // 为空表示没有下一页
+	PrevCursor string `json:"prev_cursor"` // 为空表示没有上一页
+}
+
+// 可以用 errors.Is(err, ErrInvalidArgument) 判断
+var ErrInvalidCursor = newError(ErrInvalidArgument, "Invalid cursor")
+
+// 签名的长度，截取 HMAC-SHA256 的前16个字节
+const cursorMacSize = 16
+
+// 游标签名的密钥，每个 GModel 一个，默认打开时随机生成，所以重启之后旧的游标会失效
+type cursorSigner struct {
+	secret []byte
+	mutex  sync.RWMutex
+}
+
+func newCursorSigner() *cursorSigner {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	return &cursorSigner{secret: secret}
+}
+
+// 设置游标签名的密钥，多台服务器或者重启之后需要游标仍然有效时，需要设置固定的密钥
+func (this *cursorSigner) setSecret(secret []byte) error {
+	if len(secret) == 0 {
+		return newError(ErrInvalidArgument, "Cursor secret must not empty")
+	}
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.secret = make([]byte, len(secret))
+	copy(this.secret, secret)
+	return nil
+}
+
+type cursor struct {
+	next bool   // true 表示下一页，false 表示上一页
+	key  []byte // 锚点key，结果中不包括该key，为空表示第一页
+}
+
+// 格式：base64(方向 + 锚点key + 签名)，签名时带上 scope，这样游标不能用在其他列表上
+func (this *cursorSigner) encode(scope string, c *cursor) string {
+	payload := make([]byte, 0, 1+len(c.key)+cursorMacSize)
+	if c.next {
+		payload = append(payload, 'n')
+	} else {
+		payload = append(payload, 'p')
+	}
+	payload = append(payload, c.key...)
+	payload = append(payload, this.sign(scope, payload)...)
+
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+// 空字符串表示第一页
+func (this *cursorSigner) decode(scope string, s string) (*cursor, error) {
+	if s == "" {
+		return &cursor{next: true}, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(data) < 1+cursorMacSize {
+		return nil, ErrInvalidCursor
+	}
+
+	payload, mac := data[:len(data)-cursorMacSize], data[len(data)-cursorMacSize:]
+	if !hmac.Equal(mac, this.sign(scope, payload)) || (payload[0] != 'n' && payload[0] != 'p') {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor{next: payload[0] == 'n', key: payload[1:]}, nil
+}
+
+func (this *cursorSigner) sign(scope string, payload []byte) []byte {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	h := hmac.New(sha256.New, this.secret)
+	h.Write([]byte(scope))
+	h.Write([]byte{0})
+	h.Write(payload)
+	return h.Sum(nil)[:cursorMacSize]
+}
+
+// 遍历 db 中以 prefix 开头的key，返回一页数据，每页最多n条，游标用 signer 签名
+// scope 用于区分不同的列表，convert 将key和value转换成结果，返回false表示跳过
+// OrderAsc 表示按key的字典序从小到大，OrderDesc 表示从大到小
+func scanPage[T any](signer *cursorSigner, db *KVStore, prefix []byte, scope string, cursorStr string, n int, order Order,
+	convert func(key, value []byte) (T, bool)) (*Page[T], error) {
+
+	scope = scope + "_" + strconv.Itoa(int(order))
+	c, err := signer.decode(scope, cursorStr)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page[T]{Items: make([]T, 0)}
+	if n <= 0 {
+		return page, nil
+	}
+
+	// 按key的字典序遍历的方向，下一页和排序方向一致，上一页和排序方向相反
+	ascending := (order == OrderAsc) == c.next
+	scan := func(anchor []byte, ascending bool, f func(key, value []byte) bool) {
+		if ascending {
+			db.ScanAfter(prefix, anchor, f)
+		} else {
+			db.ScanBefore(prefix, anchor, f)
+		}
+	}
+
+	keys := make([][]byte, 0, n)
+	scan(c.key, ascending, func(key, value []byte) bool {
+		if item, ok := convert(key, value); ok {
+			page.Items = append(page.Items, item)
+			keys = append(keys, key)
+		}
+		return len(page.Items) < n
+	})
+
+	// 上一页是倒着遍历的，需要反转成排序方向
+	if !c.next {
+		for i, j := 0, len(keys)-1; i < j; i, j = i+1, j-1 {
+			keys[i], keys[j] = keys[j], keys[i]
+			page.Items[i], page.Items[j] = page.Items[j], page.Items[i]
+		}
+	}
+
+	firstKey, lastKey := c.key, c.key
+	if len(keys) > 0 {
+		firstKey, lastKey = keys[0], keys[len(keys)-1]
+	}
+	if len(firstKey) == 0 {
+		return page, nil
+	}
+
+	// 判断前后是否还有数据，被 convert 跳过的不算
+	hasMore := func(anchor []byte, ascending bool) bool {
+		found := false
+		scan(anchor, ascending, func(key, value []byte) bool {
+			_, found = convert(key, value)
+			return !found
+		})
+		return found
+	}
+	if hasMore(lastKey, order == OrderAsc) {
+		page.NextCursor = signer.encode(scope, &cursor{next: true, key: lastKey})
+	}
+	if hasMore(firstKey, order != OrderAsc) {
+		page.PrevCursor = signer.encode(scope, &cursor{next: false, key: firstKey})
+	}
+
+	return page, nil
+}
@@ -0,0 +1,188 @@
+package gmodel
+
+import (
+	"os"
+	"strconv"
+	"testing"
+)
+
+func articleIds(articles []*Article) []uint64 {
+	ids := make([]uint64, 0)
+	for _, article := range articles {
+		ids = append(ids, article.Id)
+	}
+	return ids
+}
+
+func TestCursor(t *testing.T) {
+	scope := "test"
+	signer := newCursorSigner()
+	s := signer.encode(scope, &cursor{next: false, key: []byte("key_1")})
+
+	c, err := signer.decode(scope, s)
+	if err != nil || c.next || string(c.key) != "key_1" {
+		t.Fatal(err)
+	}
+	c, err = signer.decode(scope, "")
+	if err != nil || !c.next || len(c.key) != 0 {
+		t.Fatal(err)
+	}
+
+	// 篡改、用在其他列表上、随便填的游标都无效
+	tampered := []byte(s)
+	if tampered[3] == 'A' {
+		tampered[3] = 'B'
+	} else {
+		tampered[3] = 'A'
+	}
+	for _, invalid := range []string{string(tampered), s[:len(s)-1], "abc", "!!!"} {
+		if _, err = signer.decode(scope, invalid); err != ErrInvalidCursor {
+			t.Fatal(invalid)
+		}
+	}
+	if _, err = signer.decode("other", s); err != ErrInvalidCursor {
+		t.Fatal()
+	}
+
+	// 其他的密钥不能解码，更换密钥之后旧的游标失效
+	if _, err = newCursorSigner().decode(scope, s); err != ErrInvalidCursor {
+		t.Fatal()
+	}
+	if signer.setSecret(nil) == nil {
+		t.Fatal()
+	}
+	if err = signer.setSecret([]byte("secret")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = signer.decode(scope, s); err != ErrInvalidCursor {
+		t.Fatal()
+	}
+}
+
+func TestListArticles(t *testing.T) {
+	articleDBPath := "test_article.db"
+	tagDBPath := "test_tag.db"
+	indexDBPath := "test_index.db"
+
+	defer func() {
+		os.RemoveAll(articleDBPath)
+		os.RemoveAll(tagDBPath)
+		os.RemoveAll(indexDBPath)
+	}()
+
+	gmodel := &GModel{}
+	err := gmodel.Open(articleDBPath, tagDBPath, indexDBPath)
+	if err != nil {
+		t.Fatal()
+	}
+	defer gmodel.Close()
+
+	for i := 1; i <= 7; i++ {
+		tags := []string{"all"}
+		if i%2 == 1 {
+			tags = append(tags, "odd")
+		}
+		gmodel.AddArticle(tags, "data_"+strconv.Itoa(i))
+	}
+
+	// 最新的在前面，一直往后翻，再一直往前翻
+	page, err := gmodel.ListArticles("", 3, OrderDesc)
+	if err != nil || !isEqualIds(articleIds(page.Items), []uint64{7, 6, 5}) || page.PrevCursor != "" || page.NextCursor == "" {
+		t.Fatal(err)
+	}
+	page, err = gmodel.ListArticles(page.NextCursor, 3, OrderDesc)
+	if err != nil || !isEqualIds(articleIds(page.Items), []uint64{4, 3, 2}) || page.PrevCursor == "" || page.NextCursor == "" {
+		t.Fatal(err)
+	}
+	page, err = gmodel.ListArticles(page.NextCursor, 3, OrderDesc)
+	if err != nil || !isEqualIds(articleIds(page.Items), []uint64{1}) || page.NextCursor != "" {
+		t.Fatal(err)
+	}
+	page, err = gmodel.ListArticles(page.PrevCursor, 3, OrderDesc)
+	if err != nil || !isEqualIds(articleIds(page.Items), []uint64{4, 3, 2}) {
+		t.Fatal(err)
+	}
+	page, err = gmodel.ListArticles(page.PrevCursor, 3, OrderDesc)
+	if err != nil || !isEqualIds(articleIds(page.Items), []uint64{7, 6, 5}) || page.PrevCursor != "" {
+		t.Fatal(err)
+	}
+
+	// 游标和排序方向绑定
+	if _, err = gmodel.ListArticles(page.NextCursor, 3, OrderAsc); err != ErrInvalidCursor {
+		t.Fatal()
+	}
+
+	// 锚点文章被删除后游标仍然有效
+	page, _ = gmodel.ListArticles("", 2, OrderAsc)
+	gmodel.DeleteArticle(2)
+	page, err = gmodel.ListArticles(page.NextCursor, 2, OrderAsc)
+	if err != nil || !isEqualIds(articleIds(page.Items), []uint64{3, 4}) {
+		t.Fatal(err)
+	}
+
+	// 按分类
+	page, err = gmodel.ListArticlesByTag("odd", "", 3, OrderAsc)
+	if err != nil || !isEqualIds(articleIds(page.Items), []uint64{1, 3, 5}) || page.NextCursor == "" {
+		t.Fatal(err)
+	}
+	if _, err = gmodel.ListArticlesByTag("all", page.NextCursor, 3, OrderAsc); err != ErrInvalidCursor {
+		t.Fatal()
+	}
+	page, err = gmodel.ListArticlesByTag("odd", page.NextCursor, 3, OrderAsc)
+	if err != nil || !isEqualIds(articleIds(page.Items), []uint64{7}) || page.NextCursor != "" || page.PrevCursor == "" {
+		t.Fatal(err)
+	}
+	page, err = gmodel.ListArticlesByTag("none", "", 3, OrderAsc)
+	if err != nil || len(page.Items) != 0 || page.NextCursor != "" || page.PrevCursor != "" {
+		t.Fatal(err)
+	}
+	if _, err = gmodel.ListArticlesByTerm("none", "odd", "", 3, OrderAsc); err == nil {
+		t.Fatal()
+	}
+
+	// 分类
+	tags, err := gmodel.ListTags("", 1, OrderAsc)
+	if err != nil || len(tags.Items) != 1 || tags.Items[0].Name != "all" {
+		t.Fatal(err)
+	}
+	tags, err = gmodel.ListTags(tags.NextCursor, 1, OrderAsc)
+	if err != nil || len(tags.Items) != 1 || tags.Items[0].Name != "odd" || tags.NextCursor != "" {
+		t.Fatal(err)
+	}
+	tags, err = gmodel.ListTopTags("", 10)
+	if err != nil || len(tags.Items) != 2 || tags.Items[0].Name != "all" || tags.Items[0].ArticleCount != 6 {
+		t.Fatal(err)
+	}
+
+	// 每个 GModel 的密钥互不影响，设置同样的密钥之后游标可以通用，修改密钥之后旧的游标失效
+	other := &GModel{}
+	if err = other.Open("test_article_other.db", "test_tag_other.db", "test_index_other.db"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		other.Close()
+		os.RemoveAll("test_article_other.db")
+		os.RemoveAll("test_tag_other.db")
+		os.RemoveAll("test_index_other.db")
+	}()
+	page, _ = gmodel.ListArticles("", 2, OrderAsc)
+	if _, err = other.ListArticles(page.NextCursor, 2, OrderAsc); err != ErrInvalidCursor {
+		t.Fatal()
+	}
+	if gmodel.SetCursorSecret(nil) == nil {
+		t.Fatal()
+	}
+	gmodel.SetCursorSecret([]byte("secret"))
+	other.SetCursorSecret([]byte("secret"))
+	if _, err = gmodel.ListArticles(page.NextCursor, 2, OrderAsc); err != ErrInvalidCursor {
+		t.Fatal()
+	}
+	page, _ = gmodel.ListArticles("", 2, OrderAsc)
+	if _, err = other.ListArticles(page.NextCursor, 2, OrderAsc); err != nil {
+		t.Fatal(err)
+	}
+	tags, _ = gmodel.ListTags("", 1, OrderAsc)
+	if _, err = other.ListTags(tags.NextCursor, 1, OrderAsc); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,60 @@
+package gmodel
+
+import (
+	"errors"
+)
+
+// 错误类型，返回的错误可以用 errors.Is 判断，比如 errors.Is(err, gmodel.ErrNotFound)
+// remote 会把它们转换成不同的 errcode 和 HTTP 状态码，APIClient 返回的错误也可以用 errors.Is 判断
+var (
+	ErrNotFound        = errors.New("Not found")        // 文章、分类、ID等不存在
+	ErrExists          = errors.New("Already exists")   // 文章ID、分类名称、自定义ID等已经存在
+	ErrInvalidArgument = errors.New("Invalid argument") // 参数错误，比如文章数据为空、游标无效
+	ErrReadOnly        = errors.New("Read only")        // 不允许修改，比如保留的key
+	ErrIdExhausted     = errors.New("Id exhausted")     // 随机生成字符串ID时一直冲突，详见 IdGenConfig
+)
+
+// 带有错误类型的错误，Error() 返回具体的错误信息，Unwrap() 返回错误类型
+type Error struct {
+	Kind error
+	Msg  string
+}
+
+func (this *Error) Error() string {
+	return this.Msg
+}
+
+func (this *Error) Unwrap() error {
+	return this.Kind
+}
+
+// 整型ID和字符串ID的映射冲突，errors.Is(err, ErrExists) 为true，详见 IdMgr.SetIdMap
+// BoundStringId 不为空表示冲突原因是 IntId 已经映射到了其他字符串ID
+type IdConflictError struct {
+	IntId         uint64
+	StringId      string
+	BoundStringId string
+	Msg           string
+}
+
+func (this *IdConflictError) Error() string {
+	return this.Msg
+}
+
+func (this *IdConflictError) Unwrap() error {
+	return ErrExists
+}
+
+func newError(kind error, msg string) error {
+	return &Error{Kind: kind, Msg: msg}
+}
+
+// 修改错误信息，保留原来的错误类型（包括 *IdConflictError 这样 Unwrap 成错误类型的）
+func wrapError(err error, msg string) error {
+	for _, kind := range []error{ErrNotFound, ErrExists, ErrInvalidArgument, ErrReadOnly, ErrIdExhausted} {
+		if errors.Is(err, kind) {
+			return newError(kind, msg)
+		}
+	}
+	return errors.New(msg)
+}
@@ -0,0 +1,74 @@
+package gmodel
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	articleDBPath := "test_errors_article.db"
+	tagDBPath := "test_errors_tag.db"
+	indexDBPath := "test_errors_index.db"
+
+	defer func() {
+		os.RemoveAll(articleDBPath)
+		os.RemoveAll(tagDBPath)
+		os.RemoveAll(indexDBPath)
+	}()
+
+	gmodel := &GModel{}
+	if err := gmodel.Open(articleDBPath, tagDBPath, indexDBPath); err != nil {
+		t.Fatal(err)
+	}
+	defer gmodel.Close()
+
+	articleId, err := gmodel.AddArticle([]string{"go", "db"}, "data")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 错误信息不变，可以用 errors.Is 判断错误类型
+	_, err = gmodel.GetArticle(articleId + 1)
+	if !errors.Is(err, ErrNotFound) || err.Error() != "Article ID[2] not found" {
+		t.Fatal(err)
+	}
+	if err = gmodel.DeleteArticle(articleId + 1); !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+	if _, err = gmodel.GetTagByName("none"); !errors.Is(err, ErrNotFound) || errors.Is(err, ErrExists) {
+		t.Fatal(err)
+	}
+	if err = gmodel.RenameTag("go", "db"); !errors.Is(err, ErrExists) {
+		t.Fatal(err)
+	}
+	if _, err = gmodel.AddArticle(nil, ""); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatal(err)
+	}
+	if _, err = gmodel.ListArticles("invalid", 10, OrderDesc); !errors.Is(err, ErrInvalidArgument) || !errors.Is(err, ErrInvalidCursor) {
+		t.Fatal(err)
+	}
+	if _, err = gmodel.ListArticlesByTerm("none", "go", "", 10, OrderDesc); !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+	if err = gmodel.articleMgr.AddBatchWithIds([]*Article{{Id: articleId, Data: "data"}}); !errors.Is(err, ErrExists) {
+		t.Fatal(err)
+	}
+
+	// 保留的key不允许修改
+	db := gmodel.articleMgr.db
+	if err = db.Put(keyForCount, []byte("1")); !errors.Is(err, ErrReadOnly) {
+		t.Fatal(err)
+	}
+	if _, err = db.Get([]byte("none")); !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+
+	// 修改错误信息时保留错误类型
+	if err = wrapError(newError(ErrExists, "a"), "b"); !errors.Is(err, ErrExists) || err.Error() != "b" {
+		t.Fatal(err)
+	}
+	if err = wrapError(errors.New("a"), "b"); errors.Is(err, ErrExists) || err.Error() != "b" {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,266 @@
+package gmodel
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// 导入导出使用 JSON Lines 格式，每行一篇文章，方便在不同服务器、不同版本之间迁移数据
+// 文章的分类保存的是名称而不是ID，因为分类ID在不同的数据库中是不一样的
+
+// 导出的一篇文章
+type ExportArticle struct {
+	Id       uint64 `json:"id"`                  // 原来的文章ID，导入时可以选择保留
+	CustomId string `json:"custom_id,omitempty"` // IdMgr 中对应的字符串ID
+	NewArticle
+}
+
+// 导入导出时每批处理的文章数
+const exportBatchSize = 1000
+
+// 单行的最大长度，文章数据比较大时需要调大
+var MaxImportLineSize = 64 * 1024 * 1024
+
+// 按文章ID从小到大导出全部文章到 w，每行一篇，返回导出的文章数
+// idMgr 不为nil时同时导出文章对应的字符串ID
+// 文章用到的分类法都需要先用 OpenTaxonomy 打开，否则返回错误
+// 只在读取一批（exportBatchSize 篇）文章时持有读锁，写入 w 时释放，所以 w 很慢时也不会一直阻塞写操作
+// 下一批从上一批最后的文章ID之后继续，导出过程中增加、修改、删除的文章是否导出取决于它在哪一批
+func (this *GModel) Export(w io.Writer, idMgr *IdMgr) (int, error) {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+
+	count := 0
+	var afterId uint64
+	for {
+		exports, err := this.exportBatch(afterId, idMgr)
+		if err != nil {
+			return count, err
+		}
+		if len(exports) == 0 {
+			return count, nil
+		}
+
+		for _, export := range exports {
+			if err = encoder.Encode(export); err != nil {
+				return count, err
+			}
+		}
+		count += len(exports)
+		afterId = exports[len(exports)-1].Id
+	}
+}
+
+// 持有读锁，读取 afterId 之后的一批文章并转换成导出的格式
+func (this *GModel) exportBatch(afterId uint64, idMgr *IdMgr) ([]*ExportArticle, error) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	articles := make([]*Article, 0, exportBatchSize)
+	err := this.articleMgr.ScanAfter(afterId, func(article *Article) bool {
+		articles = append(articles, article)
+		return len(articles) < exportBatchSize
+	})
+	if err != nil {
+		return nil, err
+	}
+	return this.exportArticles(articles, idMgr)
+}
+
+// 转换一批文章，分类名称和字符串ID都是批量获取的
+func (this *GModel) exportArticles(articles []*Article, idMgr *IdMgr) ([]*ExportArticle, error) {
+	for _, article := range articles {
+		for name := range article.Terms {
+			if _, err := this.getTaxonomy(name); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	ids := make([]uint64, len(articles))
+	for i, article := range articles {
+		ids[i] = article.Id
+	}
+	customIds := make(map[uint64]string)
+	if idMgr != nil {
+		customIds = idMgr.GetStringIds(ids)
+	}
+
+	exports := make([]*ExportArticle, len(articles))
+	for i, article := range articles {
+		exports[i] = &ExportArticle{
+			Id:         article.Id,
+			CustomId:   customIds[article.Id],
+			NewArticle: NewArticle{Tags: make([]string, 0), Data: article.Data},
+		}
+	}
+
+	for _, tax := range this.allTaxonomies() {
+		termIds := make([]uint64, 0)
+		for _, article := range articles {
+			termIds = append(termIds, tax.getTermIds(article)...)
+		}
+		tags := tax.tagMgr.GetByIds(termIds)
+
+		for i, article := range articles {
+			names := make([]string, 0)
+			for _, termId := range tax.getTermIds(article) {
+				if tag, ok := tags[termId]; ok {
+					names = append(names, tag.Name)
+				}
+			}
+
+			if tax.name == "" {
+				exports[i].Tags = names
+			} else if len(names) > 0 {
+				if exports[i].Terms == nil {
+					exports[i].Terms = make(map[string][]string)
+				}
+				exports[i].Terms[tax.name] = names
+			}
+		}
+	}
+
+	return exports, nil
+}
+
+// 从 r 导入 Export 导出的文章，每批一次写入，返回导入的文章数
+// keepIds 为true时保留原来的文章ID，文章ID已经存在时返回错误；为false时重新分配文章ID
+// idMgr 不为nil时同时导入字符串ID，为空的随机生成，字符串ID已经存在时返回错误
+// 遇到错误时停止导入，之前的批次已经导入，错误信息中带有行号
+func (this *GModel) Import(r io.Reader, idMgr *IdMgr, keepIds bool) (int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxImportLineSize)
+
+	count := 0
+	line := 0
+	lines := make([]int, 0, exportBatchSize)
+	articles := make([]*ExportArticle, 0, exportBatchSize)
+	for scanner.Scan() {
+		line++
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		article := &ExportArticle{}
+		if err := json.Unmarshal(scanner.Bytes(), article); err != nil {
+			return count, newError(ErrInvalidArgument, fmt.Sprintf("Import line %v failed: %v", line, err))
+		}
+		articles = append(articles, article)
+		lines = append(lines, line)
+
+		if len(articles) == exportBatchSize {
+			n, err := this.importArticles(articles, lines, idMgr, keepIds)
+			count += n
+			if err != nil {
+				return count, err
+			}
+			articles = articles[:0]
+			lines = lines[:0]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return count, errors.New(fmt.Sprintf("Import line %v failed: %v", line+1, err))
+	}
+
+	n, err := this.importArticles(articles, lines, idMgr, keepIds)
+	return count + n, err
+}
+
+// 导入一批文章，先检查ID，再批量写入文章和字符串ID
+func (this *GModel) importArticles(articles []*ExportArticle, lines []int, idMgr *IdMgr, keepIds bool) (int, error) {
+	if len(articles) == 0 {
+		return 0, nil
+	}
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	fail := func(i int, err error) (int, error) {
+		return 0, wrapError(err, fmt.Sprintf("Import line %v failed: %v", lines[i], err))
+	}
+
+	// 先检查参数和ID，避免写入一部分之后才失败
+	articleIds := make(map[uint64]bool)
+	for i, article := range articles {
+		if len(article.Data) == 0 {
+			return fail(i, newError(ErrInvalidArgument, "Article data must not empty"))
+		}
+		for name := range article.Terms {
+			if _, err := this.getTaxonomy(name); err != nil {
+				return fail(i, err)
+			}
+		}
+		if keepIds {
+			if article.Id == 0 || articleIds[article.Id] || this.articleMgr.has(article.Id) {
+				return fail(i, newError(ErrExists, fmt.Sprintf("Article ID[%v] is invalid or exist", article.Id)))
+			}
+			articleIds[article.Id] = true
+		}
+	}
+
+	// 不保留文章ID时，按 AddBatch 的规则提前算出新的文章ID（持有锁，不会被其他文章占用），用来检查字符串ID
+	newArticles := make([]NewArticle, len(articles))
+	ids := make([]uint64, len(articles))
+	maxId := this.articleMgr.GetMaxId()
+	for i, article := range articles {
+		newArticles[i] = article.NewArticle
+		if keepIds {
+			ids[i] = article.Id
+		} else {
+			ids[i] = maxId + uint64(i) + 1
+		}
+	}
+
+	// 字符串ID和文章一起检查，有冲突时什么都不写入
+	var stringIds []string
+	if idMgr != nil {
+		stringIds = make([]string, len(articles))
+		for i, article := range articles {
+			stringIds[i] = article.CustomId
+		}
+		if err := idMgr.CheckIdMaps(ids, stringIds); err != nil {
+			return fail(conflictIndex(err, ids, stringIds), err)
+		}
+	}
+
+	ids, errs := this.addArticles(newArticles, ids)
+	for i, err := range errs {
+		if err != nil {
+			return fail(i, err)
+		}
+	}
+
+	// 文章已经写入，这里失败时（比如检查之后字符串ID被其他进程使用）返回已经写入的文章数
+	if idMgr != nil {
+		if _, err := idMgr.AddIdMaps(ids, stringIds); err != nil {
+			i := conflictIndex(err, ids, stringIds)
+			return len(articles), wrapError(err, fmt.Sprintf("Import line %v failed: articles are imported without custom ids: %v", lines[i], err))
+		}
+	}
+
+	return len(articles), nil
+}
+
+// 根据 IdMgr 返回的 *IdConflictError 找出冲突的那一项，找不到时返回0
+func conflictIndex(err error, ids []uint64, stringIds []string) int {
+	var conflict *IdConflictError
+	if !errors.As(err, &conflict) {
+		return 0
+	}
+	for i := range ids {
+		if conflict.StringId != "" && stringIds[i] == conflict.StringId {
+			return i
+		}
+	}
+	for i, id := range ids {
+		if id == conflict.IntId {
+			return i
+		}
+	}
+	return 0
+}
@@ -0,0 +1,192 @@
+package gmodel
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func openExportTestModel(t *testing.T, prefix string) (*GModel, *IdMgr) {
+	gmodel := &GModel{}
+	if err := gmodel.Open(prefix+"_article.db", prefix+"_tag.db", prefix+"_index.db"); err != nil {
+		t.Fatal(err)
+	}
+	if err := gmodel.OpenTaxonomy("keyword", prefix+"_keyword_tag.db", prefix+"_keyword_index.db"); err != nil {
+		t.Fatal(err)
+	}
+	idMgr := &IdMgr{}
+	if err := idMgr.Open(prefix + "_id.db"); err != nil {
+		t.Fatal(err)
+	}
+	return gmodel, idMgr
+}
+
+func removeExportTestModel(prefix string) {
+	for _, name := range []string{"article", "tag", "index", "keyword_tag", "keyword_index", "id"} {
+		os.RemoveAll(prefix + "_" + name + ".db")
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	defer removeExportTestModel("test_src")
+	defer removeExportTestModel("test_dst")
+
+	src, srcIdMgr := openExportTestModel(t, "test_src")
+	defer src.Close()
+	defer srcIdMgr.Close()
+
+	// 删除一些文章，使ID不连续，数量超过一批
+	for i := 1; i <= 2500; i++ {
+		tags := []string{"tag" + strconv.Itoa(i%3)}
+		terms := map[string][]string{}
+		if i%2 == 0 {
+			terms["keyword"] = []string{"even"}
+		}
+		id, err := src.AddArticleWithTerms(tags, terms, "data_<"+strconv.Itoa(i)+">")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = srcIdMgr.AddIntId(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 1; i <= 2500; i += 100 {
+		src.DeleteArticle(uint64(i))
+	}
+
+	var buf bytes.Buffer
+	count, err := src.Export(&buf, srcIdMgr)
+	if err != nil || count != 2475 || strings.Count(buf.String(), "\n") != 2475 {
+		t.Fatal(count, err)
+	}
+	data := buf.String()
+
+	// 保留文章ID导入
+	dst, dstIdMgr := openExportTestModel(t, "test_dst")
+	defer dst.Close()
+	defer dstIdMgr.Close()
+
+	count, err = dst.Import(strings.NewReader(data), dstIdMgr, true)
+	if err != nil || count != 2475 {
+		t.Fatal(count, err)
+	}
+	if dst.GetArticleCount() != 2475 || dst.GetMaxArticleId() != 2500 || dstIdMgr.Count() != 2475 {
+		t.Fatal()
+	}
+	if dst.GetArticleCountByTag("tag2") != src.GetArticleCountByTag("tag2") ||
+		dst.GetArticleCountByTerm("keyword", "even") != 1250 {
+		t.Fatal()
+	}
+	article, err := dst.GetArticle(2500)
+	if err != nil || article.Data != "data_<2500>" {
+		t.Fatal(err)
+	}
+	srcStringId, _ := srcIdMgr.GetStringId(2500)
+	if intId, ok := dstIdMgr.GetIntId(srcStringId); !ok || intId != 2500 {
+		t.Fatal()
+	}
+	if id, _ := dst.AddArticle(nil, "new"); id != 2501 {
+		t.Fatal(id)
+	}
+
+	// 导出的内容一样
+	buf.Reset()
+	dst.DeleteArticle(2501)
+	if _, err = dst.Export(&buf, dstIdMgr); err != nil || buf.String() != data {
+		t.Fatal(err)
+	}
+
+	// 文章ID和字符串ID已经存在
+	if count, err = dst.Import(strings.NewReader(data), dstIdMgr, true); err == nil || count != 0 {
+		t.Fatal()
+	}
+	if count, err = dst.Import(strings.NewReader(data), dstIdMgr, false); err == nil || count != 0 {
+		t.Fatal()
+	}
+
+	// 字符串ID冲突时什么都不写入，错误中是冲突的那一行
+	articleCount := dst.GetArticleCount()
+	conflict := "{\"custom_id\":\"new_1\",\"data\":\"x\"}\n{\"custom_id\":\"" + srcStringId + "\",\"data\":\"y\"}\n"
+	count, err = dst.Import(strings.NewReader(conflict), dstIdMgr, false)
+	if !errors.Is(err, ErrExists) || count != 0 || !strings.Contains(err.Error(), "line 2") {
+		t.Fatal(count, err)
+	}
+	if dst.GetArticleCount() != articleCount || dstIdMgr.HasStringId("new_1") {
+		t.Fatal()
+	}
+
+	// 不保留文章ID，不导入字符串ID，错误带有行号
+	lines := "{\"id\":1,\"tags\":[\"a\"],\"data\":\"x\"}\n\n{\"id\":1,\"tags\":[\"b\"],\"data\":\"y\"}\n{\"data\":\"\"}\n"
+	count, err = dst.Import(strings.NewReader(lines), nil, false)
+	if err == nil || count != 0 || !strings.Contains(err.Error(), "line 4") {
+		t.Fatal(err)
+	}
+	count, err = dst.Import(strings.NewReader(lines[:strings.LastIndex(lines, "{")]), nil, false)
+	if err != nil || count != 2 || dst.GetArticleCountByTag("a") != 1 {
+		t.Fatal(err)
+	}
+	if _, err = dst.Import(strings.NewReader("not json\n"), nil, false); err == nil {
+		t.Fatal()
+	}
+}
+
+// 第一次写入时调用 f 修改模型，导出时一直持有读锁的话会死锁
+type modifyingWriter struct {
+	bytes.Buffer
+	f func()
+}
+
+func (this *modifyingWriter) Write(p []byte) (int, error) {
+	if this.f != nil {
+		this.f()
+		this.f = nil
+	}
+	return this.Buffer.Write(p)
+}
+
+func TestExportReleasesLock(t *testing.T) {
+	defer removeExportTestModel("test_export_lock")
+
+	gmodel, idMgr := openExportTestModel(t, "test_export_lock")
+	defer gmodel.Close()
+	defer idMgr.Close()
+
+	for i := 1; i <= exportBatchSize+500; i++ {
+		gmodel.AddArticle([]string{"tag"}, "data_"+strconv.Itoa(i))
+	}
+
+	// 写入第一批时增加一篇文章、删除第二批中的一篇，后面的批次能看到这些修改
+	w := &modifyingWriter{f: func() {
+		if _, err := gmodel.AddArticle([]string{"tag"}, "new"); err != nil {
+			t.Error(err)
+		}
+		if err := gmodel.DeleteArticle(exportBatchSize + 200); err != nil {
+			t.Error(err)
+		}
+	}}
+	type result struct {
+		count int
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		count, err := gmodel.Export(w, nil)
+		done <- result{count, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil || r.count != exportBatchSize+500 {
+			t.Fatal(r.count, r.err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Export holds the lock while writing")
+	}
+	if strings.Contains(w.String(), `"data_`+strconv.Itoa(exportBatchSize+200)+`"`) || !strings.Contains(w.String(), `"new"`) {
+		t.Fatal()
+	}
+}
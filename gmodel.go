@@ -2,7 +2,7 @@ package gmodel
 
 import (
 	"bytes"
-	"errors"
+	"fmt"
 	"strconv"
 	"sync"
 )
@@ -14,28 +14,45 @@ type GModel struct {
 
 	// 为了方便按分类查找文章，增加一个数据库用来作为索引
 	// 索引格式：tagId_articleId -> articleId
+	// 另外还保存了按文章ID分段的文章数，用于按页码分页，详见 page.go
 	indexDB *KVStore
 
+	// 其他分类法，比如关键词、作者、系列等，key为分类法名称，详见 OpenTaxonomy
+	taxonomies map[string]*taxonomy
+
+	// 游标签名的密钥，所有列表共用，详见 SetCursorSecret
+	cursors *cursorSigner
+
 	// 全局一把锁
 	mutex sync.RWMutex
 }
 
 // 初始化，使用三个数据库文件，分别存放文章、分类、索引
 func (this *GModel) Open(articleDBPath, tagDBPath, indexDBPath string) error {
-	this.articleMgr = &ArticleMgr{}
-	this.tagMgr = &TagMgr{}
+	this.cursors = newCursorSigner()
+	this.articleMgr = &ArticleMgr{cursors: this.cursors}
+	this.tagMgr = &TagMgr{cursors: this.cursors}
 	this.indexDB = &KVStore{}
+	this.taxonomies = make(map[string]*taxonomy)
 
+	// 出错时关闭已经打开的数据库，这样可以重新打开
 	if err := this.articleMgr.Open(articleDBPath); err != nil {
 		return err
 	}
 	if err := this.tagMgr.Open(tagDBPath); err != nil {
+		this.articleMgr.Close()
 		return err
 	}
 	if err := this.indexDB.Open(indexDBPath); err != nil {
+		this.articleMgr.Close()
+		this.tagMgr.Close()
 		return err
 	}
 
+	if err := this.upgradeIndex(this.indexDB); err != nil {
+		this.Close()
+		return err
+	}
 	return nil
 }
 
@@ -43,6 +60,10 @@ func (this *GModel) Close() error {
 	this.articleMgr.Close()
 	this.tagMgr.Close()
 	this.indexDB.Close()
+	for _, tax := range this.taxonomies {
+		tax.tagMgr.Close()
+		tax.indexDB.Close()
+	}
 	return nil
 }
 
@@ -82,41 +103,36 @@ func (this *GModel) GetMaxArticleId() uint64 {
 // tags：文章分类名称，可以为空，tags为空表示该文章属于未分类
 // data: 文章数据内容，不能为空
 func (this *GModel) AddArticle(tags []string, data string) (uint64, error) {
+	return this.AddArticleWithTerms(tags, nil, data)
+}
+
+// 增加文章，同时设置其他分类法下的分类，返回文章ID
+// terms：key为分类法名称，value为该分类法下的分类名称，可以为空，分类法需要先用 OpenTaxonomy 打开
+func (this *GModel) AddArticleWithTerms(tags []string, terms map[string][]string, data string) (uint64, error) {
 	this.mutex.Lock()
 	defer this.mutex.Unlock()
 
-	if len(data) == 0 {
-		return 0, errors.New("GModel AddArticle data must not empty!")
-	}
+	return this.addArticle(tags, terms, data)
+}
 
-	// tags 为空，则补一个空字符串，方便索引，也就是每篇文章至少存在一个分类，该分类可以为空
-	// tagMgr.Add 支持插入空字符串
-	if len(tags) == 0 {
-		tags = []string{""}
+func (this *GModel) addArticle(tags []string, terms map[string][]string, data string) (uint64, error) {
+	if len(data) == 0 {
+		return 0, newError(ErrInvalidArgument, "GModel AddArticle data must not empty!")
 	}
-
-	// 注意需要对tags去重，而且要保持tags的原有顺序
-	tagMark := make(map[string]bool)
-	tagIds := make([]uint64, 0)
-
-	for _, tag := range tags {
-		_, exist := tagMark[tag]
-		if exist {
-			continue
-		}
-		tagMark[tag] = true
-
-		// 增加分类
-		id, err := this.tagMgr.Add(tag)
-		if err == nil {
-			tagIds = append(tagIds, id)
+	for name := range terms {
+		if _, err := this.getTaxonomy(name); err != nil {
+			return 0, err
 		}
 	}
 
 	article := &Article{
-		TagIds: tagIds,
+		TagIds: this.addTags(tags),
 		Data:   data,
 	}
+	for name, names := range terms {
+		tax := this.taxonomies[name]
+		tax.setTermIds(article, this.addTaxonomyTerms(tax, names))
+	}
 
 	// 增加文章
 	articleId, err := this.articleMgr.Add(article)
@@ -124,11 +140,15 @@ func (this *GModel) AddArticle(tags []string, data string) (uint64, error) {
 		return 0, err
 	}
 
-	// 分类下的文章数加1
-	this.addArticleCountForTags(tagIds, 1)
+	for _, tax := range this.allTaxonomies() {
+		termIds := tax.getTermIds(article)
 
-	// 增加索引
-	this.addIndex(tagIds, articleId)
+		// 分类下的文章数加1
+		this.addArticleCountForTerms(tax, termIds, 1)
+
+		// 增加索引
+		this.addIndex(tax.indexDB, termIds, articleId)
+	}
 
 	return articleId, nil
 }
@@ -142,17 +162,24 @@ func (this *GModel) DeleteArticle(articleId uint64) error {
 	if err != nil {
 		return err
 	}
+	if err = this.checkArticleTaxonomies(article); err != nil {
+		return err
+	}
 
 	// 删除文章
 	if err = this.articleMgr.Delete(article.Id); err != nil {
 		return err
 	}
 
-	// 分类下的文章数减1
-	this.addArticleCountForTags(article.TagIds, -1)
+	for _, tax := range this.allTaxonomies() {
+		termIds := tax.getTermIds(article)
 
-	// 删除索引
-	this.deleteIndex(article.TagIds, article.Id)
+		// 分类下的文章数减1
+		this.addArticleCountForTerms(tax, termIds, -1)
+
+		// 删除索引
+		this.deleteIndex(tax.indexDB, termIds, article.Id)
+	}
 
 	return nil
 }
@@ -165,8 +192,18 @@ func (this *GModel) GetArticle(articleId uint64) (*Article, error) {
 	return this.articleMgr.GetById(articleId)
 }
 
+// 批量获取文章，返回结果和 articleIds 一一对应，获取失败时文章为nil，错误信息在 errs 中
+func (this *GModel) GetArticles(articleIds []uint64) ([]*Article, []error) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	return this.articleMgr.GetByIds(articleIds)
+}
+
 // 获取指定文章的后N篇（不包括当前这篇）
 // 如果 articleId 等于 0，则表示获取最旧的N篇文章（id最小的N篇）
+//
+// Deprecated: 使用 ListArticles 代替
 func (this *GModel) GetNextArticles(articleId uint64, n int) []*Article {
 	this.mutex.RLock()
 	defer this.mutex.RUnlock()
@@ -176,6 +213,8 @@ func (this *GModel) GetNextArticles(articleId uint64, n int) []*Article {
 
 // 获取指定文章的前N篇（不包括当前这篇）
 // 如果 articleId 大于 最大的文章ID，则表示获取最新的N篇文章（id最大的N篇）
+//
+// Deprecated: 使用 ListArticles 代替
 func (this *GModel) GetPrevArticles(articleId uint64, n int) []*Article {
 	this.mutex.RLock()
 	defer this.mutex.RUnlock()
@@ -186,95 +225,42 @@ func (this *GModel) GetPrevArticles(articleId uint64, n int) []*Article {
 // 获取指定文章的后N篇（不包括当前这篇），保证这N篇文章的分类为tagName
 // tagName为文章分类，如果tag不存在，则返回空数组，如果tag为空，则表示未分类，会返回未分类的文章
 // articleId为文章ID，如果articleId为0，则返回该分类最旧的N篇文章（id最小的N篇）
+//
+// Deprecated: 使用 ListArticlesByTag 代替
 func (this *GModel) GetNextArticlesByTag(tagName string, articleId uint64, n int) []*Article {
 	this.mutex.RLock()
 	defer this.mutex.RUnlock()
 
-	articles := make([]*Article, 0)
-	if n == 0 {
-		return articles
-	}
-
-	tag, err := this.tagMgr.GetByName(tagName)
-	if err != nil {
-		return articles
-	}
-
-	// 按索引前缀查找
-	// 比如 tagName 为 java， 对应的tagid 为 23， articleId 为 99,
-	// 那么 prefix 为 23_
-	// 那么 searchKey 为 23_000000000000099
-
-	prefix := []byte(this.getIndexKeyPrefix(tag.Id))
-	searchKey := this.getIndexKey(tag.Id, articleId)
-	keys := this.indexDB.Next(searchKey, n)
-
-	for _, key := range keys {
-		if bytes.HasPrefix(key, prefix) {
-			value, err := this.indexDB.Get(key)
-			if err != nil {
-				continue
-			}
-
-			if articleId, err := strconv.ParseUint(string(value), 10, 64); err == nil {
-				if article, err := this.articleMgr.GetById(articleId); err == nil {
-					articles = append(articles, article)
-				}
-			}
-		}
-	}
-
-	return articles
+	return this.getNextArticlesByTerm(this.defaultTaxonomy(), tagName, articleId, n)
 }
 
 // 获取指定文章的前N篇（不包括当前这篇），保证这N篇文章的分类为tagName
 // tagName为文章分类，如果tag不存在，则返回空数组，如果tag为空，则表示未分类，会返回未分类的文章
 // articleId为文章ID，如果 articleId 大于 最大的文章ID，则返回该分类最新的N篇文章（id最大的N篇）
+//
+// Deprecated: 使用 ListArticlesByTag 代替
 func (this *GModel) GetPrevArticlesByTag(tagName string, articleId uint64, n int) []*Article {
 	this.mutex.RLock()
 	defer this.mutex.RUnlock()
 
-	articles := make([]*Article, 0)
-	if n == 0 {
-		return articles
-	}
-
-	tag, err := this.tagMgr.GetByName(tagName)
-	if err != nil {
-		return articles
-	}
-
-	// 按索引前缀查找
-	// 比如 tagName 为 java， 对应的tagid 为 23， articleId 为 99,
-	// 那么 prefix 为 23_
-	// 那么 searchKey 为 23_000000000000099
-
-	prefix := []byte(this.getIndexKeyPrefix(tag.Id))
-	searchKey := this.getIndexKey(tag.Id, articleId)
-	// 注意，这里n+1，因为查找某个tag的最新N篇时，填的articleId一般是最大的id+1，
-	// 那么指向的位置可能是下一个tag的第一篇，而这一篇会被过滤掉
-	keys := this.indexDB.Prev(searchKey, n+1)
+	return this.getPrevArticlesByTerm(this.defaultTaxonomy(), tagName, articleId, n)
+}
 
-	for _, key := range keys {
-		if bytes.HasPrefix(key, prefix) {
-			value, err := this.indexDB.Get(key)
-			if err != nil {
-				continue
-			}
+// 按文章ID分页，cursor 为空表示第一页，下一页、上一页传入返回的 NextCursor、PrevCursor
+// OrderDesc 表示最新的文章在前面
+func (this *GModel) ListArticles(cursor string, n int, order Order) (*Page[*Article], error) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
 
-			if articleId, err := strconv.ParseUint(string(value), 10, 64); err == nil {
-				if article, err := this.articleMgr.GetById(articleId); err == nil {
-					articles = append(articles, article)
+	return this.articleMgr.List(cursor, n, order)
+}
 
-					if len(articles) == n {
-						break
-					}
-				}
-			}
-		}
-	}
+// 按文章ID分页获取指定分类下的文章，如果tag不存在，则返回空页，详见 ListArticles
+func (this *GModel) ListArticlesByTag(tagName string, cursor string, n int, order Order) (*Page[*Article], error) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
 
-	return articles
+	return this.listArticlesByTerm(this.defaultTaxonomy(), tagName, cursor, n, order)
 }
 
 // 修改文章
@@ -285,55 +271,262 @@ func (this *GModel) UpdateArticle(articleId uint64, newTags []string, newData st
 	this.mutex.Lock()
 	defer this.mutex.Unlock()
 
+	// 其他分类法下的分类不变
+	return this.updateArticleWithTerms(articleId, newTags, nil, newData)
+}
+
+// 修改文章，同时修改其他分类法下的分类，newTerms 中没有出现的分类法保持不变
+// newTerms 中的分类法需要先用 OpenTaxonomy 打开，有一个没有打开时什么都不修改，返回 ErrNotFound
+// 分类、其他分类法下的分类和文章数据在一把锁内一起修改
+func (this *GModel) UpdateArticleWithTerms(articleId uint64, newTags []string, newTerms map[string][]string, newData string) error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	return this.updateArticleWithTerms(articleId, newTags, newTerms, newData)
+}
+
+func (this *GModel) updateArticleWithTerms(articleId uint64, newTags []string, newTerms map[string][]string, newData string) error {
+	for name := range newTerms {
+		if _, err := this.getTaxonomy(name); err != nil {
+			return err
+		}
+	}
+
 	article, err := this.articleMgr.GetById(articleId)
 	if err != nil {
 		return err
 	}
+	if err = this.checkArticleTaxonomies(article); err != nil {
+		return err
+	}
 
-	// tags 为空，则补一个空字符串，方便索引
-	// tagMgr.Add 支持插入空字符串
-	if len(newTags) == 0 {
-		newTags = []string{""}
+	sequences := this.tagSequences()
+	article.Data = newData
+	if err = this.updateArticle(article, this.addTermUpdates(newTags, newTerms)); err != nil {
+		this.deleteNewEmptyTags(sequences)
+		return err
+	}
+	return nil
+}
+
+// 增加文章新的分类，返回默认分类法和 terms 中出现的分类法的新分类ID，分类法需要已经检查过
+func (this *GModel) addTermUpdates(tags []string, terms map[string][]string) []termUpdate {
+	updates := []termUpdate{{this.defaultTaxonomy(), this.addTags(tags)}}
+	for _, tax := range this.allTaxonomies()[1:] {
+		if names, ok := terms[tax.name]; ok {
+			updates = append(updates, termUpdate{tax, this.addTaxonomyTerms(tax, names)})
+		}
+	}
+	return updates
+}
+
+// 增加或者修改文章的结果
+type UpsertResult int
+
+const (
+	UpsertCreated   UpsertResult = iota + 1 // 增加了新文章
+	UpsertUpdated                           // 修改了已有的文章
+	UpsertUnchanged                         // 文章内容没有变化，没有修改
+)
+
+func (this UpsertResult) String() string {
+	switch this {
+	case UpsertCreated:
+		return "created"
+	case UpsertUpdated:
+		return "updated"
+	case UpsertUnchanged:
+		return "unchanged"
 	}
+	return fmt.Sprintf("UpsertResult(%d)", int(this))
+}
+
+// 增加或者修改文章，返回文章ID和结果
+// articleId 为0时增加文章，否则修改文章，文章不存在时返回 ErrNotFound
+// 修改时先比较文章内容的哈希（分类、其他分类法下的分类、文章数据），内容没有变化时不修改，返回 UpsertUnchanged
+// terms 中没有出现的分类法保持不变，和 SetArticleTerms 一样，terms 中的分类法需要先用 OpenTaxonomy 打开
+// 整个操作在一把锁内完成，不会和其他修改交叉；修改时文章只写入一次，每个分类法的文章数和索引各自一次写入
+func (this *GModel) UpsertArticle(articleId uint64, tags []string, terms map[string][]string, data string) (uint64, UpsertResult, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
 
-	// 注意需要对tags去重，而且要保持tags的原有顺序
-	tagMark := make(map[string]bool)
+	if articleId == 0 {
+		articleId, err := this.addArticle(tags, terms, data)
+		if err != nil {
+			return 0, 0, err
+		}
+		return articleId, UpsertCreated, nil
+	}
+
+	if len(data) == 0 {
+		return 0, 0, newError(ErrInvalidArgument, "GModel UpsertArticle data must not empty!")
+	}
+	taxonomies := make(map[string]*taxonomy)
+	for name := range terms {
+		tax, err := this.getTaxonomy(name)
+		if err != nil {
+			return 0, 0, err
+		}
+		taxonomies[name] = tax
+	}
+
+	article, err := this.articleMgr.GetById(articleId)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err = this.checkArticleTaxonomies(article); err != nil {
+		return 0, 0, err
+	}
+	if !this.articleChanged(article, tags, taxonomies, terms, data) {
+		return articleId, UpsertUnchanged, nil
+	}
+
+	// 先增加所有分类，再一起修改，不会只修改了一部分分类法
+	sequences := this.tagSequences()
+	article.Data = data
+	if err = this.updateArticle(article, this.addTermUpdates(tags, terms)); err != nil {
+		this.deleteNewEmptyTags(sequences)
+		return 0, 0, err
+	}
+	return articleId, UpsertUpdated, nil
+}
+
+// 判断按新的内容修改之后文章是否会变化，只查找已有的分类，不增加分类
+// 有分类不存在时一定会变化，否则按修改后的分类ID和文章数据计算哈希，和原来的比较
+func (this *GModel) articleChanged(article *Article, tags []string, taxonomies map[string]*taxonomy, terms map[string][]string, data string) bool {
+	if len(tags) == 0 {
+		tags = []string{""}
+	}
+	tagIds, ok := this.lookupTerms(this.tagMgr, tags)
+	if !ok {
+		return true
+	}
+
+	newArticle := &Article{
+		Id:     article.Id,
+		TagIds: tagIds,
+		Terms:  make(map[string][]uint64),
+		Data:   data,
+	}
+	for name, termIds := range article.Terms {
+		newArticle.Terms[name] = termIds
+	}
+	for name, names := range terms {
+		tax := taxonomies[name]
+		termIds, ok := this.lookupTerms(tax.tagMgr, tax.filterTerms(names))
+		if !ok {
+			return true
+		}
+		tax.setTermIds(newArticle, termIds)
+	}
+
+	return newArticle.ContentHash() != article.ContentHash()
+}
+
+// 和 addTerms 一样按分类ID去重，但只查找已有的分类，有分类不存在时返回 false
+func (this *GModel) lookupTerms(tagMgr *TagMgr, names []string) ([]uint64, bool) {
+	tagMark := make(map[uint64]bool)
 	tagIds := make([]uint64, 0)
 
-	for _, tag := range newTags {
-		_, exist := tagMark[tag]
-		if exist {
+	for _, name := range names {
+		tag, err := tagMgr.GetByName(name)
+		if err != nil {
+			return nil, false
+		}
+		if tagMark[tag.Id] {
 			continue
 		}
-		tagMark[tag] = true
+		tagMark[tag.Id] = true
+		tagIds = append(tagIds, tag.Id)
+	}
 
-		// 增加分类
-		id, err := this.tagMgr.Add(tag)
-		if err == nil {
-			tagIds = append(tagIds, id)
+	return tagIds, true
+}
+
+// 文章在一个分类法下新的分类ID
+type termUpdate struct {
+	tax     *taxonomy
+	termIds []uint64
+}
+
+// 按 updates 修改文章的分类并保存文章，updates 中没有的分类法不变
+// 文章只写入一次，之后每个分类法的文章数变化和索引变化分别一次写入分类数据库和索引数据库，
+// 不会出现只修改了一部分分类的情况，保存文章失败时分类和索引都不修改
+func (this *GModel) updateArticle(article *Article, updates []termUpdate) error {
+	oldTermIds := make([][]uint64, len(updates))
+	for i, update := range updates {
+		oldTermIds[i] = update.tax.getTermIds(article)
+		update.tax.setTermIds(article, update.termIds)
+	}
+	if err := this.articleMgr.Update(article); err != nil {
+		return err
+	}
+
+	for i, update := range updates {
+		if err := this.updateTermsBatch(update.tax, article.Id, oldTermIds[i], update.termIds); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	// 先将新分类下的文章数加1，再将旧分类下的文章数减1，
-	// 一定要按这个顺序，因为在减1的时候可能会删掉tag
-	this.addArticleCountForTags(tagIds, 1)
-	this.addArticleCountForTags(article.TagIds, -1)
+// 文章的分类从 oldTermIds 改为 termIds，文章数和索引的变化各自一次写入
+func (this *GModel) updateTermsBatch(tax *taxonomy, articleId uint64, oldTermIds []uint64, termIds []uint64) error {
+	// 同时在新旧分类中的不变，所以不用担心先减1的时候删掉分类
+	counts := make(map[uint64]int64)
+	for _, tagId := range termIds {
+		counts[tagId]++
+	}
+	for _, tagId := range oldTermIds {
+		counts[tagId]--
+	}
+	if err := tax.tagMgr.AddArticleCounts(counts); err != nil {
+		return err
+	}
 
-	// 删掉旧索引
-	this.deleteIndex(article.TagIds, articleId)
+	batch := &KVBatch{}
+	value := []byte(strconv.FormatUint(articleId, 10))
+	bucketCounts := make(map[string]int64)
+	for tagId, count := range counts {
+		key := this.getIndexKey(tagId, articleId)
+		bucketKey := string(this.getBucketKey(tagId, articleId))
+		if count > 0 {
+			if !tax.indexDB.Has(key) {
+				bucketCounts[bucketKey]++
+			}
+			batch.Put(key, value)
+		} else if count < 0 && tax.indexDB.Has(key) {
+			bucketCounts[bucketKey]--
+			batch.Delete(key)
+		}
+	}
 
-	// 更新文章
-	article.TagIds = tagIds
-	article.Data = newData
-	err = this.articleMgr.Update(article)
-	if err != nil {
-		return err
+	// 分段文章数需要加上已有的
+	for key, delta := range bucketCounts {
+		count := int64(this.getBucketCount(tax.indexDB, []byte(key))) + delta
+		if count <= 0 {
+			batch.Delete([]byte(key))
+		} else {
+			batch.Put([]byte(key), []byte(strconv.FormatInt(count, 10)))
+		}
 	}
+	return tax.indexDB.Write(batch)
+}
 
-	// 增加新索引
-	this.addIndex(tagIds, articleId)
+// 记录每个分类法当前最大的分类ID，之后写入失败时用 deleteNewEmptyTags 删除新增加的分类
+func (this *GModel) tagSequences() map[*TagMgr]uint64 {
+	sequences := make(map[*TagMgr]uint64)
+	for _, tax := range this.allTaxonomies() {
+		sequences[tax.tagMgr] = tax.tagMgr.db.CurrentSequence()
+	}
+	return sequences
+}
 
-	return nil
+// 删除 tagSequences 之后新增加的、没有文章的分类
+func (this *GModel) deleteNewEmptyTags(sequences map[*TagMgr]uint64) {
+	for tagMgr, sequence := range sequences {
+		tagMgr.deleteEmptyTagsAfter(sequence)
+	}
 }
 
 // 根据分类ID获取分类
@@ -344,6 +537,14 @@ func (this *GModel) GetTagById(id uint64) (*Tag, error) {
 	return this.tagMgr.GetById(id)
 }
 
+// 批量获取分类，返回分类ID到分类的映射，不存在的分类ID不在结果中
+func (this *GModel) GetTagsByIds(ids []uint64) map[uint64]*Tag {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	return this.tagMgr.GetByIds(ids)
+}
+
 // 根据分类名称获取分类
 func (this *GModel) GetTagByName(name string) (*Tag, error) {
 	this.mutex.RLock()
@@ -354,6 +555,8 @@ func (this *GModel) GetTagByName(name string) (*Tag, error) {
 
 // 返回指定分类的后N个分类（不包括当前分类）
 // 如果分类不存在，则表示获取最旧的N个分类
+//
+// Deprecated: 使用 ListTags 代替
 func (this *GModel) GetNextTags(name string, n int) []*Tag {
 	this.mutex.RLock()
 	defer this.mutex.RUnlock()
@@ -363,6 +566,8 @@ func (this *GModel) GetNextTags(name string, n int) []*Tag {
 
 // 返回指定分类的前N个分类（不包括当前分类）
 // 如果分类不存在，则表示获取最新的N个分类
+//
+// Deprecated: 使用 ListTags 代替
 func (this *GModel) GetPrevTags(name string, n int) []*Tag {
 	this.mutex.RLock()
 	defer this.mutex.RUnlock()
@@ -370,6 +575,50 @@ func (this *GModel) GetPrevTags(name string, n int) []*Tag {
 	return this.tagMgr.PrevByName(name, n)
 }
 
+// 返回文章数最多的N个分类，按文章数从多到少排序，未分类不参与排序
+func (this *GModel) GetTopTags(n int) []*Tag {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	return this.tagMgr.Top(n)
+}
+
+// 按文章数从多到少的顺序，返回指定分类的后N个分类（不包括当前分类），用于分页
+// 如果分类不存在，则表示获取文章数最多的N个分类
+//
+// Deprecated: 使用 ListTopTags 代替
+func (this *GModel) GetNextTopTags(name string, n int) []*Tag {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	return this.tagMgr.TopByName(name, n)
+}
+
+// 按分类ID分页，详见 ListArticles
+func (this *GModel) ListTags(cursor string, n int, order Order) (*Page[*Tag], error) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	return this.tagMgr.List(cursor, n, order)
+}
+
+// 按文章数从多到少的顺序分页，未分类不参与排序，详见 ListArticles
+func (this *GModel) ListTopTags(cursor string, n int) (*Page[*Tag], error) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	return this.tagMgr.ListTop(cursor, n)
+}
+
+// 按名称前缀查找分类，用于输入时提示已有的分类，游标分页详见 ListArticles
+// ignoreCase 为 true 时忽略大小写，名称为空的分类（未分类）不会返回
+func (this *GModel) SearchTagsByPrefix(prefix string, cursor string, n int, ignoreCase bool) (*Page[*Tag], error) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	return this.tagMgr.SearchByPrefix(prefix, cursor, n, ignoreCase)
+}
+
 // 修改分类名称
 func (this *GModel) RenameTag(oldName, newName string) error {
 	this.mutex.Lock()
@@ -378,32 +627,408 @@ func (this *GModel) RenameTag(oldName, newName string) error {
 	return this.tagMgr.Rename(oldName, newName)
 }
 
+// 增加分类，不需要先有文章，返回新增的分类，分类已经存在时返回 ErrExists
+// 注意：没有附加信息的分类，在最后一篇文章移出时会被自动删除
+func (this *GModel) AddTag(name string) (*Tag, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	return this.addTag(this.defaultTaxonomy(), name)
+}
+
+// 删除分类，分类下的文章不会删除，只是不再属于该分类，没有其他分类的文章变成未分类
+func (this *GModel) DeleteTag(name string) error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	return this.deleteTag(this.defaultTaxonomy(), name)
+}
+
+// 设置分类名称的规范化函数，需要在Open之后、使用之前设置，为nil时不做规范化
+// 规范化之后相同的名称被认为是同一个分类，分类本身仍然保存原始名称用于显示
+// 如果数据库中已经有分类，修改规范化函数后需要调用一次 MigrateTagNames
+func (this *GModel) SetTagNormalizer(normalizer TagNormalizer) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.tagMgr.SetNormalizer(normalizer)
+	for _, tax := range this.taxonomies {
+		tax.tagMgr.SetNormalizer(normalizer)
+	}
+}
+
+// 设置游标签名的密钥，需要在Open之后设置，文章、分类、分类法的列表都使用这个密钥
+// 默认每次打开时随机生成，多台服务器或者重启之后需要游标仍然有效时，需要设置固定的密钥，修改之后旧的游标失效
+func (this *GModel) SetCursorSecret(secret []byte) error {
+	return this.cursors.setSecret(secret)
+}
+
+// 按当前的规范化函数合并冲突的分类，并重建分类名称的索引，返回被合并掉的分类数量
+// 规范化之后名称或者别名相同的分类，会合并到ID最小的分类中，文章和索引也会一起迁移
+// 其他分类法也会一起迁移
+// 这是一个一次性的迁移操作，分类很多时会比较慢，完成后在分类数据库中记录标记，之后再调用会直接跳过
+// 没有规范化函数时增加、改名、修改别名都会清除标记；换成其他的规范化函数时需要删除分类数据库的 meta_names_migrated
+func (this *GModel) MigrateTagNames() (int, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	merged := 0
+	for _, tax := range this.allTaxonomies() {
+		if tax.tagMgr.namesMigrated() {
+			continue
+		}
+
+		// 规范化后的名称和别名 -> 分类
+		canonicalTags := make(map[string]*Tag)
+
+		for _, tag := range tax.tagMgr.all() {
+			// 找出名称或者别名冲突的分类，all 按ID从小到大返回，冲突的分类都合并到ID最小的
+			conflicts := make([]*Tag, 0)
+			for _, name := range tax.tagMgr.lookupNames(tag) {
+				if other, exist := canonicalTags[name]; exist && !containsTag(conflicts, other) {
+					conflicts = append(conflicts, other)
+				}
+			}
+			if len(conflicts) == 0 {
+				for _, name := range tax.tagMgr.lookupNames(tag) {
+					canonicalTags[name] = tag
+				}
+				continue
+			}
+
+			canonicalTag := conflicts[0]
+			for _, other := range conflicts[1:] {
+				if other.Id < canonicalTag.Id {
+					canonicalTag = other
+				}
+			}
+			for _, from := range append(conflicts, tag) {
+				if from == canonicalTag {
+					continue
+				}
+				if err := this.mergeTag(tax, from, canonicalTag); err != nil {
+					return merged, err
+				}
+				merged++
+			}
+
+			// 合并后 canonicalTag 带有被合并分类的名称和别名
+			for _, name := range tax.tagMgr.lookupNames(canonicalTag) {
+				canonicalTags[name] = canonicalTag
+			}
+		}
+
+		if err := tax.tagMgr.rebuildKeys(); err != nil {
+			return merged, err
+		}
+		if err := tax.tagMgr.setNamesMigrated(); err != nil {
+			return merged, err
+		}
+	}
+
+	return merged, nil
+}
+
+func containsTag(tags []*Tag, tag *Tag) bool {
+	for _, item := range tags {
+		if item == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// 将 from 分类下的文章全部移到 to 分类，然后删除 from 分类
+func (this *GModel) mergeTag(tax *taxonomy, from, to *Tag) error {
+	for _, articleId := range this.getArticleIdsByTermId(tax, from.Id) {
+		article, err := this.articleMgr.GetById(articleId)
+		if err != nil {
+			continue
+		}
+
+		hasTo := false
+		for _, tagId := range tax.getTermIds(article) {
+			if tagId == to.Id {
+				hasTo = true
+			}
+		}
+
+		// 替换分类ID，保持原有顺序，如果文章已经属于 to 分类，则直接去掉 from 分类
+		tagIds := make([]uint64, 0)
+		for _, tagId := range tax.getTermIds(article) {
+			if tagId != from.Id {
+				tagIds = append(tagIds, tagId)
+			} else if !hasTo {
+				tagIds = append(tagIds, to.Id)
+			}
+		}
+
+		tax.setTermIds(article, tagIds)
+		if err = this.articleMgr.Update(article); err != nil {
+			return err
+		}
+
+		this.deleteIndex(tax.indexDB, []uint64{from.Id}, articleId)
+		if !hasTo {
+			this.addIndex(tax.indexDB, []uint64{to.Id}, articleId)
+			to.ArticleCount++
+		}
+	}
+
+	return tax.tagMgr.merge(from, to)
+}
+
+// 读出分类下的全部文章ID，遍历的时候不修改数据库，所以需要先读出来
+func (this *GModel) getArticleIdsByTermId(tax *taxonomy, tagId uint64) []uint64 {
+	articleIds := make([]uint64, 0)
+	tax.indexDB.Scan([]byte(this.getIndexKeyPrefix(tagId)), func(key, value []byte) bool {
+		if articleId, err := strconv.ParseUint(string(value), 10, 64); err == nil {
+			articleIds = append(articleIds, articleId)
+		}
+		return true
+	})
+	return articleIds
+}
+
+// 空的分类名称在默认的分类法中表示未分类，不能直接增加、删除
+func (this *GModel) addTag(tax *taxonomy, name string) (*Tag, error) {
+	if tax.tagMgr.normalize(name) == "" {
+		return nil, newError(ErrInvalidArgument, "Tag name must not empty")
+	}
+	if _, err := tax.tagMgr.GetByName(name); err == nil {
+		return nil, newError(ErrExists, fmt.Sprintf("Tag Name[%v] exist", name))
+	}
+
+	tagId, err := tax.tagMgr.Add(name)
+	if err != nil {
+		return nil, err
+	}
+	return tax.tagMgr.GetById(tagId)
+}
+
+func (this *GModel) deleteTag(tax *taxonomy, name string) error {
+	if tax.tagMgr.normalize(name) == "" {
+		return newError(ErrInvalidArgument, "Tag name must not empty")
+	}
+	tag, err := tax.tagMgr.GetByName(name)
+	if err != nil {
+		return err
+	}
+
+	for _, articleId := range this.getArticleIdsByTermId(tax, tag.Id) {
+		article, err := this.articleMgr.GetById(articleId)
+		if err != nil {
+			continue
+		}
+
+		tagIds := make([]uint64, 0)
+		for _, tagId := range tax.getTermIds(article) {
+			if tagId != tag.Id {
+				tagIds = append(tagIds, tagId)
+			}
+		}
+
+		// 默认的分类法中每篇文章至少有一个分类，详见 addTags
+		if tax.name == "" && len(tagIds) == 0 {
+			tagIds = this.addTags(nil)
+			this.addArticleCountForTerms(tax, tagIds, 1)
+			this.addIndex(tax.indexDB, tagIds, articleId)
+		}
+
+		tax.setTermIds(article, tagIds)
+		if err = this.articleMgr.Update(article); err != nil {
+			return err
+		}
+		this.deleteIndex(tax.indexDB, []uint64{tag.Id}, articleId)
+	}
+
+	return tax.tagMgr.DeleteById(tag.Id)
+}
+
+// 根据slug获取分类
+func (this *GModel) GetTagBySlug(slug string) (*Tag, error) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	return this.tagMgr.GetBySlug(slug)
+}
+
+// 修改分类的附加信息（slug、描述、别名、自定义属性），会覆盖旧的附加信息
+func (this *GModel) UpdateTagMeta(name string, meta *TagMeta) error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	return this.tagMgr.SetMeta(name, meta)
+}
+
+// 增加分类，返回去重后的分类ID，保持tags的原有顺序
+func (this *GModel) addTags(tags []string) []uint64 {
+	// tags 为空，则补一个空字符串，方便索引，也就是每篇文章至少存在一个分类，该分类可以为空
+	// tagMgr.Add 支持插入空字符串
+	if len(tags) == 0 {
+		tags = []string{""}
+	}
+
+	return this.addTerms(this.tagMgr, tags)
+}
+
+// 增加分类，返回去重后的分类ID，保持names的原有顺序
+func (this *GModel) addTerms(tagMgr *TagMgr, names []string) []uint64 {
+	// 注意需要按分类ID去重，因为不同的名称（比如别名）可能对应同一个分类
+	tagMark := make(map[uint64]bool)
+	tagIds := make([]uint64, 0)
+
+	for _, name := range names {
+		// 增加分类
+		id, err := tagMgr.Add(name)
+		if err != nil || tagMark[id] {
+			continue
+		}
+		tagMark[id] = true
+		tagIds = append(tagIds, id)
+	}
+
+	return tagIds
+}
+
 // 修改分类下的文章数量
-func (this *GModel) addArticleCountForTags(tagIds []uint64, count int64) {
+func (this *GModel) addArticleCountForTerms(tax *taxonomy, tagIds []uint64, count int64) {
 	for _, tagId := range tagIds {
-		this.tagMgr.AddArticleCountForId(tagId, count)
+		tax.tagMgr.AddArticleCountForId(tagId, count)
 
-		// 删除文章数为0的分类
-		if count < 0 && this.tagMgr.GetArticleCountById(tagId) == 0 {
-			this.tagMgr.DeleteById(tagId)
+		// 删除文章数为0的分类，设置了附加信息（slug、描述等）的分类需要保留
+		if count < 0 {
+			if tag, err := tax.tagMgr.GetById(tagId); err == nil && tag.ArticleCount == 0 && !tag.hasMeta() {
+				tax.tagMgr.DeleteById(tagId)
+			}
 		}
 	}
 }
 
+// 获取指定文章的后N篇（不包括当前这篇），保证这N篇文章属于分类法 tax 下的分类 name
+func (this *GModel) getNextArticlesByTerm(tax *taxonomy, name string, articleId uint64, n int) []*Article {
+	articles := make([]*Article, 0)
+	if n == 0 {
+		return articles
+	}
+
+	tag, err := tax.tagMgr.GetByName(name)
+	if err != nil {
+		return articles
+	}
+
+	// 按索引前缀查找
+	// 比如 tagName 为 java， 对应的tagid 为 23， articleId 为 99,
+	// 那么 prefix 为 23_
+	// 那么 searchKey 为 23_000000000000099
+
+	prefix := []byte(this.getIndexKeyPrefix(tag.Id))
+	searchKey := this.getIndexKey(tag.Id, articleId)
+	keys := tax.indexDB.Next(searchKey, n)
+
+	for _, key := range keys {
+		if bytes.HasPrefix(key, prefix) {
+			value, err := tax.indexDB.Get(key)
+			if err != nil {
+				continue
+			}
+
+			if articleId, err := strconv.ParseUint(string(value), 10, 64); err == nil {
+				if article, err := this.articleMgr.GetById(articleId); err == nil {
+					articles = append(articles, article)
+				}
+			}
+		}
+	}
+
+	return articles
+}
+
+// 获取指定文章的前N篇（不包括当前这篇），保证这N篇文章属于分类法 tax 下的分类 name
+func (this *GModel) getPrevArticlesByTerm(tax *taxonomy, name string, articleId uint64, n int) []*Article {
+	articles := make([]*Article, 0)
+	if n == 0 {
+		return articles
+	}
+
+	tag, err := tax.tagMgr.GetByName(name)
+	if err != nil {
+		return articles
+	}
+
+	// 按索引前缀查找
+	// 比如 tagName 为 java， 对应的tagid 为 23， articleId 为 99,
+	// 那么 prefix 为 23_
+	// 那么 searchKey 为 23_000000000000099
+
+	prefix := []byte(this.getIndexKeyPrefix(tag.Id))
+	searchKey := this.getIndexKey(tag.Id, articleId)
+	// 注意，这里n+1，因为查找某个tag的最新N篇时，填的articleId一般是最大的id+1，
+	// 那么指向的位置可能是下一个tag的第一篇，而这一篇会被过滤掉
+	keys := tax.indexDB.Prev(searchKey, n+1)
+
+	for _, key := range keys {
+		if bytes.HasPrefix(key, prefix) {
+			value, err := tax.indexDB.Get(key)
+			if err != nil {
+				continue
+			}
+
+			if articleId, err := strconv.ParseUint(string(value), 10, 64); err == nil {
+				if article, err := this.articleMgr.GetById(articleId); err == nil {
+					articles = append(articles, article)
+
+					if len(articles) == n {
+						break
+					}
+				}
+			}
+		}
+	}
+
+	return articles
+}
+
+// 按文章ID分页获取分类法 tax 下的分类 name 的文章
+func (this *GModel) listArticlesByTerm(tax *taxonomy, name string, cursor string, n int, order Order) (*Page[*Article], error) {
+	tag, err := tax.tagMgr.GetByName(name)
+	if err != nil {
+		return &Page[*Article]{Items: make([]*Article, 0)}, nil
+	}
+
+	// 游标和分类法、分类绑定，不能用在其他分类上
+	scope := "index_" + tax.name + "_" + strconv.FormatUint(tag.Id, 10)
+	prefix := []byte(this.getIndexKeyPrefix(tag.Id))
+
+	return scanPage(this.cursors, tax.indexDB, prefix, scope, cursor, n, order, func(key, value []byte) (*Article, bool) {
+		if articleId, err := strconv.ParseUint(string(value), 10, 64); err == nil {
+			if article, err := this.articleMgr.GetById(articleId); err == nil {
+				return article, true
+			}
+		}
+		return nil, false
+	})
+}
+
 // 增加索引
-func (this *GModel) addIndex(tagIds []uint64, articleId uint64) {
+func (this *GModel) addIndex(indexDB *KVStore, tagIds []uint64, articleId uint64) {
 	value := []byte(strconv.FormatUint(articleId, 10))
 	for _, tagId := range tagIds {
 		key := this.getIndexKey(tagId, articleId)
-		this.indexDB.Put(key, value)
+		if !indexDB.Has(key) {
+			this.addBucketCount(indexDB, tagId, articleId, 1)
+		}
+		indexDB.Put(key, value)
 	}
 }
 
 // 删除索引
-func (this *GModel) deleteIndex(tagIds []uint64, articleId uint64) {
+func (this *GModel) deleteIndex(indexDB *KVStore, tagIds []uint64, articleId uint64) {
 	for _, tagId := range tagIds {
 		key := this.getIndexKey(tagId, articleId)
-		this.indexDB.Delete(key)
+		if indexDB.Delete(key) == nil {
+			this.addBucketCount(indexDB, tagId, articleId, -1)
+		}
 	}
 }
 
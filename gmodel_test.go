@@ -1,6 +1,7 @@
 package gmodel
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"testing"
@@ -193,6 +194,322 @@ func TestNextGModel(t *testing.T) {
 
 }
 
+func TestGModelTagMeta(t *testing.T) {
+	articleDBPath := "test_article.db"
+	tagDBPath := "test_tag.db"
+	indexDBPath := "test_index.db"
+
+	defer func() {
+		os.RemoveAll(articleDBPath)
+		os.RemoveAll(tagDBPath)
+		os.RemoveAll(indexDBPath)
+	}()
+
+	gmodel := &GModel{}
+	err := gmodel.Open(articleDBPath, tagDBPath, indexDBPath)
+	if err != nil {
+		t.Fatal()
+	}
+	defer gmodel.Close()
+
+	articleId, _ := gmodel.AddArticle([]string{"JavaScript"}, "data_id_1")
+	if err = gmodel.UpdateTagMeta("JavaScript", &TagMeta{Slug: "javascript", Aliases: []string{"JS"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 别名和原分类是同一个分类
+	gmodel.AddArticle([]string{"JS", "JavaScript"}, "data_id_2")
+	if gmodel.GetTagCount() != 1 || gmodel.GetArticleCountByTag("JavaScript") != 2 {
+		t.Fatal()
+	}
+	article, _ := gmodel.GetArticle(2)
+	if len(article.TagIds) != 1 {
+		t.Fatal()
+	}
+	if len(gmodel.GetNextArticlesByTag("JS", 0, 10)) != 2 {
+		t.Fatal()
+	}
+
+	// 设置了附加信息的分类，文章数为0时也不会被删除
+	gmodel.DeleteArticle(articleId)
+	gmodel.DeleteArticle(2)
+	tag, err := gmodel.GetTagBySlug("javascript")
+	if err != nil || tag.Name != "JavaScript" || tag.ArticleCount != 0 {
+		t.Fatal(err)
+	}
+}
+
+func TestGModelAddDeleteTag(t *testing.T) {
+	articleDBPath := "test_article.db"
+	tagDBPath := "test_tag.db"
+	indexDBPath := "test_index.db"
+
+	defer func() {
+		os.RemoveAll(articleDBPath)
+		os.RemoveAll(tagDBPath)
+		os.RemoveAll(indexDBPath)
+	}()
+
+	gmodel := &GModel{}
+	err := gmodel.Open(articleDBPath, tagDBPath, indexDBPath)
+	if err != nil {
+		t.Fatal()
+	}
+	defer gmodel.Close()
+
+	// 增加没有文章的分类
+	tag, err := gmodel.AddTag("go")
+	if err != nil || tag.Name != "go" || tag.ArticleCount != 0 {
+		t.Fatal(err)
+	}
+	if _, err = gmodel.AddTag("go"); !errors.Is(err, ErrExists) {
+		t.Fatal(err)
+	}
+	if _, err = gmodel.AddTag(""); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatal(err)
+	}
+
+	articleId1, _ := gmodel.AddArticle([]string{"go", "db"}, "data_id_1")
+	articleId2, _ := gmodel.AddArticle([]string{"go"}, "data_id_2")
+	if gmodel.GetTagCount() != 2 || gmodel.GetArticleCountByTag("go") != 2 {
+		t.Fatal()
+	}
+
+	// 删除分类后文章保留，没有其他分类的文章变成未分类
+	if err = gmodel.DeleteTag("go"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = gmodel.GetTagByName("go"); !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+	if err = gmodel.DeleteTag("go"); !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+	article, err := gmodel.GetArticle(articleId1)
+	if err != nil || len(article.TagIds) != 1 {
+		t.Fatal(err)
+	}
+	if gmodel.GetArticleCountByTag("db") != 1 || gmodel.GetArticleCountByTag("") != 1 {
+		t.Fatal()
+	}
+	articles := gmodel.GetNextArticlesByTag("", 0, 10)
+	if len(articles) != 1 || articles[0].Id != articleId2 {
+		t.Fatal()
+	}
+
+	if err = gmodel.DeleteArticle(articleId2); err != nil {
+		t.Fatal(err)
+	}
+	if gmodel.GetArticleCountByTag("") != 0 || gmodel.GetTagCount() != 1 {
+		t.Fatal()
+	}
+}
+
+func TestGModelUpsertArticle(t *testing.T) {
+	paths := []string{"test_article.db", "test_tag.db", "test_index.db", "test_keyword_tag.db", "test_keyword_index.db"}
+	defer func() {
+		for _, path := range paths {
+			os.RemoveAll(path)
+		}
+	}()
+
+	gmodel := &GModel{}
+	err := gmodel.Open(paths[0], paths[1], paths[2])
+	if err != nil {
+		t.Fatal()
+	}
+	defer gmodel.Close()
+	if err = gmodel.OpenTaxonomy("keyword", paths[3], paths[4]); err != nil {
+		t.Fatal(err)
+	}
+
+	// articleId 为0时增加文章
+	terms := map[string][]string{"keyword": {"leveldb"}}
+	articleId, result, err := gmodel.UpsertArticle(0, []string{"go", "db"}, terms, "data_1")
+	if err != nil || articleId == 0 || result != UpsertCreated || result.String() != "created" {
+		t.Fatal(err, articleId, result)
+	}
+
+	// 内容没有变化时不修改，重复的分类、terms 中没有出现的分类法不影响比较
+	id, result, err := gmodel.UpsertArticle(articleId, []string{"go", "db", "go"}, nil, "data_1")
+	if err != nil || id != articleId || result != UpsertUnchanged {
+		t.Fatal(err, id, result)
+	}
+	if _, result, _ = gmodel.UpsertArticle(articleId, []string{"go", "db"}, terms, "data_1"); result != UpsertUnchanged {
+		t.Fatal(result)
+	}
+	if gmodel.GetArticleCountByTag("go") != 1 || gmodel.GetArticleCountByTerm("keyword", "leveldb") != 1 {
+		t.Fatal()
+	}
+
+	// 分类顺序、分类、其他分类法、数据有变化时修改
+	for _, c := range []struct {
+		tags  []string
+		terms map[string][]string
+		data  string
+	}{
+		{[]string{"db", "go"}, nil, "data_1"},
+		{[]string{"db"}, nil, "data_1"},
+		{[]string{"db"}, map[string][]string{"keyword": {"leveldb", "go"}}, "data_1"},
+		{[]string{"db"}, map[string][]string{"keyword": {}}, "data_1"},
+		{[]string{"db"}, nil, "data_2"},
+		{nil, nil, "data_2"},
+	} {
+		if _, result, err = gmodel.UpsertArticle(articleId, c.tags, c.terms, c.data); err != nil || result != UpsertUpdated {
+			t.Fatal(err, result, c)
+		}
+		if _, result, _ = gmodel.UpsertArticle(articleId, c.tags, c.terms, c.data); result != UpsertUnchanged {
+			t.Fatal(result, c)
+		}
+	}
+
+	article, err := gmodel.GetArticle(articleId)
+	if err != nil || article.Data != "data_2" || len(article.Terms) != 0 {
+		t.Fatal(err, article)
+	}
+	if gmodel.GetArticleCountByTag("") != 1 || gmodel.GetArticleCountByTag("go") != 0 || gmodel.GetArticleCountByTerm("keyword", "leveldb") != 0 {
+		t.Fatal()
+	}
+
+	if _, _, err = gmodel.UpsertArticle(articleId+1, nil, nil, "data"); !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+	if _, _, err = gmodel.UpsertArticle(articleId, nil, nil, ""); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatal(err)
+	}
+	if _, _, err = gmodel.UpsertArticle(articleId, nil, map[string][]string{"not_exist": {"go"}}, "data"); !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+
+	// 保存文章失败时所有分类法的文章数和索引都不修改
+	ghost := &Article{Id: articleId + 1, TagIds: article.TagIds, Data: "data"}
+	tagIds, termIds := gmodel.addTags([]string{"rust"}), gmodel.addTaxonomyTerms(gmodel.taxonomies["keyword"], []string{"leveldb"})
+	err = gmodel.updateArticle(ghost, []termUpdate{{gmodel.defaultTaxonomy(), tagIds}, {gmodel.taxonomies["keyword"], termIds}})
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+	if gmodel.GetArticleCountByTag("") != 1 || gmodel.GetArticleCountByTag("rust") != 0 || gmodel.GetArticleCountByTerm("keyword", "leveldb") != 0 {
+		t.Fatal()
+	}
+	if page, err := gmodel.ListArticlesByTag("rust", "", 10, OrderDesc); err != nil || len(page.Items) != 0 {
+		t.Fatal(err, page)
+	}
+
+	// 一次修改多个分类法，文章数和索引一起更新
+	if _, _, err = gmodel.UpsertArticle(articleId, []string{"go", "db"}, map[string][]string{"keyword": {"leveldb"}}, "data_3"); err != nil {
+		t.Fatal(err)
+	}
+	if gmodel.GetArticleCountByTag("") != 0 || gmodel.GetArticleCountByTag("go") != 1 || gmodel.GetArticleCountByTerm("keyword", "leveldb") != 1 {
+		t.Fatal()
+	}
+	if page, err := gmodel.ListArticlesByTerm("keyword", "leveldb", "", 10, OrderDesc); err != nil || len(page.Items) != 1 || page.Items[0].Id != articleId {
+		t.Fatal(err, page)
+	}
+	if _, err = gmodel.GetTagByName(""); !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrateTagNames(t *testing.T) {
+	articleDBPath := "test_article.db"
+	tagDBPath := "test_tag.db"
+	indexDBPath := "test_index.db"
+
+	defer func() {
+		os.RemoveAll(articleDBPath)
+		os.RemoveAll(tagDBPath)
+		os.RemoveAll(indexDBPath)
+	}()
+
+	gmodel := &GModel{}
+	err := gmodel.Open(articleDBPath, tagDBPath, indexDBPath)
+	if err != nil {
+		t.Fatal()
+	}
+	defer gmodel.Close()
+
+	// 没有规范化时是三个不同的分类
+	gmodel.AddArticle([]string{"Go", "Rust"}, "data_id_1")
+	gmodel.AddArticle([]string{"go ", "Ｇｏ"}, "data_id_2")
+	gmodel.AddArticle([]string{"Ｇｏ"}, "data_id_3")
+	gmodel.UpdateTagMeta("go ", &TagMeta{Slug: "go", Aliases: []string{"Golang"}})
+
+	// 名称和其他分类的别名冲突时也合并
+	gmodel.UpdateTagMeta("Rust", &TagMeta{Aliases: []string{"rs"}})
+	gmodel.AddArticle([]string{"RS"}, "data_id_4")
+	if gmodel.GetTagCount() != 5 {
+		t.Fatal()
+	}
+
+	gmodel.SetTagNormalizer(DefaultTagNormalizer)
+	merged, err := gmodel.MigrateTagNames()
+	if err != nil || merged != 3 {
+		t.Fatal(merged, err)
+	}
+	if tag, err := gmodel.GetTagByName("rs"); err != nil || tag.Name != "Rust" || tag.ArticleCount != 2 {
+		t.Fatal(err, tag)
+	}
+	if gmodel.GetTagCount() != 2 {
+		t.Fatal()
+	}
+
+	tag, err := gmodel.GetTagByName("GO")
+	if err != nil || tag.Name != "Go" || tag.ArticleCount != 3 || tag.Slug != "go" {
+		t.Fatal(err)
+	}
+	if tag, err = gmodel.GetTagBySlug("go"); err != nil || tag.Name != "Go" {
+		t.Fatal(err)
+	}
+	if tag, err = gmodel.GetTagByName("golang"); err != nil || tag.Name != "Go" {
+		t.Fatal(err)
+	}
+
+	articles := gmodel.GetNextArticlesByTag("go", 0, 10)
+	if len(articles) != 3 {
+		t.Fatal()
+	}
+	for _, article := range articles {
+		if article.Id == 1 && !isEqual(convertTagIds(gmodel, article.TagIds), []string{"Go", "Rust"}) {
+			t.Fatal()
+		}
+		if article.Id != 1 && !isEqual(convertTagIds(gmodel, article.TagIds), []string{"Go"}) {
+			t.Fatal()
+		}
+	}
+
+	if page, err := gmodel.SearchTagsByPrefix(" G", "", 10, false); err != nil || len(page.Items) != 1 || page.Items[0].Name != "Go" {
+		t.Fatal(err)
+	}
+	if page, err := gmodel.SearchTagsByPrefix("R", "", 10, true); err != nil || len(page.Items) != 1 || page.Items[0].Name != "Rust" {
+		t.Fatal(err)
+	}
+
+	// 再次执行没有影响，已经记录了标记，直接跳过
+	if !gmodel.tagMgr.namesMigrated() {
+		t.Fatal()
+	}
+	if merged, err = gmodel.MigrateTagNames(); err != nil || merged != 0 {
+		t.Fatal()
+	}
+	if gmodel.GetArticleCountByTag("go") != 3 || gmodel.GetArticleCountByTag("rust") != 2 {
+		t.Fatal()
+	}
+
+	// 没有规范化函数时增加分类会清除标记，需要重新合并
+	gmodel.SetTagNormalizer(nil)
+	gmodel.AddArticle([]string{"RUST"}, "data_id_5")
+	if gmodel.tagMgr.namesMigrated() {
+		t.Fatal()
+	}
+	gmodel.SetTagNormalizer(DefaultTagNormalizer)
+	if merged, err = gmodel.MigrateTagNames(); err != nil || merged != 1 {
+		t.Fatal(merged, err)
+	}
+	if gmodel.GetArticleCountByTag("rust") != 3 {
+		t.Fatal()
+	}
+}
+
 func isEqual(left, right []string) bool {
 	if len(left) != len(right) {
 		return false
@@ -214,3 +531,51 @@ func convertTagIds(gmodel *GModel, ids []uint64) []string {
 	}
 	return names
 }
+
+func TestGModelGetArticles(t *testing.T) {
+	articleDBPath := "test_article.db"
+	tagDBPath := "test_tag.db"
+	indexDBPath := "test_index.db"
+
+	defer func() {
+		os.RemoveAll(articleDBPath)
+		os.RemoveAll(tagDBPath)
+		os.RemoveAll(indexDBPath)
+	}()
+
+	gmodel := &GModel{}
+	err := gmodel.Open(articleDBPath, tagDBPath, indexDBPath)
+	if err != nil {
+		t.Fatal()
+	}
+	defer gmodel.Close()
+
+	gmodel.AddArticle([]string{"tag1", "tag2"}, "data_id_1")
+	gmodel.AddArticle([]string{"tag2"}, "data_id_2")
+	gmodel.AddArticle([]string{"tag3"}, "data_id_3")
+	gmodel.DeleteArticle(2)
+
+	// 按请求的顺序返回，允许重复
+	articles, errs := gmodel.GetArticles([]uint64{3, 2, 1, 100, 3})
+	if len(articles) != 5 || len(errs) != 5 {
+		t.Fatal()
+	}
+	if articles[0].Data != "data_id_3" || articles[2].Data != "data_id_1" || articles[4].Id != 3 {
+		t.Fatal()
+	}
+	if articles[1] != nil || errs[1] == nil || articles[3] != nil || errs[3] == nil || errs[0] != nil {
+		t.Fatal()
+	}
+	articles, errs = gmodel.GetArticles(nil)
+	if len(articles) != 0 || len(errs) != 0 {
+		t.Fatal()
+	}
+
+	tags := gmodel.GetTagsByIds([]uint64{1, 2, 3, 2, 100})
+	if len(tags) != 3 || tags[1].Name != "tag1" || tags[3].Name != "tag3" {
+		t.Fatal()
+	}
+	if len(gmodel.GetTermsByIds("none", []uint64{1})) != 0 {
+		t.Fatal()
+	}
+}
@@ -0,0 +1,150 @@
+package gmodel
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// 不需要数据库的整型ID和字符串ID互相转换，用于只是为了隐藏递增的文章ID、不需要自定义ID的网站
+// 先用密钥对 uint64 做可逆的置换（8轮 Feistel 网络，轮函数为 HMAC-SHA256），再编码成固定长度的字符串，
+// 后面加上用密钥计算的校验字符，随便写的字符串（比如和自定义ID同样长度的单词）几乎不可能通过校验，不会被当成 codec 的字符串ID
+// 没有密钥时无法从字符串ID推算出整型ID，也无法按顺序遍历；每个整型ID只对应一个字符串ID，不会冲突
+// 不同的密钥（或者字符集）得到的字符串ID完全不同，所以一旦使用就不能修改，详见 IdMgr.MigrateToCodec
+type IdCodec struct {
+	secret      []byte
+	alphabet    string
+	length      int // 表示整型ID的字符数
+	checkLength int // 校验字符数
+}
+
+// 密钥的最小长度
+const MinIdCodecSecretLen = 16
+
+const idCodecRounds = 8
+
+// 校验字符至少能表示的位数，随便写的字符串通过校验的概率不超过 2^-idCodecCheckBits
+const idCodecCheckBits = 32
+
+// alphabet 为空时使用 DefaultIdAlphabet，规则同 IdGenConfig.Alphabet
+// 字符串ID的长度由字符集的大小决定，要能表示所有的 uint64 和 idCodecCheckBits 位的校验，默认的字符集为12+6位
+func NewIdCodec(secret []byte, alphabet string) (*IdCodec, error) {
+	if len(secret) < MinIdCodecSecretLen {
+		return nil, newError(ErrInvalidArgument, fmt.Sprintf("IdCodec secret needs at least %v bytes", MinIdCodecSecretLen))
+	}
+	if alphabet == "" {
+		alphabet = DefaultIdAlphabet
+	}
+	if err := checkIdAlphabet(alphabet); err != nil {
+		return nil, err
+	}
+
+	// 最短的长度，使得 len(alphabet)^length > math.MaxUint64
+	length := 0
+	base := uint64(len(alphabet))
+	for capacity := uint64(1); ; length++ {
+		if capacity > math.MaxUint64/base {
+			length++
+			break
+		}
+		capacity *= base
+	}
+
+	// 最短的校验长度，使得 len(alphabet)^checkLength >= 2^idCodecCheckBits
+	checkLength := 0
+	for capacity := uint64(1); capacity < 1<<idCodecCheckBits; checkLength++ {
+		capacity *= base
+	}
+
+	return &IdCodec{
+		secret:      append([]byte(nil), secret...),
+		alphabet:    alphabet,
+		length:      length,
+		checkLength: checkLength,
+	}, nil
+}
+
+// 返回字符串ID的长度（包括校验字符）
+func (this *IdCodec) Length() int {
+	return this.length + this.checkLength
+}
+
+// 整型ID转换成字符串ID
+func (this *IdCodec) Encode(intId uint64) string {
+	value := this.permute(intId, false)
+
+	buf := make([]byte, this.length)
+	this.putDigits(buf, value)
+	return string(buf) + this.check(value)
+}
+
+// 字符串ID转换成整型ID，不是 Encode 生成的字符串ID（长度不对、有其他字符、超出范围、校验不通过）时返回false
+func (this *IdCodec) Decode(stringId string) (uint64, bool) {
+	if len(stringId) != this.Length() {
+		return 0, false
+	}
+
+	base := uint64(len(this.alphabet))
+	var value uint64
+	for i := 0; i < this.length; i++ {
+		digit := strings.IndexByte(this.alphabet, stringId[i])
+		if digit < 0 || value > (math.MaxUint64-uint64(digit))/base {
+			return 0, false
+		}
+		value = value*base + uint64(digit)
+	}
+	if !hmac.Equal([]byte(stringId[this.length:]), []byte(this.check(value))) {
+		return 0, false
+	}
+	return this.permute(value, true), true
+}
+
+// 把 value 按字符集编码到 buf 中，高位在前，超出 buf 长度的高位丢弃
+func (this *IdCodec) putDigits(buf []byte, value uint64) {
+	base := uint64(len(this.alphabet))
+	for i := len(buf) - 1; i >= 0; i-- {
+		buf[i] = this.alphabet[value%base]
+		value /= base
+	}
+}
+
+// 置换之后的值的校验字符，用 HMAC-SHA256 计算，和 Feistel 网络的轮函数用不同的输入前缀区分
+func (this *IdCodec) check(value uint64) string {
+	var input [9]byte
+	input[0] = 0xff
+	binary.BigEndian.PutUint64(input[1:], value)
+
+	mac := hmac.New(sha256.New, this.secret)
+	mac.Write(input[:])
+
+	buf := make([]byte, this.checkLength)
+	this.putDigits(buf, binary.BigEndian.Uint64(mac.Sum(nil)))
+	return string(buf)
+}
+
+// Feistel 网络，高32位和低32位交替变换，inverse 为true时按相反的顺序还原
+func (this *IdCodec) permute(value uint64, inverse bool) uint64 {
+	left, right := uint32(value>>32), uint32(value)
+	for i := 0; i < idCodecRounds; i++ {
+		if inverse {
+			round := idCodecRounds - 1 - i
+			left, right = right^this.round(round, left), left
+		} else {
+			left, right = right, left^this.round(i, right)
+		}
+	}
+	return uint64(left)<<32 | uint64(right)
+}
+
+func (this *IdCodec) round(round int, half uint32) uint32 {
+	var input [5]byte
+	input[0] = byte(round)
+	binary.BigEndian.PutUint32(input[1:], half)
+
+	mac := hmac.New(sha256.New, this.secret)
+	mac.Write(input[:])
+	return binary.BigEndian.Uint32(mac.Sum(nil))
+}
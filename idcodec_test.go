@@ -0,0 +1,69 @@
+package gmodel
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestIdCodec(t *testing.T) {
+	secret := []byte("0123456789abcdef")
+	if _, err := NewIdCodec([]byte("short"), ""); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatal(err)
+	}
+	if _, err := NewIdCodec(secret, "aa"); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatal(err)
+	}
+
+	codec, err := NewIdCodec(secret, "")
+	if err != nil || codec.Length() != 18 {
+		t.Fatal(err)
+	}
+
+	// 可以还原，长度固定，相邻的ID看不出规律
+	seen := make(map[string]bool)
+	for _, intId := range []uint64{0, 1, 2, 3, 100, 1 << 32, math.MaxUint64 - 1, math.MaxUint64} {
+		stringId := codec.Encode(intId)
+		if len(stringId) != codec.Length() || strings.Trim(stringId, DefaultIdAlphabet) != "" || seen[stringId] {
+			t.Fatal(intId, stringId)
+		}
+		seen[stringId] = true
+
+		if decoded, ok := codec.Decode(stringId); !ok || decoded != intId {
+			t.Fatal(intId, stringId, decoded)
+		}
+	}
+	if codec.Encode(1)[:6] == codec.Encode(2)[:6] {
+		t.Fatal(codec.Encode(1), codec.Encode(2))
+	}
+
+	// 同样的密钥结果一样，不同的密钥结果不同
+	same, _ := NewIdCodec(secret, "")
+	other, _ := NewIdCodec([]byte("fedcba9876543210"), "")
+	if same.Encode(100) != codec.Encode(100) || other.Encode(100) == codec.Encode(100) {
+		t.Fatal()
+	}
+
+	// 长度不对、字符不在字符集中、超出 uint64 范围、校验不通过的都不能解码
+	encoded := codec.Encode(1)
+	changed := encoded[:len(encoded)-1] + "a"
+	if changed == encoded {
+		changed = encoded[:len(encoded)-1] + "b"
+	}
+	for _, stringId := range []string{"", "abc", encoded + "a", "0000000000oo", "ZZZZZZZZZZZZaaaaaa", "dictionariesabcdef", changed} {
+		if _, ok := codec.Decode(stringId); ok {
+			t.Fatal(stringId)
+		}
+	}
+
+	// 字符集决定长度：2^64 需要64位二进制、20位十进制，2^32 的校验需要32位二进制、10位十进制
+	binary, _ := NewIdCodec(secret, "01")
+	decimal, _ := NewIdCodec(secret, "0123456789")
+	if binary.Length() != 96 || decimal.Length() != 30 {
+		t.Fatal(binary.Length(), decimal.Length())
+	}
+	if intId, ok := decimal.Decode(decimal.Encode(12345)); !ok || intId != 12345 {
+		t.Fatal(intId)
+	}
+}
@@ -4,43 +4,243 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 )
 
-// ID是递增的，所以不需要考虑删除，只管做映射即可
 // 这个类的作用：将整型ID和字符串ID互相映射
-// 只能转换整型ID到字符串ID
+// 删除映射时可以保留墓碑，墓碑中的字符串ID查不到整型ID，但是不能再次使用
+// 修改字符串ID时旧的字符串ID可以重定向到新的，详见 Rebind
+// 设置了 IdCodec 时，没有保存映射的整型ID用 IdCodec 转换，新的整型ID不再保存映射，详见 SetCodec、OpenStateless
 type IdMgr struct {
 	db    *KVStore
 	mutex sync.RWMutex
+
+	redirectTTL time.Duration
+	idGen       IdGenConfig
+	codec       *IdCodec
+}
+
+// 修改字符串ID之后，旧的字符串ID默认保留的重定向时间
+const DefaultIdRedirectTTL = 90 * 24 * time.Hour
+
+// 随机生成字符串ID的默认规则
+// 默认的字符集去掉了 l L 1 0 o O 这6个不易识别的字符，每个字符有62-6=56种可能，56^8 约等于 9.7e13
+const (
+	DefaultIdLength     = 8
+	DefaultIdAlphabet   = "abcdefghijkmnpqrstuvwxyzABCDEFGHIJKMNPQRSTUVWXYZ23456789"
+	DefaultIdMaxRetries = 10
+)
+
+// 随机生成字符串ID的规则，字段为0或者为空时使用默认值
+// 每个 IdMgr（一个数据库）是一个独立的ID命名空间，可以分别设置，比如文章用长一点的ID，短链接用短一点的ID
+// 随机数来自 crypto/rand，ID不能通过时间或者已有的ID推测出来
+type IdGenConfig struct {
+	Length     int    // 字符串ID的长度，范围为 [4, 64]
+	Alphabet   string // 字符集，只能是可见的ASCII字符，不能重复，至少2个字符
+	MaxRetries int    // 和已有的字符串ID冲突时最多重试的次数，超过之后返回 ErrIdExhausted
+}
+
+// 检查字符串ID的生成规则
+func CheckIdGenConfig(config IdGenConfig) error {
+	if config.Length != 0 && (config.Length < 4 || config.Length > 64) {
+		return newError(ErrInvalidArgument, fmt.Sprintf("IdGenConfig Length [%v] is invalid, want 4 to 64", config.Length))
+	}
+	if config.MaxRetries < 0 {
+		return newError(ErrInvalidArgument, fmt.Sprintf("IdGenConfig MaxRetries [%v] must not be negative", config.MaxRetries))
+	}
+	if config.Alphabet == "" {
+		return nil
+	}
+	return checkIdAlphabet(config.Alphabet)
+}
+
+// 字符集只能是可见的ASCII字符，不能重复，至少2个字符
+func checkIdAlphabet(alphabet string) error {
+	if len(alphabet) < 2 {
+		return newError(ErrInvalidArgument, "Id Alphabet needs at least 2 characters")
+	}
+	seen := make(map[byte]bool)
+	for i := 0; i < len(alphabet); i++ {
+		c := alphabet[i]
+		if c <= ' ' || c > '~' {
+			return newError(ErrInvalidArgument, fmt.Sprintf("Id Alphabet has invalid character [%q]", c))
+		}
+		if seen[c] {
+			return newError(ErrInvalidArgument, fmt.Sprintf("Id Alphabet has duplicated character [%c]", c))
+		}
+		seen[c] = true
+	}
+	return nil
+}
+
+// 补上默认值
+func (this IdGenConfig) withDefaults() IdGenConfig {
+	if this.Length == 0 {
+		this.Length = DefaultIdLength
+	}
+	if this.Alphabet == "" {
+		this.Alphabet = DefaultIdAlphabet
+	}
+	if this.MaxRetries == 0 {
+		this.MaxRetries = DefaultIdMaxRetries
+	}
+	return this
+}
+
+// 旧的字符串ID From 重定向到新的字符串ID To，直到 ExpireAt
+type IdRedirect struct {
+	From     string
+	To       string
+	IntId    uint64
+	ExpireAt time.Time
 }
 
 var (
-	idPrefixInt    = "int_"
-	idPrefixString = "str_"
+	idPrefixInt       = "int_"
+	idPrefixString    = "str_"
+	idPrefixTombstone = "del_"
+	idPrefixRedirect  = "rdr_"
+
+	// 重定向的反向索引：rdi_整型ID_旧字符串ID，删除整型ID时只需要遍历它自己的重定向
+	idPrefixRedirectIndex = "rdi_"
+
+	// 映射的数量，墓碑也占用key，所以不能再用 key总数/2 计算
+	idKeyCount = []byte("meta_count")
+
+	// 存在时表示所有重定向都已经建立反向索引
+	idKeyRedirectIndex = []byte("meta_redirect_index")
 )
 
+// MigrateToCodec 每次写入的映射数量
+const idMigrateBatchSize = 1000
+
 // 打开数据库文件
 func (this *IdMgr) Open(path string) error {
 	this.db = &KVStore{}
-	return this.db.Open(path)
+	if err := this.db.Open(path); err != nil {
+		return err
+	}
+
+	// 旧的数据库没有墓碑，映射的数量就是 key总数/2
+	if !this.db.Has(idKeyCount) {
+		if err := this.db.Put(idKeyCount, []byte(strconv.FormatUint(this.db.Count()/2, 10))); err != nil {
+			this.db.Close()
+			return err
+		}
+	}
+
+	// 旧的数据库没有重定向的反向索引，打开时补上
+	if !this.db.Has(idKeyRedirectIndex) {
+		if err := this.buildRedirectIndex(); err != nil {
+			this.db.Close()
+			return err
+		}
+	}
+	return nil
+}
+
+func (this *IdMgr) buildRedirectIndex() error {
+	batch := &KVBatch{}
+	this.db.Scan([]byte(idPrefixRedirect), func(key, value []byte) bool {
+		intIdStr, _, _ := strings.Cut(string(value), "_")
+		if intId, err := strconv.ParseUint(intIdStr, 10, 64); err == nil {
+			batch.Put(this.getKeyFromRedirectIndex(intId, strings.TrimPrefix(string(key), idPrefixRedirect)), []byte{})
+		}
+		return true
+	})
+	batch.Put(idKeyRedirectIndex, []byte{})
+	return this.db.Write(batch)
+}
+
+// 不使用数据库，整型ID和字符串ID全部用 codec 转换，不能保存自定义的字符串ID（返回 ErrReadOnly）
+func (this *IdMgr) OpenStateless(codec *IdCodec) error {
+	if codec == nil {
+		return newError(ErrInvalidArgument, "IdMgr OpenStateless codec must not nil")
+	}
+	this.db = nil
+	this.codec = codec
+	return nil
+}
+
+// 在 Open 之后设置，已经保存的映射（包括自定义的字符串ID）仍然有效，优先于 codec
+// 之后 AddIntId、AddIdMaps 自动生成的字符串ID都由 codec 转换，不再保存，codec 为nil时恢复随机生成
+// codec 能解码的字符串ID都算已经使用，不能再作为自定义的字符串ID，避免指向其他整型ID
+func (this *IdMgr) SetCodec(codec *IdCodec) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.codec = codec
 }
 
 func (this *IdMgr) Close() error {
+	if this.db == nil {
+		return nil
+	}
 	return this.db.Close()
 }
 
+// 是否用 OpenStateless 打开，没有数据库，不能保存自定义的字符串ID
+func (this *IdMgr) Stateless() bool {
+	return this.db == nil && this.codec != nil
+}
+
+// 没有数据库时不能保存映射
+func (this *IdMgr) checkWritable() error {
+	if this.db == nil {
+		return newError(ErrReadOnly, "IdMgr is stateless, stringId can not be saved")
+	}
+	return nil
+}
+
+// 设置修改字符串ID之后旧的字符串ID保留的重定向时间，为0时使用 DefaultIdRedirectTTL，小于0时不保留
+func (this *IdMgr) SetRedirectTTL(ttl time.Duration) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.redirectTTL = ttl
+}
+
+// 设置随机生成字符串ID的规则，只影响之后生成的字符串ID，已有的不变
+func (this *IdMgr) SetIdGenConfig(config IdGenConfig) error {
+	if err := CheckIdGenConfig(config); err != nil {
+		return err
+	}
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.idGen = config
+	return nil
+}
+
 // 获取记录的数量
 func (this *IdMgr) Count() uint64 {
 	this.mutex.RLock()
 	defer this.mutex.RUnlock()
 
-	// 注意要除以2
-	return this.db.Count() / 2
+	return this.count()
+}
+
+func (this *IdMgr) count() uint64 {
+	if this.db == nil {
+		return 0
+	}
+	value, err := this.db.Get(idKeyCount)
+	if err != nil {
+		return 0
+	}
+	count, _ := strconv.ParseUint(string(value), 10, 64)
+	return count
+}
+
+func (this *IdMgr) putCount(batch *KVBatch, count uint64) {
+	batch.Put(idKeyCount, []byte(strconv.FormatUint(count, 10)))
 }
 
 // 增加一个整型ID
-// 返回对应的字符串ID
+// 返回对应的字符串ID，设置了 codec 时直接返回 codec 转换的字符串ID，不保存
 func (this *IdMgr) AddIntId(intId uint64) (string, error) {
 	this.mutex.Lock()
 	defer this.mutex.Unlock()
@@ -50,36 +250,193 @@ func (this *IdMgr) AddIntId(intId uint64) (string, error) {
 		if stringId, ok := this.getStringId(intId); ok {
 			return stringId, nil
 		} else {
-			return "", errors.New(fmt.Sprintf("intId[%v] not found", intId))
+			return "", newError(ErrNotFound, fmt.Sprintf("intId[%v] not found", intId))
 		}
 	}
 
+	if this.codec != nil {
+		return this.codec.Encode(intId), nil
+	}
+
 	// 生成字符串ID
-	stringId := this.generateStringId()
+	stringId, err := this.generateStringId(nil)
+	if err != nil {
+		return "", err
+	}
 
 	return stringId, this.setIdMap(intId, stringId)
 }
 
-// 获取整型ID对应的字符串ID
+// 批量保存映射，stringIds 和 intIds 一一对应，stringIds 中为空的项（或者 stringIds 为nil）会随机生成字符串ID
+// 返回最终的字符串ID，所有映射一次写入，要么全部成功，要么全部失败
+// 和 SetIdMap 一样检查冲突，批量中重复的ID也算冲突，有一项冲突时全部不写入，返回 *IdConflictError
+// 设置了 codec 时，为空的项（以及和 codec 转换结果一样的项）使用 codec 转换的字符串ID，不保存
+func (this *IdMgr) AddIdMaps(intIds []uint64, stringIds []string) ([]string, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	result, batch, err := this.prepareIdMaps(intIds, stringIds)
+	if err != nil || batch.Len() == 0 {
+		return result, err
+	}
+	if err := this.db.Write(batch); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// 和 AddIdMaps 一样检查映射，但不写入，用于先写入其他数据、再调用 AddIdMaps 之前提前发现冲突
+func (this *IdMgr) CheckIdMaps(intIds []uint64, stringIds []string) error {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	_, _, err := this.prepareIdMaps(intIds, stringIds)
+	return err
+}
+
+// 检查映射并生成字符串ID，返回最终的字符串ID和需要写入的 batch
+func (this *IdMgr) prepareIdMaps(intIds []uint64, stringIds []string) ([]string, *KVBatch, error) {
+	if stringIds != nil && len(stringIds) != len(intIds) {
+		return nil, nil, newError(ErrInvalidArgument, fmt.Sprintf("AddIdMaps length not match: %v != %v", len(intIds), len(stringIds)))
+	}
+
+	result := make([]string, len(intIds))
+	byCodec := make([]bool, len(intIds))
+	used := make(map[string]bool)
+	added := make(map[uint64]bool)
+	count := this.count()
+	batch := &KVBatch{}
+
+	// 先检查指定的字符串ID，随机生成的字符串ID不能和它们重复
+	for i, intId := range intIds {
+		if added[intId] {
+			return nil, nil, &IdConflictError{IntId: intId, Msg: fmt.Sprintf("intId[%v] is duplicated", intId)}
+		}
+		added[intId] = true
+
+		if this.codec != nil {
+			encoded := this.codec.Encode(intId)
+			if stringIds == nil || stringIds[i] == "" || stringIds[i] == encoded {
+				result[i], byCodec[i] = encoded, true
+				if err := this.checkIdMap(intId, "", batch); err != nil {
+					return nil, nil, err
+				}
+				continue
+			}
+		}
+
+		if stringIds != nil && stringIds[i] != "" {
+			if err := this.checkWritable(); err != nil {
+				return nil, nil, err
+			}
+			result[i] = stringIds[i]
+			if used[result[i]] {
+				return nil, nil, &IdConflictError{IntId: intId, StringId: result[i], Msg: fmt.Sprintf("stringId[%v] is duplicated", result[i])}
+			}
+			used[result[i]] = true
+		}
+		if err := this.checkIdMap(intId, result[i], batch); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for i, intId := range intIds {
+		if byCodec[i] {
+			continue
+		}
+		if result[i] == "" {
+			// 随机生成的字符串ID在批处理中也不能重复
+			stringId, err := this.generateStringId(used)
+			if err != nil {
+				return nil, nil, err
+			}
+			result[i] = stringId
+			used[stringId] = true
+		}
+
+		batch.Put(this.getKeyFromString(result[i]), []byte(strconv.FormatUint(intId, 10)))
+		batch.Put(this.getKeyFromInt(intId), []byte(result[i]))
+		if !this.hasIntId(intId) {
+			count++
+		}
+	}
+	if batch.Len() > 0 {
+		this.putCount(batch, count)
+	}
+	return result, batch, nil
+}
+
+// 获取整型ID对应的字符串ID，设置了 codec 时没有保存映射的整型ID返回 codec 转换的字符串ID
 func (this *IdMgr) GetStringId(intId uint64) (string, bool) {
 	this.mutex.RLock()
 	defer this.mutex.RUnlock()
 
-	return this.getStringId(intId)
+	return this.resolveStringId(intId)
 }
 
+// 只查找保存的映射
 func (this *IdMgr) getStringId(intId uint64) (string, bool) {
+	if this.db == nil {
+		return "", false
+	}
 	if value, err := this.db.Get(this.getKeyFromInt(intId)); err == nil {
 		return string(value), true
 	}
 	return "", false
 }
 
-// 获取字符串ID对应的整型ID
+// 保存的映射优先，再用 codec 转换
+func (this *IdMgr) resolveStringId(intId uint64) (string, bool) {
+	if stringId, ok := this.getStringId(intId); ok {
+		return stringId, true
+	}
+	if this.codec != nil {
+		return this.codec.Encode(intId), true
+	}
+	return "", false
+}
+
+// 批量获取整型ID对应的字符串ID，不存在的整型ID不在结果中
+func (this *IdMgr) GetStringIds(intIds []uint64) map[uint64]string {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	stringIds := make(map[uint64]string)
+	for _, intId := range intIds {
+		if stringId, ok := this.resolveStringId(intId); ok {
+			stringIds[intId] = stringId
+		}
+	}
+	return stringIds
+}
+
+// 获取字符串ID对应的整型ID，设置了 codec 时没有保存映射的字符串ID用 codec 解码
 func (this *IdMgr) GetIntId(stringId string) (uint64, bool) {
 	this.mutex.RLock()
 	defer this.mutex.RUnlock()
 
+	return this.resolveIntId(stringId)
+}
+
+// 批量获取字符串ID对应的整型ID，不存在的字符串ID不在结果中
+func (this *IdMgr) GetIntIds(stringIds []string) map[string]uint64 {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	intIds := make(map[string]uint64)
+	for _, stringId := range stringIds {
+		if intId, ok := this.resolveIntId(stringId); ok {
+			intIds[stringId] = intId
+		}
+	}
+	return intIds
+}
+
+// 只查找保存的映射
+func (this *IdMgr) getIntId(stringId string) (uint64, bool) {
+	if this.db == nil {
+		return 0, false
+	}
 	if value, err := this.db.Get(this.getKeyFromString(stringId)); err == nil {
 		if intId, err := strconv.ParseUint(string(value), 10, 64); err == nil {
 			return intId, true
@@ -88,29 +445,374 @@ func (this *IdMgr) GetIntId(stringId string) (uint64, bool) {
 	return 0, false
 }
 
-// 保存intId和stringId的映射
-func (this *IdMgr) SetIdMap(intId uint64, stringId string) error {
+// 保存的映射优先，再用 codec 解码
+func (this *IdMgr) resolveIntId(stringId string) (uint64, bool) {
+	if intId, ok := this.getIntId(stringId); ok {
+		return intId, true
+	}
+	if this.codec != nil {
+		// 还在重定向的旧字符串ID不用 codec 解码，详见 MigrateToCodec
+		if _, ok := this.getRedirect(stringId); ok {
+			return 0, false
+		}
+		return this.codec.Decode(stringId)
+	}
+	return 0, false
+}
+
+// 设置或者修改整型ID对应的字符串ID，旧的字符串ID直接删除，不留墓碑和重定向，修改文章的自定义ID应该使用 Rebind
+// 字符串ID已经对应其他整型ID时返回 *IdConflictError，映射一次写入，要么全部成功，要么全部失败
+func (this *IdMgr) SetStringId(intId uint64, stringId string) error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if stringId == "" {
+		return newError(ErrInvalidArgument, "stringId must not empty")
+	}
+	if err := this.checkWritable(); err != nil {
+		return err
+	}
+	if oldIntId, ok := this.getIntId(stringId); ok {
+		if oldIntId == intId {
+			return nil
+		}
+		return &IdConflictError{IntId: intId, StringId: stringId, Msg: fmt.Sprintf("stringId[%v] is bound to intId[%v]", stringId, oldIntId)}
+	}
+
+	batch := &KVBatch{}
+	if err := this.checkNewStringId(intId, stringId, batch); err != nil {
+		return err
+	}
+	count := this.count()
+	if oldStringId, ok := this.getStringId(intId); ok {
+		batch.Delete(this.getKeyFromString(oldStringId))
+	} else {
+		count++
+	}
+	batch.Put(this.getKeyFromString(stringId), []byte(strconv.FormatUint(intId, 10)))
+	batch.Put(this.getKeyFromInt(intId), []byte(stringId))
+	this.putCount(batch, count)
+	return this.db.Write(batch)
+}
+
+// 修改整型ID对应的字符串ID，一次写入，整型ID没有映射时返回 ErrNotFound，新的字符串ID已经被使用时返回 *IdConflictError
+// 旧的字符串ID在一段时间内重定向到新的（详见 SetRedirectTTL），方便网站返回301，返回的重定向为nil表示没有保留
+// 重定向指向整型ID，所以多次修改之后，所有旧的字符串ID都重定向到最新的
+func (this *IdMgr) Rebind(intId uint64, newStringId string) (*IdRedirect, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if newStringId == "" {
+		return nil, newError(ErrInvalidArgument, "newStringId must not empty")
+	}
+	if err := this.checkWritable(); err != nil {
+		return nil, err
+	}
+	oldStringId, ok := this.getStringId(intId)
+	if !ok {
+		return nil, newError(ErrNotFound, fmt.Sprintf("intId[%v] not found", intId))
+	}
+	if oldStringId == newStringId {
+		return nil, nil
+	}
+	if oldIntId, ok := this.getIntId(newStringId); ok {
+		return nil, &IdConflictError{IntId: intId, StringId: newStringId, Msg: fmt.Sprintf("stringId[%v] is bound to intId[%v]", newStringId, oldIntId)}
+	}
+
+	batch := &KVBatch{}
+	if err := this.checkNewStringId(intId, newStringId, batch); err != nil {
+		return nil, err
+	}
+	batch.Delete(this.getKeyFromString(oldStringId))
+	batch.Put(this.getKeyFromString(newStringId), []byte(strconv.FormatUint(intId, 10)))
+	batch.Put(this.getKeyFromInt(intId), []byte(newStringId))
+
+	var redirect *IdRedirect
+	ttl := this.redirectTTL
+	if ttl == 0 {
+		ttl = DefaultIdRedirectTTL
+	}
+	if ttl > 0 {
+		redirect = &IdRedirect{
+			From:     oldStringId,
+			To:       newStringId,
+			IntId:    intId,
+			ExpireAt: time.Now().Add(ttl),
+		}
+		this.putRedirectBatch(batch, oldStringId, intId, redirect.ExpireAt.UnixNano())
+	}
+
+	if err := this.db.Write(batch); err != nil {
+		return nil, err
+	}
+	return redirect, nil
+}
+
+// 返回旧的字符串ID的重定向，已经过期或者整型ID已经删除时返回false
+func (this *IdMgr) GetRedirect(stringId string) (*IdRedirect, bool) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	return this.getRedirect(stringId)
+}
+
+func (this *IdMgr) getRedirect(stringId string) (*IdRedirect, bool) {
+	if this.db == nil {
+		return nil, false
+	}
+	value, err := this.db.Get(this.getKeyFromRedirect(stringId))
+	if err != nil {
+		return nil, false
+	}
+
+	// 格式：intId_过期时间（UnixNano）
+	intIdStr, expireStr, _ := strings.Cut(string(value), "_")
+	intId, err1 := strconv.ParseUint(intIdStr, 10, 64)
+	expire, err2 := strconv.ParseInt(expireStr, 10, 64)
+	if err1 != nil || err2 != nil || time.Now().UnixNano() > expire {
+		return nil, false
+	}
+
+	to, ok := this.resolveStringId(intId)
+	if !ok {
+		return nil, false
+	}
+	return &IdRedirect{From: stringId, To: to, IntId: intId, ExpireAt: time.Unix(0, expire)}, true
+}
+
+// 检查 intId 和 stringId 能否建立映射，已经是同样的映射也可以，stringId 为空表示只检查 intId
+func (this *IdMgr) checkIdMap(intId uint64, stringId string, batch *KVBatch) error {
+	if oldStringId, ok := this.getStringId(intId); ok && oldStringId != stringId {
+		return &IdConflictError{IntId: intId, StringId: stringId, BoundStringId: oldStringId, Msg: fmt.Sprintf("intId[%v] is bound to stringId[%v]", intId, oldStringId)}
+	}
+	if stringId == "" {
+		return nil
+	}
+	if oldIntId, ok := this.getIntId(stringId); ok {
+		if oldIntId != intId {
+			return &IdConflictError{IntId: intId, StringId: stringId, Msg: fmt.Sprintf("stringId[%v] is bound to intId[%v]", stringId, oldIntId)}
+		}
+		return nil
+	}
+	return this.checkNewStringId(intId, stringId, batch)
+}
+
+// 检查 stringId 能否作为 intId 新的字符串ID（调用之前已经检查过 str_ 映射）
+// 墓碑、其他整型ID的重定向、codec 能解码成其他整型ID的都不能使用，过期的或者指向 intId 自己的重定向在 batch 中删除
+func (this *IdMgr) checkNewStringId(intId uint64, stringId string, batch *KVBatch) error {
+	if this.codec != nil {
+		if codecIntId, ok := this.codec.Decode(stringId); ok && codecIntId != intId {
+			return &IdConflictError{IntId: intId, StringId: stringId, Msg: fmt.Sprintf("stringId[%v] is the codec id of intId[%v]", stringId, codecIntId)}
+		}
+	}
+	if this.isTombstone(stringId) {
+		return &IdConflictError{IntId: intId, StringId: stringId, Msg: fmt.Sprintf("stringId[%v] is deleted and can not be reused", stringId)}
+	}
+	if redirect, ok := this.getRedirect(stringId); ok && redirect.IntId != intId {
+		return &IdConflictError{IntId: intId, StringId: stringId, Msg: fmt.Sprintf("stringId[%v] is redirected to stringId[%v]", stringId, redirect.To)}
+	}
+	this.deleteRedirectBatch(batch, stringId)
+	return nil
+}
+
+// 在 batch 中写入 stringId 重定向到 intId，同时写入反向索引，stringId 原来的重定向一起替换
+func (this *IdMgr) putRedirectBatch(batch *KVBatch, stringId string, intId uint64, expire int64) {
+	this.deleteRedirectBatch(batch, stringId)
+	value := strconv.FormatUint(intId, 10) + "_" + strconv.FormatInt(expire, 10)
+	batch.Put(this.getKeyFromRedirect(stringId), []byte(value))
+	batch.Put(this.getKeyFromRedirectIndex(intId, stringId), []byte{})
+}
+
+// 在 batch 中删除 stringId 的重定向和反向索引，没有重定向时什么也不做
+func (this *IdMgr) deleteRedirectBatch(batch *KVBatch, stringId string) {
+	key := this.getKeyFromRedirect(stringId)
+	value, err := this.db.Get(key)
+	if err != nil {
+		return
+	}
+	batch.Delete(key)
+	intIdStr, _, _ := strings.Cut(string(value), "_")
+	if intId, err := strconv.ParseUint(intIdStr, 10, 64); err == nil {
+		batch.Delete(this.getKeyFromRedirectIndex(intId, stringId))
+	}
+}
+
+// 删除整型ID的映射和重定向到它的旧字符串ID，keepTombstone 为true时都保留墓碑，字符串ID不能再次使用，避免旧的链接指向新的文章
+// 整型ID不存在时返回 ErrNotFound
+func (this *IdMgr) DeleteByIntId(intId uint64, keepTombstone bool) error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	stringId, ok := this.getStringId(intId)
+	if !ok {
+		return newError(ErrNotFound, fmt.Sprintf("intId[%v] not found", intId))
+	}
+	return this.deleteIdMap(intId, stringId, keepTombstone)
+}
+
+// 删除字符串ID的映射，详见 DeleteByIntId
+func (this *IdMgr) DeleteByStringId(stringId string, keepTombstone bool) error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	intId, ok := this.getIntId(stringId)
+	if !ok {
+		return newError(ErrNotFound, fmt.Sprintf("stringId[%v] not found", stringId))
+	}
+	return this.deleteIdMap(intId, stringId, keepTombstone)
+}
+
+func (this *IdMgr) deleteIdMap(intId uint64, stringId string, keepTombstone bool) error {
+	batch := &KVBatch{}
+	batch.Delete(this.getKeyFromString(stringId))
+	batch.Delete(this.getKeyFromInt(intId))
+	if keepTombstone {
+		// 墓碑中保存原来的整型ID，方便排查
+		batch.Put(this.getKeyFromTombstone(stringId), []byte(strconv.FormatUint(intId, 10)))
+	}
+
+	// 重定向到这个整型ID的旧字符串ID也一起删除，保留墓碑时旧字符串ID同样不能再次使用
+	// 只遍历这个整型ID的反向索引，不用遍历所有的重定向
+	intIdStr := strconv.FormatUint(intId, 10)
+	prefix := this.getKeyFromRedirectIndex(intId, "")
+	this.db.Scan(prefix, func(key, value []byte) bool {
+		batch.Delete(key)
+		oldStringId := strings.TrimPrefix(string(key), string(prefix))
+		redirectKey := this.getKeyFromRedirect(oldStringId)
+		if redirectValue, err := this.db.Get(redirectKey); err == nil {
+			if redirectIntId, _, _ := strings.Cut(string(redirectValue), "_"); redirectIntId == intIdStr {
+				batch.Delete(redirectKey)
+				if keepTombstone {
+					batch.Put(this.getKeyFromTombstone(oldStringId), []byte(intIdStr))
+				}
+			}
+		}
+		return true
+	})
+	if count := this.count(); count > 0 {
+		this.putCount(batch, count-1)
+	}
+	return this.db.Write(batch)
+}
+
+// 把保存的映射迁移到 codec，需要先 Open 并且 SetCodec，返回迁移的映射数量
+// 删除所有的映射，旧的字符串ID重定向到 codec 转换的字符串ID（重定向时间详见 SetRedirectTTL，小于0时不保留）
+// 旧的字符串ID和 codec 转换的一样时直接删除；自定义的字符串ID也会迁移，需要保留的话就不要迁移，直接 SetCodec 即可
+// 迁移之后重定向全部过期时，就不再需要数据库，可以改用 OpenStateless；一次写入 idMigrateBatchSize 个映射，中途失败可以重新执行
+func (this *IdMgr) MigrateToCodec() (int, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if err := this.checkWritable(); err != nil {
+		return 0, err
+	}
+	if this.codec == nil {
+		return 0, newError(ErrInvalidArgument, "IdMgr MigrateToCodec needs SetCodec first")
+	}
+
+	// 遍历的时候不修改数据库，先读出来
+	stringIds := make([]string, 0)
+	intIds := make([]uint64, 0)
+	this.db.Scan([]byte(idPrefixString), func(key, value []byte) bool {
+		if intId, err := strconv.ParseUint(string(value), 10, 64); err == nil {
+			stringIds = append(stringIds, strings.TrimPrefix(string(key), idPrefixString))
+			intIds = append(intIds, intId)
+		}
+		return true
+	})
+
+	ttl := this.redirectTTL
+	if ttl == 0 {
+		ttl = DefaultIdRedirectTTL
+	}
+	expire := time.Now().Add(ttl).UnixNano()
+
+	count := this.count()
+	migrated := 0
+	for start := 0; start < len(stringIds); start += idMigrateBatchSize {
+		end := min(start+idMigrateBatchSize, len(stringIds))
+		batch := &KVBatch{}
+		for i := start; i < end; i++ {
+			batch.Delete(this.getKeyFromString(stringIds[i]))
+			batch.Delete(this.getKeyFromInt(intIds[i]))
+			if ttl > 0 && stringIds[i] != this.codec.Encode(intIds[i]) {
+				this.putRedirectBatch(batch, stringIds[i], intIds[i], expire)
+			}
+			if count > 0 {
+				count--
+			}
+		}
+		this.putCount(batch, count)
+		if err := this.db.Write(batch); err != nil {
+			return migrated, err
+		}
+		migrated = end
+	}
+	return migrated, nil
+}
+
+// 字符串ID是否已经被使用，包括已经删除但是保留了墓碑的、还在重定向的、codec 能解码的，增加映射之前用来判断是否重复
+func (this *IdMgr) HasStringId(stringId string) bool {
 	this.mutex.RLock()
 	defer this.mutex.RUnlock()
 
-	return this.setIdMap(intId, stringId)
+	return this.hasStringId(stringId)
 }
 
+// 字符串ID是否已经删除并且保留了墓碑
+func (this *IdMgr) IsTombstone(stringId string) bool {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	return this.isTombstone(stringId)
+}
+
+func (this *IdMgr) isTombstone(stringId string) bool {
+	if this.db == nil {
+		return false
+	}
+	return this.db.Has(this.getKeyFromTombstone(stringId))
+}
+
+// 保存intId和stringId的映射，比较并设置：两个ID都没有其他映射（包括墓碑和重定向）时才写入，两个方向一次写入
+// 已经是同样的映射时直接返回nil，冲突时返回 *IdConflictError，可以用 errors.Is(err, ErrExists) 判断
+// 多个请求同时使用同一个字符串ID时只有一个成功，不需要事先检查
+func (this *IdMgr) SetIdMap(intId uint64, stringId string) error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if stringId == "" {
+		return newError(ErrInvalidArgument, "stringId must not empty")
+	}
+	if err := this.checkWritable(); err != nil {
+		return err
+	}
+	batch := &KVBatch{}
+	if err := this.checkIdMap(intId, stringId, batch); err != nil {
+		return err
+	}
+	return this.setIdMapBatch(batch, intId, stringId)
+}
+
+// 不检查冲突，调用者需要保证ID没有被使用
 func (this *IdMgr) setIdMap(intId uint64, stringId string) error {
+	return this.setIdMapBatch(&KVBatch{}, intId, stringId)
+}
+
+func (this *IdMgr) setIdMapBatch(batch *KVBatch, intId uint64, stringId string) error {
 	// str_stringId --> intId
-	err1 := this.db.Put(this.getKeyFromString(stringId), []byte(strconv.FormatUint(intId, 10)))
+	batch.Put(this.getKeyFromString(stringId), []byte(strconv.FormatUint(intId, 10)))
 
 	// int_intId --> stringId
-	err2 := this.db.Put(this.getKeyFromInt(intId), []byte(stringId))
-
-	if err1 != nil || err2 != nil {
-		// 如果有一个失败就全部删掉
-		this.db.Delete(this.getKeyFromString(stringId))
-		this.db.Delete(this.getKeyFromInt(intId))
+	batch.Put(this.getKeyFromInt(intId), []byte(stringId))
 
-		return errors.New(fmt.Sprintf("AddIntId[%v] failed, err1[%v] err2[%v]", intId, err1, err2))
+	if !this.hasIntId(intId) {
+		this.putCount(batch, this.count()+1)
 	}
 
+	if err := this.db.Write(batch); err != nil {
+		return errors.New(fmt.Sprintf("AddIntId[%v] failed, err[%v]", intId, err))
+	}
 	return nil
 }
 
@@ -122,21 +824,53 @@ func (this *IdMgr) getKeyFromString(stringId string) []byte {
 	return []byte(idPrefixString + stringId)
 }
 
+func (this *IdMgr) getKeyFromTombstone(stringId string) []byte {
+	return []byte(idPrefixTombstone + stringId)
+}
+
+func (this *IdMgr) getKeyFromRedirect(stringId string) []byte {
+	return []byte(idPrefixRedirect + stringId)
+}
+
+func (this *IdMgr) getKeyFromRedirectIndex(intId uint64, stringId string) []byte {
+	return []byte(idPrefixRedirectIndex + strconv.FormatUint(intId, 10) + "_" + stringId)
+}
+
 func (this *IdMgr) hasIntId(intId uint64) bool {
+	if this.db == nil {
+		return false
+	}
 	return this.db.Has(this.getKeyFromInt(intId))
 }
 
 func (this *IdMgr) hasStringId(stringId string) bool {
-	return this.db.Has(this.getKeyFromString(stringId))
+	if this.codec != nil {
+		if _, ok := this.codec.Decode(stringId); ok {
+			return true
+		}
+	}
+	if this.db == nil {
+		return false
+	}
+	if this.db.Has(this.getKeyFromString(stringId)) || this.isTombstone(stringId) {
+		return true
+	}
+	_, ok := this.getRedirect(stringId)
+	return ok
 }
 
-// 产生一个字符串ID
-func (this *IdMgr) generateStringId() string {
-	for {
-		stringId := string(RandomBytes(8, 8))
-		if !this.hasStringId(stringId) {
-			return stringId
+// 产生一个没有被使用的字符串ID，used 中的字符串ID也算已经使用，可以为nil
+// 冲突次数超过 MaxRetries 时返回 ErrIdExhausted，一般说明ID太短，已经快用完了
+func (this *IdMgr) generateStringId(used map[string]bool) (string, error) {
+	config := this.idGen.withDefaults()
+	for i := 0; i <= config.MaxRetries; i++ {
+		stringId, err := randomString(config.Alphabet, config.Length)
+		if err != nil {
+			return "", err
+		}
+		if !used[stringId] && !this.hasStringId(stringId) {
+			return stringId, nil
 		}
 	}
-	return ""
+	return "", newError(ErrIdExhausted, fmt.Sprintf("generate stringId failed after %v retries, increase Length or Alphabet", config.MaxRetries))
 }
@@ -1,8 +1,12 @@
 package gmodel
 
 import (
+	"errors"
 	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestIdMgr(t *testing.T) {
@@ -41,4 +45,571 @@ func TestIdMgr(t *testing.T) {
 		}
 	}
 
+	stringIds := mgr.GetStringIds([]uint64{1, 999, 1000})
+	if len(stringIds) != 2 || stringIds[1] == "" || stringIds[999] == "" {
+		t.Fatal()
+	}
+	intIds := mgr.GetIntIds([]string{stringIds[999], "none", stringIds[1]})
+	if len(intIds) != 2 || intIds[stringIds[1]] != 1 || intIds[stringIds[999]] != 999 {
+		t.Fatal()
+	}
+}
+
+func TestAddIdMaps(t *testing.T) {
+	dbPath := "test.db"
+	defer os.RemoveAll(dbPath)
+
+	mgr := &IdMgr{}
+	err := mgr.Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mgr.Close()
+
+	stringIds, err := mgr.AddIdMaps([]uint64{1, 2, 3}, []string{"a", "", "c"})
+	if err != nil || len(stringIds) != 3 || stringIds[0] != "a" || stringIds[1] == "" || stringIds[2] != "c" {
+		t.Fatal(err)
+	}
+	if intId, ok := mgr.GetIntId(stringIds[1]); !ok || intId != 2 {
+		t.Fatal()
+	}
+	if stringId, ok := mgr.GetStringId(3); !ok || stringId != "c" {
+		t.Fatal()
+	}
+
+	stringIds, err = mgr.AddIdMaps([]uint64{4, 5}, nil)
+	if err != nil || len(stringIds) != 2 || stringIds[0] == stringIds[1] || mgr.Count() != 5 {
+		t.Fatal(err)
+	}
+	if _, err = mgr.AddIdMaps([]uint64{6}, []string{"f", "g"}); err == nil {
+		t.Fatal()
+	}
+}
+
+func TestSetStringId(t *testing.T) {
+	dbPath := "test.db"
+	defer os.RemoveAll(dbPath)
+
+	mgr := &IdMgr{}
+	err := mgr.Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mgr.Close()
+
+	if err = mgr.SetIdMap(1, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err = mgr.SetIdMap(2, "b"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 修改字符串ID，旧的字符串ID不再有效
+	if err = mgr.SetStringId(1, "c"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mgr.GetIntId("a"); ok {
+		t.Fatal()
+	}
+	if intId, ok := mgr.GetIntId("c"); !ok || intId != 1 {
+		t.Fatal()
+	}
+	if stringId, ok := mgr.GetStringId(1); !ok || stringId != "c" {
+		t.Fatal()
+	}
+	if mgr.Count() != 2 {
+		t.Fatal()
+	}
+
+	// 新的整型ID
+	if err = mgr.SetStringId(3, "d"); err != nil || mgr.Count() != 3 {
+		t.Fatal(err)
+	}
+
+	// 重复设置相同的映射没有影响，字符串ID已经被使用时失败
+	if err = mgr.SetStringId(1, "c"); err != nil {
+		t.Fatal(err)
+	}
+	if err = mgr.SetStringId(1, "b"); !errors.Is(err, ErrExists) {
+		t.Fatal(err)
+	}
+	if err = mgr.SetStringId(1, ""); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatal(err)
+	}
+}
+
+func TestDeleteIdMap(t *testing.T) {
+	dbPath := "test.db"
+	defer os.RemoveAll(dbPath)
+
+	mgr := &IdMgr{}
+	err := mgr.Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = mgr.AddIdMaps([]uint64{1, 2, 3}, []string{"a", "b", "c"}); err != nil || mgr.Count() != 3 {
+		t.Fatal(err)
+	}
+
+	// 直接删除，字符串ID可以再次使用
+	if err = mgr.DeleteByIntId(1, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mgr.GetIntId("a"); ok || mgr.HasStringId("a") || mgr.Count() != 2 {
+		t.Fatal()
+	}
+	if err = mgr.SetStringId(4, "a"); err != nil || mgr.Count() != 3 {
+		t.Fatal(err)
+	}
+
+	// 保留墓碑，字符串ID不能再次使用
+	if err = mgr.DeleteByStringId("b", true); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mgr.GetIntId("b"); ok || !mgr.HasStringId("b") || !mgr.IsTombstone("b") || mgr.Count() != 2 {
+		t.Fatal()
+	}
+	if _, ok := mgr.GetStringId(2); ok {
+		t.Fatal()
+	}
+	if err = mgr.SetStringId(5, "b"); !errors.Is(err, ErrExists) {
+		t.Fatal(err)
+	}
+
+	if err = mgr.DeleteByIntId(2, false); !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+	if err = mgr.DeleteByStringId("none", false); !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+
+	// 重新打开之后数量不变
+	mgr.Close()
+	if err = mgr.Open(dbPath); err != nil {
+		t.Fatal(err)
+	}
+	defer mgr.Close()
+	if mgr.Count() != 2 || !mgr.IsTombstone("b") {
+		t.Fatal(mgr.Count())
+	}
+}
+
+func TestRebind(t *testing.T) {
+	dbPath := "test.db"
+	defer os.RemoveAll(dbPath)
+
+	mgr := &IdMgr{}
+	err := mgr.Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mgr.Close()
+
+	mgr.AddIdMaps([]uint64{1, 2}, []string{"a", "b"})
+
+	// 旧的字符串ID重定向到新的，多次修改之后都重定向到最新的
+	redirect, err := mgr.Rebind(1, "a2")
+	if err != nil || redirect == nil || redirect.From != "a" || redirect.To != "a2" || redirect.IntId != 1 {
+		t.Fatal(err, redirect)
+	}
+	if _, err = mgr.Rebind(1, "a3"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mgr.GetIntId("a"); ok {
+		t.Fatal()
+	}
+	for _, stringId := range []string{"a", "a2"} {
+		if redirect, ok := mgr.GetRedirect(stringId); !ok || redirect.To != "a3" || redirect.IntId != 1 {
+			t.Fatal(stringId, redirect)
+		}
+	}
+	if stringId, ok := mgr.GetStringId(1); !ok || stringId != "a3" || mgr.Count() != 2 {
+		t.Fatal()
+	}
+
+	// 重定向中的字符串ID不能被其他整型ID使用
+	if !mgr.HasStringId("a") {
+		t.Fatal()
+	}
+	if _, err = mgr.Rebind(2, "a"); !errors.Is(err, ErrExists) {
+		t.Fatal(err)
+	}
+	if err = mgr.SetStringId(3, "a2"); !errors.Is(err, ErrExists) {
+		t.Fatal(err)
+	}
+	if _, err = mgr.Rebind(2, "a3"); !errors.Is(err, ErrExists) {
+		t.Fatal(err)
+	}
+	if _, err = mgr.Rebind(3, "c"); !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+	if redirect, err = mgr.Rebind(2, "b"); err != nil || redirect != nil {
+		t.Fatal(err, redirect)
+	}
+
+	// 过期之后可以被其他整型ID使用，不保留重定向
+	mgr.SetRedirectTTL(time.Nanosecond)
+	if _, err = mgr.Rebind(2, "b2"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+	if _, ok := mgr.GetRedirect("b"); ok {
+		t.Fatal()
+	}
+	mgr.SetRedirectTTL(-1)
+	if redirect, err = mgr.Rebind(2, "b"); err != nil || redirect != nil {
+		t.Fatal(err, redirect)
+	}
+	if _, ok := mgr.GetRedirect("b2"); ok || mgr.HasStringId("b2") {
+		t.Fatal()
+	}
+}
+
+func TestDeleteRedirects(t *testing.T) {
+	dbPath := "test.db"
+	defer os.RemoveAll(dbPath)
+
+	mgr := &IdMgr{}
+	err := mgr.Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mgr.AddIdMaps([]uint64{1, 2, 12}, []string{"a", "b", "c"})
+	mgr.Rebind(1, "a2")
+	mgr.Rebind(1, "a3")
+	mgr.Rebind(12, "c2")
+
+	// 旧的重定向被其他整型ID重新使用之后，删除原来的整型ID不影响它
+	mgr.SetRedirectTTL(time.Nanosecond)
+	mgr.Rebind(2, "b2")
+	time.Sleep(time.Millisecond)
+	if _, err = mgr.Rebind(12, "b"); err != nil {
+		t.Fatal(err)
+	}
+	mgr.SetRedirectTTL(0)
+
+	// 只删除重定向到这个整型ID的旧字符串ID，保留墓碑
+	if err = mgr.DeleteByIntId(1, true); err != nil {
+		t.Fatal(err)
+	}
+	for _, stringId := range []string{"a", "a2", "a3"} {
+		if _, ok := mgr.GetRedirect(stringId); ok || !mgr.IsTombstone(stringId) {
+			t.Fatal(stringId)
+		}
+	}
+	if redirect, ok := mgr.GetRedirect("c"); !ok || redirect.To != "b" || redirect.IntId != 12 {
+		t.Fatal(redirect)
+	}
+	mgr.db.Scan([]byte(idPrefixRedirectIndex+"1_"), func(key, value []byte) bool {
+		t.Fatal(string(key))
+		return true
+	})
+
+	if err = mgr.DeleteByIntId(2, false); err != nil {
+		t.Fatal(err)
+	}
+	if err = mgr.DeleteByIntId(12, false); err != nil {
+		t.Fatal(err)
+	}
+	if mgr.HasStringId("c") || mgr.HasStringId("c2") || mgr.IsTombstone("c") {
+		t.Fatal()
+	}
+	mgr.db.Scan([]byte(idPrefixRedirectIndex), func(key, value []byte) bool {
+		t.Fatal(string(key))
+		return true
+	})
+
+	// 旧的数据库没有反向索引，打开时补上
+	mgr.AddIdMaps([]uint64{3}, []string{"d"})
+	mgr.Rebind(3, "d2")
+	batch := &KVBatch{}
+	batch.Delete(mgr.getKeyFromRedirectIndex(3, "d"))
+	batch.Delete(idKeyRedirectIndex)
+	if err = mgr.db.Write(batch); err != nil {
+		t.Fatal(err)
+	}
+	mgr.Close()
+	if err = mgr.Open(dbPath); err != nil {
+		t.Fatal(err)
+	}
+	defer mgr.Close()
+	if !mgr.db.Has(mgr.getKeyFromRedirectIndex(3, "d")) {
+		t.Fatal()
+	}
+	if err = mgr.DeleteByIntId(3, false); err != nil {
+		t.Fatal(err)
+	}
+	if mgr.HasStringId("d") || mgr.Count() != 0 {
+		t.Fatal()
+	}
+}
+
+func TestSetIdMapConflict(t *testing.T) {
+	dbPath := "test.db"
+	defer os.RemoveAll(dbPath)
+
+	mgr := &IdMgr{}
+	err := mgr.Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mgr.Close()
+
+	// 同样的映射可以重复设置，两个ID中有一个已经有其他映射时冲突
+	if err = mgr.SetIdMap(1, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err = mgr.SetIdMap(1, "a"); err != nil {
+		t.Fatal(err)
+	}
+	var conflict *IdConflictError
+	if err = mgr.SetIdMap(2, "a"); !errors.As(err, &conflict) || !errors.Is(err, ErrExists) || conflict.IntId != 2 || conflict.StringId != "a" {
+		t.Fatal(err)
+	}
+	if err = mgr.SetIdMap(1, "b"); !errors.Is(err, ErrExists) {
+		t.Fatal(err)
+	}
+	if err = mgr.SetIdMap(2, ""); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatal(err)
+	}
+	if _, ok := mgr.GetIntId("b"); ok || mgr.Count() != 1 {
+		t.Fatal()
+	}
+
+	// 批量中有一项冲突时全部不写入
+	for _, c := range []struct {
+		intIds    []uint64
+		stringIds []string
+	}{
+		{[]uint64{3, 4}, []string{"c", "a"}},
+		{[]uint64{3, 1}, nil},
+		{[]uint64{3, 4}, []string{"c", "c"}},
+		{[]uint64{3, 3}, nil},
+	} {
+		if _, err = mgr.AddIdMaps(c.intIds, c.stringIds); !errors.As(err, &conflict) {
+			t.Fatal(c, err)
+		}
+	}
+	if _, ok := mgr.GetIntId("c"); ok || mgr.Count() != 1 {
+		t.Fatal()
+	}
+
+	// 并发设置同一个字符串ID，只有一个成功
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	success := 0
+	for i := 10; i < 20; i++ {
+		wg.Add(1)
+		go func(intId uint64) {
+			defer wg.Done()
+			if mgr.SetIdMap(intId, "same") == nil {
+				mutex.Lock()
+				success++
+				mutex.Unlock()
+			}
+		}(uint64(i))
+	}
+	wg.Wait()
+	if success != 1 || mgr.Count() != 2 {
+		t.Fatal(success, mgr.Count())
+	}
+}
+
+func TestIdGenConfig(t *testing.T) {
+	dbPath := "test.db"
+	defer os.RemoveAll(dbPath)
+
+	mgr := &IdMgr{}
+	if err := mgr.Open(dbPath); err != nil {
+		t.Fatal(err)
+	}
+	defer mgr.Close()
+
+	for _, config := range []IdGenConfig{
+		{Length: 3},
+		{Length: 65},
+		{Alphabet: "a"},
+		{Alphabet: "abca"},
+		{Alphabet: "ab c"},
+		{Alphabet: "ab中"},
+		{MaxRetries: -1},
+	} {
+		if err := mgr.SetIdGenConfig(config); !errors.Is(err, ErrInvalidArgument) {
+			t.Fatal(config, err)
+		}
+	}
+
+	// 按设置的长度和字符集生成
+	if err := mgr.SetIdGenConfig(IdGenConfig{Length: 12, Alphabet: "0123456789"}); err != nil {
+		t.Fatal(err)
+	}
+	stringId, err := mgr.AddIntId(1)
+	if err != nil || len(stringId) != 12 || strings.Trim(stringId, "0123456789") != "" {
+		t.Fatal(err, stringId)
+	}
+
+	// 只有 2^4=16 个字符串ID，用完之后返回 ErrIdExhausted，批量增加时不写入
+	if err = mgr.SetIdGenConfig(IdGenConfig{Length: 4, Alphabet: "ab", MaxRetries: 100}); err != nil {
+		t.Fatal(err)
+	}
+	var intId uint64 = 2
+	for ; intId < 100; intId++ {
+		if _, err = mgr.AddIntId(intId); err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, ErrIdExhausted) || mgr.Count() > 17 {
+		t.Fatal(err, mgr.Count())
+	}
+	count := mgr.Count()
+	if _, err = mgr.AddIdMaps([]uint64{200, 201}, nil); !errors.Is(err, ErrIdExhausted) || mgr.Count() != count {
+		t.Fatal(err, mgr.Count())
+	}
+
+	// 默认规则
+	if err = mgr.SetIdGenConfig(IdGenConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	stringId, err = mgr.AddIntId(300)
+	if err != nil || len(stringId) != DefaultIdLength || strings.Trim(stringId, DefaultIdAlphabet) != "" {
+		t.Fatal(err, stringId)
+	}
+}
+
+func TestIdMgrCodec(t *testing.T) {
+	dbPath := "test.db"
+	defer os.RemoveAll(dbPath)
+
+	codec, err := NewIdCodec([]byte("0123456789abcdef"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 不使用数据库，全部用 codec 转换，不能保存自定义的字符串ID
+	stateless := &IdMgr{}
+	if err = stateless.OpenStateless(codec); err != nil {
+		t.Fatal(err)
+	}
+	stringId, err := stateless.AddIntId(1)
+	if err != nil || stringId != codec.Encode(1) || stateless.Count() != 0 {
+		t.Fatal(err, stringId)
+	}
+	if intId, ok := stateless.GetIntId(stringId); !ok || intId != 1 {
+		t.Fatal(intId)
+	}
+	if s, ok := stateless.GetStringId(2); !ok || s != codec.Encode(2) || !stateless.HasStringId(s) {
+		t.Fatal(s)
+	}
+	if stringIds, err := stateless.AddIdMaps([]uint64{3, 4}, nil); err != nil || stringIds[1] != codec.Encode(4) {
+		t.Fatal(err, stringIds)
+	}
+	if err = stateless.SetIdMap(5, "custom"); !errors.Is(err, ErrReadOnly) {
+		t.Fatal(err)
+	}
+	if _, err = stateless.AddIdMaps([]uint64{5}, []string{"custom"}); !errors.Is(err, ErrReadOnly) {
+		t.Fatal(err)
+	}
+	if err = stateless.DeleteByIntId(1, true); !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+	if err = stateless.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 已有的映射优先，新的整型ID用 codec 转换，不保存
+	mgr := &IdMgr{}
+	if err = mgr.Open(dbPath); err != nil {
+		t.Fatal(err)
+	}
+	defer mgr.Close()
+	oldStringId, _ := mgr.AddIntId(1)
+	mgr.SetCodec(codec)
+
+	if stringId, _ = mgr.AddIntId(1); stringId != oldStringId {
+		t.Fatal(stringId)
+	}
+	if stringId, _ = mgr.AddIntId(2); stringId != codec.Encode(2) || mgr.Count() != 1 {
+		t.Fatal(stringId, mgr.Count())
+	}
+	stringIds, err := mgr.AddIdMaps([]uint64{3, 4}, []string{"", "custom"})
+	if err != nil || stringIds[0] != codec.Encode(3) || stringIds[1] != "custom" || mgr.Count() != 2 {
+		t.Fatal(err, stringIds, mgr.Count())
+	}
+	if intId, ok := mgr.GetIntId("custom"); !ok || intId != 4 {
+		t.Fatal(intId)
+	}
+	if s, _ := mgr.GetStringIds([]uint64{1, 2})[2]; s != codec.Encode(2) {
+		t.Fatal(s)
+	}
+
+	// codec 转换的字符串ID不能作为其他整型ID的自定义ID
+	if err = mgr.SetIdMap(5, codec.Encode(2)); !errors.Is(err, ErrExists) {
+		t.Fatal(err)
+	}
+
+	// 只是长度和字符集一样的字符串ID通不过校验，不算 codec 的字符串ID，可以作为自定义ID
+	plain := "dictionaries" + codec.Encode(2)[12:]
+	if _, ok := mgr.GetIntId(plain); ok || mgr.HasStringId(plain) {
+		t.Fatal(plain)
+	}
+	if err = mgr.SetIdMap(6, plain); err != nil {
+		t.Fatal(err)
+	}
+	if err = mgr.SetIdMap(2, codec.Encode(2)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrateToCodec(t *testing.T) {
+	dbPath := "test.db"
+	defer os.RemoveAll(dbPath)
+
+	mgr := &IdMgr{}
+	if err := mgr.Open(dbPath); err != nil {
+		t.Fatal(err)
+	}
+	defer mgr.Close()
+
+	oldStringIds := make(map[uint64]string)
+	for i := uint64(1); i <= idMigrateBatchSize+10; i++ {
+		oldStringIds[i], _ = mgr.AddIntId(i)
+	}
+	if _, err := mgr.MigrateToCodec(); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatal(err)
+	}
+
+	codec, _ := NewIdCodec([]byte("0123456789abcdef"), "")
+	mgr.SetCodec(codec)
+	migrated, err := mgr.MigrateToCodec()
+	if err != nil || migrated != idMigrateBatchSize+10 || mgr.Count() != 0 {
+		t.Fatal(err, migrated, mgr.Count())
+	}
+
+	// 旧的字符串ID重定向到 codec 转换的字符串ID
+	for intId, oldStringId := range oldStringIds {
+		if _, ok := mgr.GetIntId(oldStringId); ok {
+			t.Fatal(oldStringId)
+		}
+		redirect, ok := mgr.GetRedirect(oldStringId)
+		if !ok || redirect.IntId != intId || redirect.To != codec.Encode(intId) {
+			t.Fatal(oldStringId, redirect)
+		}
+		if s, _ := mgr.GetStringId(intId); s != codec.Encode(intId) {
+			t.Fatal(s)
+		}
+	}
+
+	// 不保留重定向时旧的字符串ID不再有效
+	mgr.SetCodec(nil)
+	mgr.AddIntId(5000)
+	mgr.SetCodec(codec)
+	mgr.SetRedirectTTL(-1)
+	if migrated, err = mgr.MigrateToCodec(); err != nil || migrated != 1 {
+		t.Fatal(err, migrated)
+	}
+	if stringId, _ := mgr.GetStringId(5000); stringId != codec.Encode(5000) {
+		t.Fatal(stringId)
+	}
 }
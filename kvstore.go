@@ -9,6 +9,7 @@ import (
 	"sync"
 
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 // 注意：
@@ -66,7 +67,7 @@ func (this *KVStore) Close() error {
 
 func (this *KVStore) Put(key, value []byte) error {
 	if isReservedlKey(key) {
-		return errors.New("Not allow put reserved key")
+		return newError(ErrReadOnly, "Not allow put reserved key")
 	}
 
 	if this.Has(key) {
@@ -89,7 +90,7 @@ func (this *KVStore) Put(key, value []byte) error {
 
 func (this *KVStore) Get(key []byte) ([]byte, error) {
 	if isReservedlKey(key) {
-		return nil, errors.New("Not allow get reserved key")
+		return nil, newError(ErrReadOnly, "Not allow get reserved key")
 	}
 
 	value, err := this.db.Get(key, nil)
@@ -98,12 +99,15 @@ func (this *KVStore) Get(key []byte) ([]byte, error) {
 		copy(copyValue, value)
 		return copyValue, nil
 	}
+	if err == leveldb.ErrNotFound {
+		return nil, newError(ErrNotFound, "Key not exist")
+	}
 	return nil, err
 }
 
 func (this *KVStore) Delete(key []byte) error {
 	if isReservedlKey(key) {
-		return errors.New("Not allow delete reserved key")
+		return newError(ErrReadOnly, "Not allow delete reserved key")
 	}
 
 	if this.Has(key) {
@@ -121,7 +125,7 @@ func (this *KVStore) Delete(key []byte) error {
 		return this.db.Write(batch, nil)
 	}
 
-	return errors.New("Key not exist")
+	return newError(ErrNotFound, "Key not exist")
 }
 
 func (this *KVStore) Has(key []byte) bool {
@@ -192,6 +196,143 @@ func (this *KVStore) Prev(key []byte, n int) [][]byte {
 	return keys
 }
 
+// 按字典序遍历所有以prefix开头的key（会过滤保留key），f 返回 false 时停止遍历
+// prefix为空数组或者nil，表示遍历全部
+// 注意：传给 f 的 key 和 value 都是拷贝，可以放心保存
+func (this *KVStore) Scan(prefix []byte, f func(key, value []byte) bool) {
+	this.ScanAfter(prefix, nil, f)
+}
+
+// 和 Scan 一样，但是从 after 后面的key开始遍历（不包括 after 本身，after 也可以不存在），用于分页
+// after 为空数组或者nil，表示从头开始遍历
+func (this *KVStore) ScanAfter(prefix []byte, after []byte, f func(key, value []byte) bool) {
+	iter := this.db.NewIterator(util.BytesPrefix(prefix), nil)
+	defer iter.Release()
+
+	ok := false
+	if len(after) == 0 {
+		ok = iter.First()
+	} else {
+		ok = iter.Seek(after)
+	}
+
+	for ; ok; ok = iter.Next() {
+		// 过滤当前key 和 保留key
+		if bytes.Equal(iter.Key(), after) || isReservedlKey(iter.Key()) {
+			continue
+		}
+
+		copyKey := make([]byte, len(iter.Key()))
+		copy(copyKey, iter.Key())
+		copyValue := make([]byte, len(iter.Value()))
+		copy(copyValue, iter.Value())
+
+		if !f(copyKey, copyValue) {
+			break
+		}
+	}
+}
+
+// 和 ScanAfter 相反，按字典序从大到小遍历 before 前面的key（不包括 before 本身，before 也可以不存在）
+// before 为空数组或者nil，表示从末尾开始遍历
+func (this *KVStore) ScanBefore(prefix []byte, before []byte, f func(key, value []byte) bool) {
+	iter := this.db.NewIterator(util.BytesPrefix(prefix), nil)
+	defer iter.Release()
+
+	ok := false
+	if len(before) == 0 {
+		ok = iter.Last()
+	} else if ok = iter.Seek(before); ok {
+		// Seek 指向第一个大于等于 before 的key，需要往前退一个
+		ok = iter.Prev()
+	} else {
+		// 没有大于等于 before 的key，说明 before 在最后面
+		ok = iter.Last()
+	}
+
+	for ; ok; ok = iter.Prev() {
+		// 过滤当前key 和 保留key
+		if bytes.Equal(iter.Key(), before) || isReservedlKey(iter.Key()) {
+			continue
+		}
+
+		copyKey := make([]byte, len(iter.Key()))
+		copy(copyKey, iter.Key())
+		copyValue := make([]byte, len(iter.Value()))
+		copy(copyValue, iter.Value())
+
+		if !f(copyKey, copyValue) {
+			break
+		}
+	}
+}
+
+// 批量写入的操作，按添加的顺序执行
+type KVBatch struct {
+	ops []kvOp
+}
+
+type kvOp struct {
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+func (this *KVBatch) Put(key, value []byte) {
+	this.ops = append(this.ops, kvOp{key: key, value: value})
+}
+
+func (this *KVBatch) Delete(key []byte) {
+	this.ops = append(this.ops, kvOp{key: key, delete: true})
+}
+
+func (this *KVBatch) Len() int {
+	return len(this.ops)
+}
+
+// 批量写入，要么全部成功，要么全部失败，同时维护key的总数
+// 大量写入时比逐个 Put 快很多，因为只写一次，而且 key总数 也只更新一次
+func (this *KVStore) Write(batch *KVBatch) error {
+	if batch.Len() == 0 {
+		return nil
+	}
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	count := this.count()
+	exists := make(map[string]bool)
+	b := new(leveldb.Batch)
+
+	for _, op := range batch.ops {
+		if isReservedlKey(op.key) {
+			return newError(ErrReadOnly, "Not allow write reserved key")
+		}
+
+		// 同一个key可能在批处理中出现多次，以最后的状态为准
+		exist, ok := exists[string(op.key)]
+		if !ok {
+			exist = this.Has(op.key)
+		}
+
+		if op.delete {
+			if exist {
+				count--
+			}
+			b.Delete(op.key)
+		} else {
+			if !exist {
+				count++
+			}
+			b.Put(op.key, op.value)
+		}
+		exists[string(op.key)] = !op.delete
+	}
+	b.Put(keyForCount, []byte(strconv.FormatUint(count, 10)))
+
+	return this.db.Write(b, nil)
+}
+
 // 返回 key 的数量
 func (this *KVStore) Count() uint64 {
 	this.mutex.RLock()
@@ -242,6 +383,34 @@ func (this *KVStore) currentSequence() uint64 {
 	return sequence
 }
 
+// 一次生成n个连续的Sequence，返回第一个，n个Sequence分别为 first ~ first+n-1
+// n为0时返回0
+func (this *KVStore) NextSequences(n uint64) (uint64, error) {
+	if n == 0 {
+		return 0, nil
+	}
+
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	first := this.currentSequence() + 1
+	err := this.db.Put(keyForSequence, []byte(strconv.FormatUint(first+n-1, 10)), nil)
+
+	return first, err
+}
+
+// 保证当前Sequence不小于sequence，用于导入时保留原来的ID，之后生成的Sequence从这里继续
+// 当前Sequence已经更大时不做任何修改
+func (this *KVStore) AdvanceSequence(sequence uint64) error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.currentSequence() >= sequence {
+		return nil
+	}
+	return this.db.Put(keyForSequence, []byte(strconv.FormatUint(sequence, 10)), nil)
+}
+
 // 生成并返回下一个Sequence
 // 注意这是一个读写操作
 func (this *KVStore) NextSequence() (uint64, error) {
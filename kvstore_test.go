@@ -289,3 +289,231 @@ func TestSequence(t *testing.T) {
 		}
 	}
 }
+
+func TestScan(t *testing.T) {
+	dbPath := "test.db"
+	defer os.RemoveAll(dbPath)
+
+	db := &KVStore{}
+	err := db.Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	db.Put([]byte("a_1"), []byte("1"))
+	db.Put([]byte("a_2"), []byte("2"))
+	db.Put([]byte("b_1"), []byte("3"))
+	db.NextSequence()
+
+	keys := make([]string, 0)
+	db.Scan([]byte("a_"), func(key, value []byte) bool {
+		keys = append(keys, string(key)+"="+string(value))
+		return true
+	})
+	if len(keys) != 2 || keys[0] != "a_1=1" || keys[1] != "a_2=2" {
+		t.Fatal(keys)
+	}
+
+	// 保留key不会被遍历到
+	count := 0
+	db.Scan(nil, func(key, value []byte) bool {
+		count++
+		return true
+	})
+	if count != 3 {
+		t.Fatal(count)
+	}
+
+	// 提前结束
+	count = 0
+	db.Scan(nil, func(key, value []byte) bool {
+		count++
+		return false
+	})
+	if count != 1 {
+		t.Fatal(count)
+	}
+}
+
+func TestScanAfter(t *testing.T) {
+	dbPath := "test.db"
+	defer os.RemoveAll(dbPath)
+
+	db := &KVStore{}
+	err := db.Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for i := 10; i < 20; i++ {
+		db.Put([]byte("a_"+strconv.Itoa(i)), []byte(strconv.Itoa(i)))
+	}
+	db.Put([]byte("b_1"), []byte("1"))
+
+	keys := make([]string, 0)
+	db.ScanAfter([]byte("a_"), []byte("a_17"), func(key, value []byte) bool {
+		keys = append(keys, string(key))
+		return true
+	})
+	if len(keys) != 2 || keys[0] != "a_18" || keys[1] != "a_19" {
+		t.Fatal(keys)
+	}
+
+	// after 不存在
+	keys = keys[:0]
+	db.ScanAfter([]byte("a_"), []byte("a_155"), func(key, value []byte) bool {
+		keys = append(keys, string(key))
+		return len(keys) < 2
+	})
+	if len(keys) != 2 || keys[0] != "a_16" || keys[1] != "a_17" {
+		t.Fatal(keys)
+	}
+
+	// after 在前缀范围之外
+	keys = keys[:0]
+	db.ScanAfter([]byte("a_"), []byte("a_9"), func(key, value []byte) bool {
+		keys = append(keys, string(key))
+		return true
+	})
+	if len(keys) != 0 {
+		t.Fatal(keys)
+	}
+}
+
+func TestScanBefore(t *testing.T) {
+	dbPath := "test.db"
+	defer os.RemoveAll(dbPath)
+
+	db := &KVStore{}
+	err := db.Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for i := 10; i < 20; i++ {
+		db.Put([]byte("a_"+strconv.Itoa(i)), []byte(strconv.Itoa(i)))
+	}
+	db.Put([]byte("b_1"), []byte("1"))
+
+	keys := make([]string, 0)
+	db.ScanBefore([]byte("a_"), []byte("a_12"), func(key, value []byte) bool {
+		keys = append(keys, string(key))
+		return true
+	})
+	if len(keys) != 2 || keys[0] != "a_11" || keys[1] != "a_10" {
+		t.Fatal(keys)
+	}
+
+	// before 为空，从末尾开始
+	keys = keys[:0]
+	db.ScanBefore([]byte("a_"), nil, func(key, value []byte) bool {
+		keys = append(keys, string(key))
+		return len(keys) < 2
+	})
+	if len(keys) != 2 || keys[0] != "a_19" || keys[1] != "a_18" {
+		t.Fatal(keys)
+	}
+
+	// before 不存在
+	keys = keys[:0]
+	db.ScanBefore([]byte("a_"), []byte("a_155"), func(key, value []byte) bool {
+		keys = append(keys, string(key))
+		return len(keys) < 2
+	})
+	if len(keys) != 2 || keys[0] != "a_15" || keys[1] != "a_14" {
+		t.Fatal(keys)
+	}
+
+	// before 在前缀范围之外
+	keys = keys[:0]
+	db.ScanBefore([]byte("a_"), []byte("a_9"), func(key, value []byte) bool {
+		keys = append(keys, string(key))
+		return len(keys) < 1
+	})
+	if len(keys) != 1 || keys[0] != "a_19" {
+		t.Fatal(keys)
+	}
+	keys = keys[:0]
+	db.ScanBefore([]byte("a_"), []byte("a_0"), func(key, value []byte) bool {
+		keys = append(keys, string(key))
+		return true
+	})
+	if len(keys) != 0 {
+		t.Fatal(keys)
+	}
+}
+
+func TestWriteBatch(t *testing.T) {
+	dbPath := "test.db"
+	defer os.RemoveAll(dbPath)
+
+	db := &KVStore{}
+	err := db.Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	db.Put([]byte("a"), []byte("1"))
+	db.Put([]byte("b"), []byte("2"))
+
+	batch := &KVBatch{}
+	batch.Put([]byte("a"), []byte("11"))
+	batch.Put([]byte("c"), []byte("3"))
+	batch.Put([]byte("c"), []byte("33"))
+	batch.Delete([]byte("b"))
+	batch.Delete([]byte("none"))
+	batch.Put([]byte("d"), []byte("4"))
+	batch.Delete([]byte("d"))
+	if err = db.Write(batch); err != nil {
+		t.Fatal(err)
+	}
+
+	if db.Count() != 2 || db.Has([]byte("b")) || db.Has([]byte("d")) {
+		t.Fatal(db.Count())
+	}
+	if v, _ := db.Get([]byte("a")); string(v) != "11" {
+		t.Fatal()
+	}
+	if v, _ := db.Get([]byte("c")); string(v) != "33" {
+		t.Fatal()
+	}
+
+	// 不允许写保留key，整个批处理都不会生效
+	batch = &KVBatch{}
+	batch.Put([]byte("e"), []byte("5"))
+	batch.Put(keyForCount, []byte("100"))
+	if db.Write(batch) == nil || db.Has([]byte("e")) || db.Count() != 2 {
+		t.Fatal()
+	}
+	if db.Write(&KVBatch{}) != nil {
+		t.Fatal()
+	}
+}
+
+func TestNextSequences(t *testing.T) {
+	dbPath := "test.db"
+	defer os.RemoveAll(dbPath)
+
+	db := &KVStore{}
+	err := db.Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	db.NextSequence()
+	first, err := db.NextSequences(100)
+	if err != nil || first != 2 || db.CurrentSequence() != 101 {
+		t.Fatal(err)
+	}
+	if seq, _ := db.NextSequence(); seq != 102 {
+		t.Fatal()
+	}
+	if first, _ = db.NextSequences(0); first != 0 || db.CurrentSequence() != 102 {
+		t.Fatal()
+	}
+}
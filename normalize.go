@@ -0,0 +1,47 @@
+package gmodel
+
+import (
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// 分类名称的规范化函数
+// 规范化之后相同的名称被认为是同一个分类，比如 "Go"、"go " 和全角的 "Ｇｏ"
+// 注意：分类本身仍然保存原始名称用于显示，规范化后的名称只用于查找
+type TagNormalizer func(name string) string
+
+// 去掉首尾空白字符
+func TrimNormalizer(name string) string {
+	return strings.TrimSpace(name)
+}
+
+// 大小写折叠，比如 "Go" 和 "GO" 都变成 "go"
+func FoldCaseNormalizer(name string) string {
+	// cases.Caser 不是线程安全的，每次新建一个
+	return cases.Fold().String(name)
+}
+
+// Unicode NFKC 规范化，比如全角字符变成半角字符
+func NFKCNormalizer(name string) string {
+	return norm.NFKC.String(name)
+}
+
+// 去掉首尾空白字符，并且将中间连续的空白字符合并成一个空格
+func CollapseSpaceNormalizer(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// 按顺序组合多个规范化函数
+func ChainNormalizers(normalizers ...TagNormalizer) TagNormalizer {
+	return func(name string) string {
+		for _, normalizer := range normalizers {
+			name = normalizer(name)
+		}
+		return name
+	}
+}
+
+// 推荐使用的规范化函数：先做 NFKC（全角空格也会变成普通空格），再合并空白字符，最后大小写折叠
+var DefaultTagNormalizer = ChainNormalizers(NFKCNormalizer, CollapseSpaceNormalizer, FoldCaseNormalizer)
@@ -0,0 +1,38 @@
+package gmodel
+
+import (
+	"testing"
+)
+
+func TestNormalizer(t *testing.T) {
+	if TrimNormalizer("  go \t") != "go" {
+		t.Fatal()
+	}
+	if FoldCaseNormalizer("GoLang") != "golang" {
+		t.Fatal()
+	}
+	if NFKCNormalizer("Ｇｏ") != "Go" {
+		t.Fatal()
+	}
+	if CollapseSpaceNormalizer("  machine \t  learning ") != "machine learning" {
+		t.Fatal()
+	}
+
+	names := []string{"Go", "go ", "Ｇｏ", " GO", "ｇ　", "　Ｇｏ　"}
+	for _, name := range names[:4] {
+		if DefaultTagNormalizer(name) != "go" {
+			t.Fatal(name)
+		}
+	}
+	if DefaultTagNormalizer(names[4]) != "g" || DefaultTagNormalizer(names[5]) != "go" {
+		t.Fatal()
+	}
+
+	chain := ChainNormalizers(TrimNormalizer)
+	if chain(" A ") != "A" {
+		t.Fatal()
+	}
+	if ChainNormalizers()(" A ") != " A " {
+		t.Fatal()
+	}
+}
@@ -0,0 +1,226 @@
+package gmodel
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// 按页码分页时，为了不从头遍历索引，在索引数据库中按文章ID分段保存每个分类的文章数
+// 格式：b分类ID_段号 -> 该段内的文章数，段号为 文章ID/indexBucketSize，补全到15位
+// 跳到第N页时，先累加各段的文章数找到目标所在的段，再从该段开始遍历索引
+// 注意：以字母b开头，不会和 分类ID_文章ID 格式的索引key冲突
+const indexBucketSize = 1000
+
+var (
+	indexKeyVersion = []byte("meta_version")
+
+	// 索引数据库版本
+	// 1: 增加分段文章数
+	indexSchemaVersion uint64 = 1
+)
+
+// 排序方式
+type Order int
+
+const (
+	OrderDesc Order = iota // 按文章ID从大到小，即最新的文章在前面
+	OrderAsc               // 按文章ID从小到大
+)
+
+// 按页码分页的结果
+type ArticlePage struct {
+	Articles  []*Article `json:"articles"`
+	Page      uint64     `json:"page"`       // 当前页码，从1开始
+	Size      int        `json:"size"`       // 每页文章数
+	Total     uint64     `json:"total"`      // 文章总数
+	PageCount uint64     `json:"page_count"` // 总页数
+}
+
+// 按页码获取指定分类下的文章，page从1开始，size为每页的文章数
+// 如果tag不存在或者页码超出范围，则返回的文章为空，但是 Total 和 PageCount 仍然有效
+func (this *GModel) GetArticlesByTagPage(tagName string, page uint64, size int, order Order) (*ArticlePage, error) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	return this.getArticlesByTermPage(this.defaultTaxonomy(), tagName, page, size, order)
+}
+
+// 按页码获取指定分类法的分类下的文章，详见 GetArticlesByTagPage
+func (this *GModel) GetArticlesByTermPage(taxonomyName string, term string, page uint64, size int, order Order) (*ArticlePage, error) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	tax, err := this.getTaxonomy(taxonomyName)
+	if err != nil {
+		return nil, err
+	}
+	return this.getArticlesByTermPage(tax, term, page, size, order)
+}
+
+func (this *GModel) getArticlesByTermPage(tax *taxonomy, name string, page uint64, size int, order Order) (*ArticlePage, error) {
+	if page == 0 || size <= 0 {
+		return nil, newError(ErrInvalidArgument, fmt.Sprintf("Invalid page[%v] or size[%v]", page, size))
+	}
+
+	result := &ArticlePage{
+		Articles: make([]*Article, 0),
+		Page:     page,
+		Size:     size,
+	}
+
+	tag, err := tax.tagMgr.GetByName(name)
+	if err != nil {
+		return result, nil
+	}
+
+	result.Total = tag.ArticleCount
+	result.PageCount = (result.Total + uint64(size) - 1) / uint64(size)
+	if page > result.PageCount {
+		return result, nil
+	}
+
+	// 先换算成按ID从小到大的位置，倒序时取出来再反转
+	offset := (page - 1) * uint64(size)
+	count := uint64(size)
+	if offset+count > result.Total {
+		count = result.Total - offset
+	}
+	if order == OrderDesc {
+		offset = result.Total - offset - count
+	}
+
+	articleIds := this.getArticleIdsByOffset(tax.indexDB, tag.Id, offset, count)
+	if order == OrderDesc {
+		for i, j := 0, len(articleIds)-1; i < j; i, j = i+1, j-1 {
+			articleIds[i], articleIds[j] = articleIds[j], articleIds[i]
+		}
+	}
+
+	for _, articleId := range articleIds {
+		if article, err := this.articleMgr.GetById(articleId); err == nil {
+			result.Articles = append(result.Articles, article)
+		}
+	}
+
+	return result, nil
+}
+
+// 返回分类下按文章ID从小到大排在第 offset 位（从0开始）之后的 count 个文章ID
+func (this *GModel) getArticleIdsByOffset(indexDB *KVStore, tagId uint64, offset uint64, count uint64) []uint64 {
+	articleIds := make([]uint64, 0)
+
+	// 找到目标所在的段
+	var after []byte
+	found := false
+	indexDB.Scan([]byte(this.getBucketKeyPrefix(tagId)), func(key, value []byte) bool {
+		n, err := strconv.ParseUint(string(value), 10, 64)
+		if err != nil {
+			return true
+		}
+		if offset < n {
+			found = true
+			bucket, _ := strconv.ParseUint(string(key[len(this.getBucketKeyPrefix(tagId)):]), 10, 64)
+			if bucket > 0 {
+				after = this.getIndexKey(tagId, bucket*indexBucketSize-1)
+			}
+			return false
+		}
+		offset -= n
+		return true
+	})
+	if !found {
+		return articleIds
+	}
+
+	// 从该段开始遍历索引
+	indexDB.ScanAfter([]byte(this.getIndexKeyPrefix(tagId)), after, func(key, value []byte) bool {
+		if offset > 0 {
+			offset--
+			return true
+		}
+		if articleId, err := strconv.ParseUint(string(value), 10, 64); err == nil {
+			articleIds = append(articleIds, articleId)
+		}
+		return uint64(len(articleIds)) < count
+	})
+
+	return articleIds
+}
+
+// 修改分段文章数
+func (this *GModel) addBucketCount(indexDB *KVStore, tagId uint64, articleId uint64, delta int64) {
+	key := this.getBucketKey(tagId, articleId)
+	count := this.getBucketCount(indexDB, key)
+
+	if delta < 0 && count <= uint64(-delta) {
+		indexDB.Delete(key)
+		return
+	}
+	indexDB.Put(key, []byte(strconv.FormatUint(uint64(int64(count)+delta), 10)))
+}
+
+func (this *GModel) getBucketCount(indexDB *KVStore, key []byte) uint64 {
+	if value, err := indexDB.Get(key); err == nil {
+		if count, err := strconv.ParseUint(string(value), 10, 64); err == nil {
+			return count
+		}
+	}
+	return 0
+}
+
+// 升级旧版本的索引数据库
+func (this *GModel) upgradeIndex(indexDB *KVStore) error {
+	version := uint64(0)
+	if value, err := indexDB.Get(indexKeyVersion); err == nil {
+		version, _ = strconv.ParseUint(string(value), 10, 64)
+	}
+	if version >= indexSchemaVersion {
+		return nil
+	}
+
+	if version < 1 {
+		// 遍历全部索引，统计分段文章数
+		counts := make(map[string]uint64)
+		indexDB.Scan(nil, func(key, value []byte) bool {
+			tagId, articleId, ok := this.parseIndexKey(key)
+			if ok {
+				counts[string(this.getBucketKey(tagId, articleId))]++
+			}
+			return true
+		})
+		for key, count := range counts {
+			if err := indexDB.Put([]byte(key), []byte(strconv.FormatUint(count, 10))); err != nil {
+				return err
+			}
+		}
+	}
+
+	return indexDB.Put(indexKeyVersion, []byte(strconv.FormatUint(indexSchemaVersion, 10)))
+}
+
+// 解析 分类ID_文章ID 格式的索引key
+func (this *GModel) parseIndexKey(key []byte) (uint64, uint64, bool) {
+	for i, c := range key {
+		if c == '_' {
+			tagId, err := strconv.ParseUint(string(key[:i]), 10, 64)
+			if err != nil {
+				return 0, 0, false
+			}
+			articleId, err := strconv.ParseUint(string(key[i+1:]), 10, 64)
+			if err != nil {
+				return 0, 0, false
+			}
+			return tagId, articleId, true
+		}
+	}
+	return 0, 0, false
+}
+
+// 返回分段文章数的key，比如tagId为101，articleId为1999，那么key为: b101_000000000000001
+func (this *GModel) getBucketKey(tagId uint64, articleId uint64) []byte {
+	return []byte(this.getBucketKeyPrefix(tagId) + GetStringKey(articleId/indexBucketSize))
+}
+
+func (this *GModel) getBucketKeyPrefix(tagId uint64) string {
+	return "b" + strconv.FormatUint(tagId, 10) + "_"
+}
@@ -0,0 +1,172 @@
+package gmodel
+
+import (
+	"os"
+	"strconv"
+	"testing"
+)
+
+func pageIds(page *ArticlePage) []uint64 {
+	ids := make([]uint64, 0)
+	for _, article := range page.Articles {
+		ids = append(ids, article.Id)
+	}
+	return ids
+}
+
+func isEqualIds(left, right []uint64) bool {
+	if len(left) != len(right) {
+		return false
+	}
+	for i := 0; i < len(left); i++ {
+		if left[i] != right[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestArticlesByTagPage(t *testing.T) {
+	articleDBPath := "test_article.db"
+	tagDBPath := "test_tag.db"
+	indexDBPath := "test_index.db"
+
+	defer func() {
+		os.RemoveAll(articleDBPath)
+		os.RemoveAll(tagDBPath)
+		os.RemoveAll(indexDBPath)
+	}()
+
+	gmodel := &GModel{}
+	err := gmodel.Open(articleDBPath, tagDBPath, indexDBPath)
+	if err != nil {
+		t.Fatal()
+	}
+	defer gmodel.Close()
+
+	// 奇数ID的文章属于 odd，偶数ID的文章属于 even，全部文章属于 all
+	for i := 1; i <= 25; i++ {
+		tags := []string{"all", "even"}
+		if i%2 == 1 {
+			tags[1] = "odd"
+		}
+		gmodel.AddArticle(tags, "data_"+strconv.Itoa(i))
+	}
+
+	page, err := gmodel.GetArticlesByTagPage("all", 1, 10, OrderDesc)
+	if err != nil || page.Total != 25 || page.PageCount != 3 {
+		t.Fatal(err)
+	}
+	if !isEqualIds(pageIds(page), []uint64{25, 24, 23, 22, 21, 20, 19, 18, 17, 16}) {
+		t.Fatal(pageIds(page))
+	}
+	page, _ = gmodel.GetArticlesByTagPage("all", 3, 10, OrderDesc)
+	if !isEqualIds(pageIds(page), []uint64{5, 4, 3, 2, 1}) {
+		t.Fatal(pageIds(page))
+	}
+	page, _ = gmodel.GetArticlesByTagPage("all", 3, 10, OrderAsc)
+	if !isEqualIds(pageIds(page), []uint64{21, 22, 23, 24, 25}) {
+		t.Fatal(pageIds(page))
+	}
+	page, _ = gmodel.GetArticlesByTagPage("odd", 2, 5, OrderAsc)
+	if page.Total != 13 || !isEqualIds(pageIds(page), []uint64{11, 13, 15, 17, 19}) {
+		t.Fatal(pageIds(page))
+	}
+
+	// 超出范围
+	page, err = gmodel.GetArticlesByTagPage("all", 4, 10, OrderDesc)
+	if err != nil || len(page.Articles) != 0 || page.Total != 25 {
+		t.Fatal(err)
+	}
+	page, err = gmodel.GetArticlesByTagPage("none", 1, 10, OrderDesc)
+	if err != nil || len(page.Articles) != 0 || page.Total != 0 || page.PageCount != 0 {
+		t.Fatal(err)
+	}
+	if _, err = gmodel.GetArticlesByTagPage("all", 0, 10, OrderDesc); err == nil {
+		t.Fatal()
+	}
+	if _, err = gmodel.GetArticlesByTagPage("all", 1, 0, OrderDesc); err == nil {
+		t.Fatal()
+	}
+
+	// 删除和修改文章后分段文章数也要同步
+	gmodel.DeleteArticle(24)
+	gmodel.UpdateArticle(25, []string{"even"}, "data_25")
+	page, _ = gmodel.GetArticlesByTagPage("all", 1, 3, OrderDesc)
+	if page.Total != 23 || !isEqualIds(pageIds(page), []uint64{23, 22, 21}) {
+		t.Fatal(pageIds(page))
+	}
+	page, _ = gmodel.GetArticlesByTagPage("even", 1, 2, OrderDesc)
+	if page.Total != 12 || !isEqualIds(pageIds(page), []uint64{25, 22}) {
+		t.Fatal(pageIds(page))
+	}
+}
+
+func TestIndexBucket(t *testing.T) {
+	articleDBPath := "test_article.db"
+	tagDBPath := "test_tag.db"
+	indexDBPath := "test_index.db"
+
+	defer func() {
+		os.RemoveAll(articleDBPath)
+		os.RemoveAll(tagDBPath)
+		os.RemoveAll(indexDBPath)
+	}()
+
+	gmodel := &GModel{}
+	err := gmodel.Open(articleDBPath, tagDBPath, indexDBPath)
+	if err != nil {
+		t.Fatal()
+	}
+
+	// 文章ID跨越多个分段，分类10的索引排在分类1和分类2之间
+	ids := []uint64{1, 2, 999, 1000, 1001, 2500, 7000, 7001, 7999}
+	gmodel.addIndex(gmodel.indexDB, []uint64{1}, ids[0])
+	for _, id := range ids {
+		gmodel.addIndex(gmodel.indexDB, []uint64{10, 2}, id)
+	}
+	gmodel.addIndex(gmodel.indexDB, []uint64{10}, ids[0])
+
+	check := func() {
+		for offset := 0; offset <= len(ids); offset++ {
+			result := gmodel.getArticleIdsByOffset(gmodel.indexDB, 10, uint64(offset), 3)
+			end := offset + 3
+			if end > len(ids) {
+				end = len(ids)
+			}
+			if !isEqualIds(result, ids[offset:end]) {
+				t.Fatal(offset, result)
+			}
+		}
+	}
+	check()
+
+	// 旧版本的索引数据库没有分段文章数，打开时自动升级
+	keys := make([][]byte, 0)
+	gmodel.indexDB.Scan([]byte("b"), func(key, value []byte) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if len(keys) != 1+4+4 {
+		t.Fatal(len(keys))
+	}
+	for _, key := range keys {
+		gmodel.indexDB.Delete(key)
+	}
+	gmodel.indexDB.Delete(indexKeyVersion)
+	gmodel.Close()
+
+	if err = gmodel.Open(articleDBPath, tagDBPath, indexDBPath); err != nil {
+		t.Fatal(err)
+	}
+	defer gmodel.Close()
+	check()
+
+	gmodel.deleteIndex(gmodel.indexDB, []uint64{10}, 2500)
+	gmodel.deleteIndex(gmodel.indexDB, []uint64{10}, 2500)
+	ids = []uint64{1, 2, 999, 1000, 1001, 7000, 7001, 7999}
+	check()
+	if gmodel.indexDB.Has(gmodel.getBucketKey(10, 2500)) {
+		t.Fatal()
+	}
+}
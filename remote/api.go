@@ -20,6 +20,30 @@ const (
 	APIGetPrevTags          = "/admin/get-prev-tags"
 	APIRenameTag            = "/admin/rename-tag"
 	APIGetArticleCountByTag = "/admin/get-article-count-by-tag"
+	APIGetTagBySlug         = "/admin/get-tag-by-slug"
+	APIUpdateTagMeta        = "/admin/update-tag-meta"
+	APIGetTopTags           = "/admin/get-top-tags"
+	APISearchTags           = "/admin/search-tags"
+
+	APIGetNextArticlesByTerm = "/admin/get-next-articles-by-term"
+	APIGetPrevArticlesByTerm = "/admin/get-prev-articles-by-term"
+	APIGetArticlesByTagPage  = "/admin/get-articles-by-tag-page"
+
+	APIListArticles      = "/admin/list-articles"
+	APIListArticlesByTag = "/admin/list-articles-by-tag"
+	APIListTags          = "/admin/list-tags"
+	APIListTopTags       = "/admin/list-top-tags"
+	APIGetArticles       = "/admin/get-articles"
+	APIAddArticles       = "/admin/add-articles"
+	APIUpsertArticle     = "/admin/upsert-article"
+
+	APIGetCustomId     = "/admin/get-custom-id"
+	APIResolveCustomId = "/admin/resolve-custom-id"
+	APISetCustomId     = "/admin/set-custom-id"
+	APIUpdateCustomId  = "/admin/update-custom-id"
+	APIAddTag          = "/admin/add-tag"
+	APIDeleteTag       = "/admin/delete-tag"
+	APIGetTagCount     = "/admin/get-tag-count"
 )
 
 // CustomArticleId 优先，CustomArticleId为空时才使用 Article.Id，下同
@@ -27,6 +51,9 @@ type RemoteArticle struct {
 	*gmodel.Article
 	CustomArticleId string   `json:"custom_article_id"` // 自定义文章ID，优先级高于 Article.Id
 	TagNameArray    []string `json:"tag_name_array"`    // 分类数组
+
+	// 其他分类法的分类名称，按分类法分组，比如 {"keyword": ["go", "leveldb"]}
+	TermNames map[string][]string `json:"term_names,omitempty"`
 }
 
 type RemoteTag struct {
@@ -46,9 +73,10 @@ type GetModelInfoResp struct {
 }
 
 type AddArticleReq struct {
-	Tags            []string `json:"tags"`
-	Data            string   `json:"data"`
-	CustomArticleId string   `json:"custom_article_id"`
+	Tags            []string            `json:"tags"`
+	Terms           map[string][]string `json:"terms"` // 其他分类法的分类，key为分类法名称
+	Data            string              `json:"data"`
+	CustomArticleId string              `json:"custom_article_id"`
 }
 
 type AddArticleResp struct {
@@ -57,6 +85,19 @@ type AddArticleResp struct {
 	CustomArticleId string `json:"custom_article_id"`
 }
 
+// 按自定义文章ID增加或者修改文章，CustomArticleId 不能为空，其他参数同 AddArticleReq
+// Terms 中没有出现的分类法保持不变，同 UpdateArticleReq
+type UpsertArticleReq = AddArticleReq
+
+// Result 为 "created"、"updated" 或者 "unchanged"，详见 gmodel.UpsertResult
+// CustomArticleId 为当前的自定义文章ID，请求的是旧的自定义文章ID（详见 UpdateCustomIdReq）时两者不同
+type UpsertArticleResp struct {
+	BaseResp
+	ArticleId       uint64 `json:"article_id"`
+	CustomArticleId string `json:"custom_article_id"`
+	Result          string `json:"result"`
+}
+
 type DeleteArticleReq struct {
 	ArticleId       uint64 `json:"article_id"`
 	CustomArticleId string `json:"custom_article_id"`
@@ -95,11 +136,13 @@ type GetNextArticlesByTagResp = GetNextArticlesResp
 type GetPrevArticlesByTagReq = GetNextArticlesByTagReq
 type GetPrevArticlesByTagResp = GetNextArticlesByTagResp
 
+// NewTerms 中没有出现的分类法保持不变，出现但是分类为空表示清空该分类法下的分类
 type UpdateArticleReq struct {
-	ArticleId       uint64   `json:"article_id"`
-	CustomArticleId string   `json:"custom_article_id"`
-	NewTags         []string `json:"new_tags"`
-	NewData         string   `json:"new_data"`
+	ArticleId       uint64              `json:"article_id"`
+	CustomArticleId string              `json:"custom_article_id"`
+	NewTags         []string            `json:"new_tags"`
+	NewTerms        map[string][]string `json:"new_terms"`
+	NewData         string              `json:"new_data"`
 }
 
 type UpdateArticleResp = BaseResp
@@ -147,3 +190,204 @@ type GetArticleCountByTagResp struct {
 	BaseResp
 	ArticleCount uint64 `json:"article_count"`
 }
+
+type GetTagBySlugReq struct {
+	Slug string `json:"slug"`
+}
+
+type GetTagBySlugResp = GetTagByIdResp
+
+// 会覆盖旧的附加信息
+type UpdateTagMetaReq struct {
+	TagName     string            `json:"tag_name"`
+	Slug        string            `json:"slug"`
+	Description string            `json:"description"`
+	Aliases     []string          `json:"aliases"`
+	Attrs       map[string]string `json:"attrs"`
+}
+
+type UpdateTagMetaResp = BaseResp
+
+// TagName 为空表示获取文章数最多的N个分类，否则返回 TagName 之后的N个分类，用于分页
+type GetTopTagsReq = GetNextTagsReq
+type GetTopTagsResp = GetNextTagsResp
+
+// 按名称前缀查找分类，游标分页，Cursor 为空表示第一页，翻页时 Prefix 和 IgnoreCase 需要保持不变
+type SearchTagsReq struct {
+	Prefix     string `json:"prefix"`
+	Cursor     string `json:"cursor"`
+	N          int    `json:"n"`
+	IgnoreCase bool   `json:"ignore_case"`
+}
+
+type SearchTagsResp = ListTagsResp
+
+type GetNextArticlesByTermReq struct {
+	GetNextArticlesReq
+	Taxonomy string `json:"taxonomy"`
+	Term     string `json:"term"`
+}
+
+type GetNextArticlesByTermResp = GetNextArticlesResp
+
+type GetPrevArticlesByTermReq = GetNextArticlesByTermReq
+type GetPrevArticlesByTermResp = GetNextArticlesByTermResp
+
+// 按页码分页，Taxonomy 为空表示按分类查找，否则表示按指定分类法的分类查找
+// Order 为 "asc" 表示按文章ID从小到大，否则按文章ID从大到小（最新的在前面）
+type GetArticlesByTagPageReq struct {
+	Taxonomy string `json:"taxonomy"`
+	Tag      string `json:"tag"`
+	Page     uint64 `json:"page"`
+	Size     int    `json:"size"`
+	Order    string `json:"order"`
+}
+
+type RemoteArticlePage struct {
+	RemoteArticles []*RemoteArticle `json:"remote_articles"`
+	Page           uint64           `json:"page"`
+	Size           int              `json:"size"`
+	Total          uint64           `json:"total"`
+	PageCount      uint64           `json:"page_count"`
+}
+
+type GetArticlesByTagPageResp struct {
+	BaseResp
+	*RemoteArticlePage
+}
+
+// 游标分页，Cursor 为空表示第一页，下一页、上一页传入返回的 next_cursor、prev_cursor
+// Order 为 "asc" 表示按ID从小到大，否则按ID从大到小（最新的在前面），翻页时需要保持不变
+type ListArticlesReq struct {
+	Cursor string `json:"cursor"`
+	N      int    `json:"n"`
+	Order  string `json:"order"`
+}
+
+type ListArticlesResp struct {
+	BaseResp
+	gmodel.Page[*RemoteArticle]
+}
+
+// Taxonomy 为空表示按分类查找，否则表示按指定分类法的分类查找
+type ListArticlesByTagReq struct {
+	ListArticlesReq
+	Taxonomy string `json:"taxonomy"`
+	Tag      string `json:"tag"`
+}
+
+type ListArticlesByTagResp = ListArticlesResp
+
+type ListTagsReq = ListArticlesReq
+
+type ListTagsResp struct {
+	BaseResp
+	gmodel.Page[*RemoteTag]
+}
+
+type ListTopTagsReq struct {
+	Cursor string `json:"cursor"`
+	N      int    `json:"n"`
+}
+
+type ListTopTagsResp = ListTagsResp
+
+// 批量获取文章时最多的文章数
+const MaxGetArticlesCount = 1000
+
+// 批量获取文章，每一项都可以用 ArticleId 或者 CustomArticleId，CustomArticleId 优先
+type GetArticlesReq struct {
+	Ids []GetArticleReq `json:"ids"`
+}
+
+// 每一项的结果，和请求的 Ids 一一对应
+type GetArticlesResult struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+	*RemoteArticle
+}
+
+type GetArticlesResp struct {
+	BaseResp
+	Results []*GetArticlesResult `json:"results"`
+}
+
+// 批量增加文章时最多的文章数，导入大量文章时需要分多次调用
+const MaxAddArticlesCount = 1000
+
+type AddArticlesReq struct {
+	Articles []AddArticleReq `json:"articles"`
+}
+
+// 每一项的结果，和请求的 Articles 一一对应
+type AddArticlesResult = AddArticleResp
+
+type AddArticlesResp struct {
+	BaseResp
+	Results []*AddArticlesResult `json:"results"`
+}
+
+// 根据文章ID获取自定义文章ID
+type GetCustomIdReq struct {
+	ArticleId uint64 `json:"article_id"`
+}
+
+type GetCustomIdResp struct {
+	BaseResp
+	ArticleId       uint64 `json:"article_id"`
+	CustomArticleId string `json:"custom_article_id"`
+}
+
+// 根据自定义文章ID获取文章ID，旧的自定义文章ID（详见 UpdateCustomIdReq）也可以
+type ResolveCustomIdReq struct {
+	CustomArticleId string `json:"custom_article_id"`
+}
+
+// CustomArticleId 为当前的自定义文章ID，和请求的不同时表示请求的是旧的自定义文章ID，网站可以返回301
+type ResolveCustomIdResp = GetCustomIdResp
+
+// 设置或者修改文章的自定义文章ID，文章需要存在，旧的自定义文章ID不再有效
+type SetCustomIdReq struct {
+	ArticleId       uint64 `json:"article_id"`
+	CustomArticleId string `json:"custom_article_id"`
+}
+
+type SetCustomIdResp = BaseResp
+
+// 修改文章的自定义文章ID，文章用 ArticleId 或者 CustomArticleId 指定
+// 旧的自定义文章ID在一段时间内重定向到新的，详见 APIServerConfig.CustomIdRedirectTTL
+type UpdateCustomIdReq struct {
+	ArticleId          uint64 `json:"article_id"`
+	CustomArticleId    string `json:"custom_article_id"`
+	NewCustomArticleId string `json:"new_custom_article_id"`
+}
+
+// RedirectFrom 为空表示没有保留重定向，RedirectExpireAt 为重定向的过期时间（Unix秒）
+type UpdateCustomIdResp struct {
+	BaseResp
+	ArticleId        uint64 `json:"article_id"`
+	CustomArticleId  string `json:"custom_article_id"`
+	RedirectFrom     string `json:"redirect_from"`
+	RedirectExpireAt int64  `json:"redirect_expire_at"`
+}
+
+// Taxonomy 为空表示分类，否则表示指定分类法的分类，下同
+type AddTagReq struct {
+	Taxonomy string `json:"taxonomy"`
+	TagName  string `json:"tag_name"`
+}
+
+type AddTagResp = GetTagByIdResp
+
+// 分类下的文章不会删除，只是不再属于该分类
+type DeleteTagReq = AddTagReq
+type DeleteTagResp = BaseResp
+
+type GetTagCountReq struct {
+	Taxonomy string `json:"taxonomy"`
+}
+
+type GetTagCountResp struct {
+	BaseResp
+	TagCount uint64 `json:"tag_count"`
+}
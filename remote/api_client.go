@@ -2,49 +2,271 @@ package remote
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
-	"io/ioutil"
+	"net"
 	"net/http"
+	"time"
+
+	"github.com/gansidui/gmodel"
+)
+
+// 客户端默认配置
+const (
+	DefaultClientTimeout = 30 * time.Second
+	DefaultMaxRetries    = 2
+	DefaultRetryBackoff  = 100 * time.Millisecond
 )
 
+// 所有客户端共享的 Transport，复用连接
+var sharedTransport = &http.Transport{
+	Proxy: http.ProxyFromEnvironment,
+	DialContext: (&net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext,
+	MaxIdleConns:          100,
+	MaxIdleConnsPerHost:   32,
+	IdleConnTimeout:       90 * time.Second,
+	TLSHandshakeTimeout:   10 * time.Second,
+	ExpectContinueTimeout: time.Second,
+}
+
+type APIClientOptions struct {
+	Timeout      time.Duration     // 每次请求的超时时间（包括读取响应），0使用 DefaultClientTimeout，小于0不超时
+	MaxRetries   int               // 只读接口失败时的最大重试次数，0使用 DefaultMaxRetries，小于0不重试
+	RetryBackoff time.Duration     // 第一次重试之前等待的时间，之后每次翻倍，0使用 DefaultRetryBackoff
+	DisableGzip  bool              // 不接受 gzip 压缩的响应
+	Transport    http.RoundTripper // 为空时使用所有客户端共享的 Transport
+
+	// 访问密钥，为空时不签名，详见 SetAPIKey
+	KeyId     string
+	KeySecret string
+}
+
+// 服务器返回的错误，包括 HTTP 状态码不是 200 和 ErrCode 不是 ErrCodeSuccess
+// 可以用 errors.As 和 TransportError 区分，也可以用 errors.Is 判断 gmodel 的错误类型
+type APIError struct {
+	StatusCode int
+	ErrCode    int
+	ErrMsg     string
+}
+
+func (this *APIError) Error() string {
+	return this.ErrMsg
+}
+
+// 返回 ErrCode 对应的 gmodel 错误类型，可以用 errors.Is(err, gmodel.ErrNotFound) 判断
+func (this *APIError) Unwrap() error {
+	return errorKinds[this.ErrCode]
+}
+
+// 没有收到服务器的响应，比如连接失败、超时、context 被取消
+type TransportError struct {
+	Err error
+}
+
+func (this *TransportError) Error() string {
+	return "gmodel: request failed: " + this.Err.Error()
+}
+
+func (this *TransportError) Unwrap() error {
+	return this.Err
+}
+
+func newAPIError(errCode int, errMsg string) *APIError {
+	return &APIError{StatusCode: http.StatusOK, ErrCode: errCode, ErrMsg: errMsg}
+}
+
 type APIClient struct {
 	// APIServer的地址，需要带协议，比如：http://127.0.0.1:9999
 	remoteAddr string
+
+	// 访问密钥，为空时不签名
+	keyId     string
+	keySecret string
+
+	httpClient   *http.Client
+	maxRetries   int
+	retryBackoff time.Duration
+	disableGzip  bool
+
+	// 详见 WithContext
+	ctx context.Context
+}
+
+// opts 为nil时使用默认配置
+func NewAPIClient(remoteAddr string, opts *APIClientOptions) *APIClient {
+	if opts == nil {
+		opts = &APIClientOptions{}
+	}
+
+	transport := opts.Transport
+	if transport == nil {
+		transport = sharedTransport
+	}
+	timeout := durationOrDefault(opts.Timeout, DefaultClientTimeout)
+	if timeout < 0 {
+		timeout = 0
+	}
+	maxRetries := opts.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = DefaultMaxRetries
+	} else if maxRetries < 0 {
+		maxRetries = 0
+	}
+
+	return &APIClient{
+		remoteAddr:   remoteAddr,
+		keyId:        opts.KeyId,
+		keySecret:    opts.KeySecret,
+		httpClient:   &http.Client{Transport: transport, Timeout: timeout},
+		maxRetries:   maxRetries,
+		retryBackoff: durationOrDefault(opts.RetryBackoff, DefaultRetryBackoff),
+		disableGzip:  opts.DisableGzip,
+		ctx:          context.Background(),
+	}
 }
 
+// 使用默认配置，详见 NewAPIClient
 func (this *APIClient) Start(remoteAddr string) {
-	this.remoteAddr = remoteAddr
+	keyId, keySecret := this.keyId, this.keySecret
+	*this = *NewAPIClient(remoteAddr, nil)
+	this.SetAPIKey(keyId, keySecret)
+}
+
+// 设置访问密钥，之后的请求都会自动签名，详见 auth.go
+func (this *APIClient) SetAPIKey(keyId, secret string) {
+	this.keyId = keyId
+	this.keySecret = secret
+}
+
+// 返回使用 ctx 的客户端，和原来的客户端共享连接，用于单次调用的取消和超时：
+//
+//	article, err := client.WithContext(ctx).GetArticle(0, "custom_id")
+func (this *APIClient) WithContext(ctx context.Context) *APIClient {
+	if ctx == nil {
+		panic("nil context")
+	}
+	client := *this
+	client.ctx = ctx
+	return &client
 }
 
 func (this *APIClient) getAPIAddr(api string) string {
 	return this.remoteAddr + api
 }
 
-// 包装POST请求
-func (this *APIClient) post(url string, body io.Reader) ([]byte, error) {
-	client := &http.Client{}
+// 包装POST请求，只读接口（RoleReader）在连接失败和服务器暂时不可用时按指数退避重试
+// 其他接口不是幂等的，不重试
+func (this *APIClient) post(api string, body io.Reader) ([]byte, error) {
+	// 签名和重试需要请求体
+	var bodyBytes []byte
+	if body != nil {
+		var err error
+		if bodyBytes, err = io.ReadAll(body); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx := this.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	httpClient := this.httpClient
+	if httpClient == nil {
+		httpClient = &http.Client{Transport: sharedTransport, Timeout: DefaultClientTimeout}
+	}
+
+	maxRetries := 0
+	if role, ok := apiRoles[api]; ok && role == RoleReader {
+		maxRetries = this.maxRetries
+	}
+
+	backoff := this.retryBackoff
+	for attempt := 0; ; attempt++ {
+		respBytes, retryable, err := this.doPost(ctx, httpClient, api, bodyBytes)
+		if err == nil || !retryable || attempt >= maxRetries {
+			return respBytes, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, &TransportError{Err: ctx.Err()}
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
 
-	req, err := http.NewRequest("POST", url, body)
+// 发送一次请求，返回响应和是否可以重试
+func (this *APIClient) doPost(ctx context.Context, httpClient *http.Client, api string, bodyBytes []byte) ([]byte, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", this.getAPIAddr(api), bytes.NewReader(bodyBytes))
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	req.Header.Set("Content-Type", "application/json;charset=utf-8")
+	// 手动设置之后 Transport 不会自动解压，下面自己解压
+	if this.disableGzip {
+		req.Header.Set("Accept-Encoding", "identity")
+	} else {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+	if this.keyId != "" {
+		// 每次重试都重新签名，否则随机数重复会被拒绝
+		setRequestSignature(req, this.keyId, this.keySecret, bodyBytes)
+	}
 
-	resp, err := client.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, ctx.Err() == nil, &TransportError{Err: err}
 	}
 	defer resp.Body.Close()
 
-	respBytes, err := ioutil.ReadAll(resp.Body)
-	return respBytes, err
+	var reader io.Reader = resp.Body
+	if resp.Header.Get("Content-Encoding") == "gzip" {
+		gzipReader, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, ctx.Err() == nil, &TransportError{Err: err}
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+
+	respBytes, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, ctx.Err() == nil, &TransportError{Err: err}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &APIError{StatusCode: resp.StatusCode, ErrCode: ErrCodeFailed, ErrMsg: fmt.Sprintf("HTTP %v %v", resp.StatusCode, http.StatusText(resp.StatusCode))}
+		baseResp := &BaseResp{}
+		if json.Unmarshal(respBytes, baseResp) == nil && baseResp.ErrCode != ErrCodeSuccess {
+			apiErr.ErrCode = baseResp.ErrCode
+			apiErr.ErrMsg = baseResp.ErrMsg
+		}
+		return nil, isRetryableStatus(resp.StatusCode), apiErr
+	}
+
+	return respBytes, false, nil
+}
+
+// 服务器暂时不可用，可以重试
+func isRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
 }
 
 // 返回文章数量、分类数量、最大的文章ID
 func (this *APIClient) GetModelInfo() (uint64, uint64, uint64, error) {
-	respBytes, err := this.post(this.getAPIAddr(APIGetModelInfo), nil)
+	respBytes, err := this.post(APIGetModelInfo, nil)
 	if err != nil {
 		return 0, 0, 0, err
 	}
@@ -55,7 +277,7 @@ func (this *APIClient) GetModelInfo() (uint64, uint64, uint64, error) {
 	}
 
 	if resp.ErrCode != ErrCodeSuccess {
-		return 0, 0, 0, errors.New(resp.ErrMsg)
+		return 0, 0, 0, newAPIError(resp.ErrCode, resp.ErrMsg)
 	}
 
 	return resp.ArticleCount, resp.TagCount, resp.MaxArticleId, nil
@@ -79,14 +301,20 @@ func (this *APIClient) GetMaxArticleId() uint64 {
 // customArticleId 可以为空，如果为空，则服务器自动生成
 // 返回：文章ID、自定义文章ID
 func (this *APIClient) AddArticle(tags []string, data string, customArticleId string) (uint64, string, error) {
+	return this.AddArticleWithTerms(tags, nil, data, customArticleId)
+}
+
+// terms 为其他分类法的分类，key为分类法名称，分类法需要在服务器配置中打开
+func (this *APIClient) AddArticleWithTerms(tags []string, terms map[string][]string, data string, customArticleId string) (uint64, string, error) {
 	req := &AddArticleReq{
 		Tags:            tags,
+		Terms:           terms,
 		Data:            data,
 		CustomArticleId: customArticleId,
 	}
 	reqBytes, _ := json.Marshal(req)
 
-	respBytes, err := this.post(this.getAPIAddr(APIAddArticle), bytes.NewBuffer(reqBytes))
+	respBytes, err := this.post(APIAddArticle, bytes.NewBuffer(reqBytes))
 	if err != nil {
 		return 0, "", err
 	}
@@ -97,12 +325,53 @@ func (this *APIClient) AddArticle(tags []string, data string, customArticleId st
 	}
 
 	if resp.ErrCode != ErrCodeSuccess {
-		return 0, "", errors.New(resp.ErrMsg)
+		return 0, "", newAPIError(resp.ErrCode, resp.ErrMsg)
 	}
 
 	return resp.ArticleId, resp.CustomArticleId, nil
 }
 
+// 按自定义文章ID增加或者修改文章，customArticleId 不能为空，内容没有变化时不修改
+// 返回：文章ID、结果（增加、修改、没有变化）
+func (this *APIClient) UpsertArticle(tags []string, terms map[string][]string, data string, customArticleId string) (uint64, gmodel.UpsertResult, error) {
+	req := &UpsertArticleReq{
+		Tags:            tags,
+		Terms:           terms,
+		Data:            data,
+		CustomArticleId: customArticleId,
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	respBytes, err := this.post(APIUpsertArticle, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	resp := &UpsertArticleResp{}
+	if err = json.Unmarshal(respBytes, resp); err != nil {
+		return 0, 0, err
+	}
+
+	if resp.ErrCode != ErrCodeSuccess {
+		return 0, 0, newAPIError(resp.ErrCode, resp.ErrMsg)
+	}
+
+	result, err := parseUpsertResult(resp.Result)
+	if err != nil {
+		return 0, 0, err
+	}
+	return resp.ArticleId, result, nil
+}
+
+func parseUpsertResult(result string) (gmodel.UpsertResult, error) {
+	for _, r := range []gmodel.UpsertResult{gmodel.UpsertCreated, gmodel.UpsertUpdated, gmodel.UpsertUnchanged} {
+		if r.String() == result {
+			return r, nil
+		}
+	}
+	return 0, errors.New(fmt.Sprintf("Unknown upsert result [%v]", result))
+}
+
 // 文章ID随便填一个，customArticleId 优先
 func (this *APIClient) DeleteArticle(articleId uint64, customArticleId string) error {
 	req := &DeleteArticleReq{
@@ -111,7 +380,7 @@ func (this *APIClient) DeleteArticle(articleId uint64, customArticleId string) e
 	}
 	reqBytes, _ := json.Marshal(req)
 
-	respBytes, err := this.post(this.getAPIAddr(APIDeleteArticle), bytes.NewBuffer(reqBytes))
+	respBytes, err := this.post(APIDeleteArticle, bytes.NewBuffer(reqBytes))
 	if err != nil {
 		return err
 	}
@@ -122,7 +391,7 @@ func (this *APIClient) DeleteArticle(articleId uint64, customArticleId string) e
 	}
 
 	if resp.ErrCode != ErrCodeSuccess {
-		return errors.New(resp.ErrMsg)
+		return newAPIError(resp.ErrCode, resp.ErrMsg)
 	}
 
 	return nil
@@ -135,7 +404,7 @@ func (this *APIClient) GetArticle(articleId uint64, customArticleId string) (*Re
 	}
 	reqBytes, _ := json.Marshal(req)
 
-	respBytes, err := this.post(this.getAPIAddr(APIGetArticle), bytes.NewBuffer(reqBytes))
+	respBytes, err := this.post(APIGetArticle, bytes.NewBuffer(reqBytes))
 	if err != nil {
 		return nil, err
 	}
@@ -146,12 +415,13 @@ func (this *APIClient) GetArticle(articleId uint64, customArticleId string) (*Re
 	}
 
 	if resp.ErrCode != ErrCodeSuccess {
-		return nil, errors.New(resp.ErrMsg)
+		return nil, newAPIError(resp.ErrCode, resp.ErrMsg)
 	}
 
 	return resp.RemoteArticle, nil
 }
 
+// Deprecated: 使用 ListArticles 代替
 func (this *APIClient) GetNextArticles(articleId uint64, customArticleId string, n int) []*RemoteArticle {
 	req := &GetNextArticlesReq{
 		ArticleId:       articleId,
@@ -160,7 +430,7 @@ func (this *APIClient) GetNextArticles(articleId uint64, customArticleId string,
 	}
 	reqBytes, _ := json.Marshal(req)
 
-	respBytes, err := this.post(this.getAPIAddr(APIGetNextArticles), bytes.NewBuffer(reqBytes))
+	respBytes, err := this.post(APIGetNextArticles, bytes.NewBuffer(reqBytes))
 	if err != nil {
 		return []*RemoteArticle{}
 	}
@@ -177,6 +447,7 @@ func (this *APIClient) GetNextArticles(articleId uint64, customArticleId string,
 	return resp.RemoteArticles
 }
 
+// Deprecated: 使用 ListArticles 代替
 func (this *APIClient) GetPrevArticles(articleId uint64, customArticleId string, n int) []*RemoteArticle {
 	req := &GetPrevArticlesReq{
 		ArticleId:       articleId,
@@ -185,7 +456,7 @@ func (this *APIClient) GetPrevArticles(articleId uint64, customArticleId string,
 	}
 	reqBytes, _ := json.Marshal(req)
 
-	respBytes, err := this.post(this.getAPIAddr(APIGetPrevArticles), bytes.NewBuffer(reqBytes))
+	respBytes, err := this.post(APIGetPrevArticles, bytes.NewBuffer(reqBytes))
 	if err != nil {
 		return []*RemoteArticle{}
 	}
@@ -202,6 +473,7 @@ func (this *APIClient) GetPrevArticles(articleId uint64, customArticleId string,
 	return resp.RemoteArticles
 }
 
+// Deprecated: 使用 ListArticlesByTag 代替
 func (this *APIClient) GetNextArticlesByTag(tagName string, articleId uint64, customArticleId string, n int) []*RemoteArticle {
 	req := &GetNextArticlesByTagReq{}
 	req.ArticleId = articleId
@@ -210,7 +482,7 @@ func (this *APIClient) GetNextArticlesByTag(tagName string, articleId uint64, cu
 	req.Tag = tagName
 	reqBytes, _ := json.Marshal(req)
 
-	respBytes, err := this.post(this.getAPIAddr(APIGetNextArticlesByTag), bytes.NewBuffer(reqBytes))
+	respBytes, err := this.post(APIGetNextArticlesByTag, bytes.NewBuffer(reqBytes))
 	if err != nil {
 		return []*RemoteArticle{}
 	}
@@ -227,6 +499,7 @@ func (this *APIClient) GetNextArticlesByTag(tagName string, articleId uint64, cu
 	return resp.RemoteArticles
 }
 
+// Deprecated: 使用 ListArticlesByTag 代替
 func (this *APIClient) GetPrevArticlesByTag(tagName string, articleId uint64, customArticleId string, n int) []*RemoteArticle {
 	req := &GetPrevArticlesByTagReq{}
 	req.ArticleId = articleId
@@ -235,7 +508,7 @@ func (this *APIClient) GetPrevArticlesByTag(tagName string, articleId uint64, cu
 	req.Tag = tagName
 	reqBytes, _ := json.Marshal(req)
 
-	respBytes, err := this.post(this.getAPIAddr(APIGetPrevArticlesByTag), bytes.NewBuffer(reqBytes))
+	respBytes, err := this.post(APIGetPrevArticlesByTag, bytes.NewBuffer(reqBytes))
 	if err != nil {
 		return []*RemoteArticle{}
 	}
@@ -253,15 +526,21 @@ func (this *APIClient) GetPrevArticlesByTag(tagName string, articleId uint64, cu
 }
 
 func (this *APIClient) UpdateArticle(articleId uint64, customArticleId string, newTags []string, newData string) error {
+	return this.UpdateArticleWithTerms(articleId, customArticleId, newTags, nil, newData)
+}
+
+// newTerms 中没有出现的分类法保持不变
+func (this *APIClient) UpdateArticleWithTerms(articleId uint64, customArticleId string, newTags []string, newTerms map[string][]string, newData string) error {
 	req := &UpdateArticleReq{
 		ArticleId:       articleId,
 		CustomArticleId: customArticleId,
 		NewTags:         newTags,
+		NewTerms:        newTerms,
 		NewData:         newData,
 	}
 	reqBytes, _ := json.Marshal(req)
 
-	respBytes, err := this.post(this.getAPIAddr(APIUpdateArticle), bytes.NewBuffer(reqBytes))
+	respBytes, err := this.post(APIUpdateArticle, bytes.NewBuffer(reqBytes))
 	if err != nil {
 		return err
 	}
@@ -272,7 +551,7 @@ func (this *APIClient) UpdateArticle(articleId uint64, customArticleId string, n
 	}
 
 	if resp.ErrCode != ErrCodeSuccess {
-		return errors.New(resp.ErrMsg)
+		return newAPIError(resp.ErrCode, resp.ErrMsg)
 	}
 
 	return nil
@@ -284,7 +563,7 @@ func (this *APIClient) GetTagById(id uint64) (*RemoteTag, error) {
 	}
 	reqBytes, _ := json.Marshal(req)
 
-	respBytes, err := this.post(this.getAPIAddr(APIGetTagById), bytes.NewBuffer(reqBytes))
+	respBytes, err := this.post(APIGetTagById, bytes.NewBuffer(reqBytes))
 	if err != nil {
 		return nil, err
 	}
@@ -295,7 +574,7 @@ func (this *APIClient) GetTagById(id uint64) (*RemoteTag, error) {
 	}
 
 	if resp.ErrCode != ErrCodeSuccess {
-		return nil, errors.New(resp.ErrMsg)
+		return nil, newAPIError(resp.ErrCode, resp.ErrMsg)
 	}
 
 	return resp.RemoteTag, nil
@@ -307,7 +586,7 @@ func (this *APIClient) GetTagByName(name string) (*RemoteTag, error) {
 	}
 	reqBytes, _ := json.Marshal(req)
 
-	respBytes, err := this.post(this.getAPIAddr(APIGetTagByName), bytes.NewBuffer(reqBytes))
+	respBytes, err := this.post(APIGetTagByName, bytes.NewBuffer(reqBytes))
 	if err != nil {
 		return nil, err
 	}
@@ -318,12 +597,13 @@ func (this *APIClient) GetTagByName(name string) (*RemoteTag, error) {
 	}
 
 	if resp.ErrCode != ErrCodeSuccess {
-		return nil, errors.New(resp.ErrMsg)
+		return nil, newAPIError(resp.ErrCode, resp.ErrMsg)
 	}
 
 	return resp.RemoteTag, nil
 }
 
+// Deprecated: 使用 ListTags 代替
 func (this *APIClient) GetNextTags(tagName string, n int) []*RemoteTag {
 	req := &GetNextTagsReq{
 		TagName: tagName,
@@ -331,7 +611,7 @@ func (this *APIClient) GetNextTags(tagName string, n int) []*RemoteTag {
 	}
 	reqBytes, _ := json.Marshal(req)
 
-	respBytes, err := this.post(this.getAPIAddr(APIGetNextTags), bytes.NewBuffer(reqBytes))
+	respBytes, err := this.post(APIGetNextTags, bytes.NewBuffer(reqBytes))
 	if err != nil {
 		return []*RemoteTag{}
 	}
@@ -348,6 +628,7 @@ func (this *APIClient) GetNextTags(tagName string, n int) []*RemoteTag {
 	return resp.RemoteTags
 }
 
+// Deprecated: 使用 ListTags 代替
 func (this *APIClient) GetPrevTags(tagName string, n int) []*RemoteTag {
 	req := &GetPrevTagsReq{
 		TagName: tagName,
@@ -355,7 +636,7 @@ func (this *APIClient) GetPrevTags(tagName string, n int) []*RemoteTag {
 	}
 	reqBytes, _ := json.Marshal(req)
 
-	respBytes, err := this.post(this.getAPIAddr(APIGetPrevTags), bytes.NewBuffer(reqBytes))
+	respBytes, err := this.post(APIGetPrevTags, bytes.NewBuffer(reqBytes))
 	if err != nil {
 		return []*RemoteTag{}
 	}
@@ -379,7 +660,7 @@ func (this *APIClient) RenameTag(oldName, newName string) error {
 	}
 	reqBytes, _ := json.Marshal(req)
 
-	respBytes, err := this.post(this.getAPIAddr(APIRenameTag), bytes.NewBuffer(reqBytes))
+	respBytes, err := this.post(APIRenameTag, bytes.NewBuffer(reqBytes))
 	if err != nil {
 		return err
 	}
@@ -390,7 +671,7 @@ func (this *APIClient) RenameTag(oldName, newName string) error {
 	}
 
 	if resp.ErrCode != ErrCodeSuccess {
-		return errors.New(resp.ErrMsg)
+		return newAPIError(resp.ErrCode, resp.ErrMsg)
 	}
 
 	return nil
@@ -402,7 +683,7 @@ func (this *APIClient) GetArticleCountByTag(tagName string) uint64 {
 	}
 	reqBytes, _ := json.Marshal(req)
 
-	respBytes, err := this.post(this.getAPIAddr(APIGetArticleCountByTag), bytes.NewBuffer(reqBytes))
+	respBytes, err := this.post(APIGetArticleCountByTag, bytes.NewBuffer(reqBytes))
 	if err != nil {
 		return 0
 	}
@@ -418,3 +699,646 @@ func (this *APIClient) GetArticleCountByTag(tagName string) uint64 {
 
 	return resp.ArticleCount
 }
+
+func (this *APIClient) GetTagBySlug(slug string) (*RemoteTag, error) {
+	req := &GetTagBySlugReq{
+		Slug: slug,
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	respBytes, err := this.post(APIGetTagBySlug, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &GetTagBySlugResp{}
+	if err = json.Unmarshal(respBytes, resp); err != nil {
+		return nil, err
+	}
+
+	if resp.ErrCode != ErrCodeSuccess {
+		return nil, newAPIError(resp.ErrCode, resp.ErrMsg)
+	}
+
+	return resp.RemoteTag, nil
+}
+
+// 会覆盖旧的附加信息
+func (this *APIClient) UpdateTagMeta(name string, meta *gmodel.TagMeta) error {
+	req := &UpdateTagMetaReq{
+		TagName:     name,
+		Slug:        meta.Slug,
+		Description: meta.Description,
+		Aliases:     meta.Aliases,
+		Attrs:       meta.Attrs,
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	respBytes, err := this.post(APIUpdateTagMeta, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return err
+	}
+
+	resp := &UpdateTagMetaResp{}
+	if err = json.Unmarshal(respBytes, resp); err != nil {
+		return err
+	}
+
+	if resp.ErrCode != ErrCodeSuccess {
+		return newAPIError(resp.ErrCode, resp.ErrMsg)
+	}
+
+	return nil
+}
+
+// 按文章数从多到少的顺序返回分类，tagName 为空表示获取文章数最多的N个分类
+// 否则返回 tagName 之后的N个分类，用于分页
+//
+// Deprecated: 使用 ListTopTags 代替
+func (this *APIClient) GetTopTags(tagName string, n int) []*RemoteTag {
+	req := &GetTopTagsReq{
+		TagName: tagName,
+		N:       n,
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	respBytes, err := this.post(APIGetTopTags, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return []*RemoteTag{}
+	}
+
+	resp := &GetTopTagsResp{}
+	if err = json.Unmarshal(respBytes, resp); err != nil {
+		return []*RemoteTag{}
+	}
+
+	if resp.ErrCode != ErrCodeSuccess {
+		return []*RemoteTag{}
+	}
+
+	return resp.RemoteTags
+}
+
+// 按名称前缀查找分类，ignoreCase 为 true 时忽略大小写，游标分页详见 ListArticles
+func (this *APIClient) SearchTags(prefix string, cursor string, n int, ignoreCase bool) (*gmodel.Page[*RemoteTag], error) {
+	req := &SearchTagsReq{
+		Prefix:     prefix,
+		Cursor:     cursor,
+		N:          n,
+		IgnoreCase: ignoreCase,
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	respBytes, err := this.post(APISearchTags, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &SearchTagsResp{}
+	if err = json.Unmarshal(respBytes, resp); err != nil {
+		return nil, err
+	}
+
+	if resp.ErrCode != ErrCodeSuccess {
+		return nil, newAPIError(resp.ErrCode, resp.ErrMsg)
+	}
+
+	return &resp.Page, nil
+}
+
+// Deprecated: 使用 ListArticlesByTag 代替
+func (this *APIClient) GetNextArticlesByTerm(taxonomy string, term string, articleId uint64, customArticleId string, n int) []*RemoteArticle {
+	req := &GetNextArticlesByTermReq{}
+	req.ArticleId = articleId
+	req.CustomArticleId = customArticleId
+	req.N = n
+	req.Taxonomy = taxonomy
+	req.Term = term
+	reqBytes, _ := json.Marshal(req)
+
+	respBytes, err := this.post(APIGetNextArticlesByTerm, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return []*RemoteArticle{}
+	}
+
+	resp := &GetNextArticlesByTermResp{}
+	if err = json.Unmarshal(respBytes, resp); err != nil {
+		return []*RemoteArticle{}
+	}
+
+	if resp.ErrCode != ErrCodeSuccess {
+		return []*RemoteArticle{}
+	}
+
+	return resp.RemoteArticles
+}
+
+// Deprecated: 使用 ListArticlesByTag 代替
+func (this *APIClient) GetPrevArticlesByTerm(taxonomy string, term string, articleId uint64, customArticleId string, n int) []*RemoteArticle {
+	req := &GetPrevArticlesByTermReq{}
+	req.ArticleId = articleId
+	req.CustomArticleId = customArticleId
+	req.N = n
+	req.Taxonomy = taxonomy
+	req.Term = term
+	reqBytes, _ := json.Marshal(req)
+
+	respBytes, err := this.post(APIGetPrevArticlesByTerm, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return []*RemoteArticle{}
+	}
+
+	resp := &GetPrevArticlesByTermResp{}
+	if err = json.Unmarshal(respBytes, resp); err != nil {
+		return []*RemoteArticle{}
+	}
+
+	if resp.ErrCode != ErrCodeSuccess {
+		return []*RemoteArticle{}
+	}
+
+	return resp.RemoteArticles
+}
+
+// 按页码获取指定分类下的文章，page从1开始，order 为 "asc" 或者 "desc"，默认为 "desc"
+// taxonomy 为空表示按分类查找，否则表示按指定分类法的分类查找
+func (this *APIClient) GetArticlesByTagPage(taxonomy string, tagName string, page uint64, size int, order string) (*RemoteArticlePage, error) {
+	req := &GetArticlesByTagPageReq{
+		Taxonomy: taxonomy,
+		Tag:      tagName,
+		Page:     page,
+		Size:     size,
+		Order:    order,
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	respBytes, err := this.post(APIGetArticlesByTagPage, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &GetArticlesByTagPageResp{}
+	if err = json.Unmarshal(respBytes, resp); err != nil {
+		return nil, err
+	}
+
+	if resp.ErrCode != ErrCodeSuccess {
+		return nil, newAPIError(resp.ErrCode, resp.ErrMsg)
+	}
+
+	return resp.RemoteArticlePage, nil
+}
+
+// 游标分页，cursor 为空表示第一页，下一页、上一页传入返回的 NextCursor、PrevCursor
+// order 为 "asc" 或者 "desc"，默认为 "desc"，翻页时需要保持不变
+func (this *APIClient) ListArticles(cursor string, n int, order string) (*gmodel.Page[*RemoteArticle], error) {
+	req := &ListArticlesReq{
+		Cursor: cursor,
+		N:      n,
+		Order:  order,
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	respBytes, err := this.post(APIListArticles, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &ListArticlesResp{}
+	if err = json.Unmarshal(respBytes, resp); err != nil {
+		return nil, err
+	}
+
+	if resp.ErrCode != ErrCodeSuccess {
+		return nil, newAPIError(resp.ErrCode, resp.ErrMsg)
+	}
+
+	return &resp.Page, nil
+}
+
+// taxonomy 为空表示按分类查找，否则表示按指定分类法的分类查找，详见 ListArticles
+func (this *APIClient) ListArticlesByTag(taxonomy string, tagName string, cursor string, n int, order string) (*gmodel.Page[*RemoteArticle], error) {
+	req := &ListArticlesByTagReq{}
+	req.Cursor = cursor
+	req.N = n
+	req.Order = order
+	req.Taxonomy = taxonomy
+	req.Tag = tagName
+	reqBytes, _ := json.Marshal(req)
+
+	respBytes, err := this.post(APIListArticlesByTag, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &ListArticlesByTagResp{}
+	if err = json.Unmarshal(respBytes, resp); err != nil {
+		return nil, err
+	}
+
+	if resp.ErrCode != ErrCodeSuccess {
+		return nil, newAPIError(resp.ErrCode, resp.ErrMsg)
+	}
+
+	return &resp.Page, nil
+}
+
+// 按分类ID分页，详见 ListArticles
+func (this *APIClient) ListTags(cursor string, n int, order string) (*gmodel.Page[*RemoteTag], error) {
+	req := &ListTagsReq{
+		Cursor: cursor,
+		N:      n,
+		Order:  order,
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	respBytes, err := this.post(APIListTags, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &ListTagsResp{}
+	if err = json.Unmarshal(respBytes, resp); err != nil {
+		return nil, err
+	}
+
+	if resp.ErrCode != ErrCodeSuccess {
+		return nil, newAPIError(resp.ErrCode, resp.ErrMsg)
+	}
+
+	return &resp.Page, nil
+}
+
+// 按文章数从多到少的顺序分页，详见 ListArticles
+func (this *APIClient) ListTopTags(cursor string, n int) (*gmodel.Page[*RemoteTag], error) {
+	req := &ListTopTagsReq{
+		Cursor: cursor,
+		N:      n,
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	respBytes, err := this.post(APIListTopTags, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &ListTopTagsResp{}
+	if err = json.Unmarshal(respBytes, resp); err != nil {
+		return nil, err
+	}
+
+	if resp.ErrCode != ErrCodeSuccess {
+		return nil, newAPIError(resp.ErrCode, resp.ErrMsg)
+	}
+
+	return &resp.Page, nil
+}
+
+// 批量获取文章，ids 中每一项都可以用 ArticleId 或者 CustomArticleId，最多 MaxGetArticlesCount 项
+// 返回的文章和错误都和 ids 一一对应，获取失败时文章为nil，最后一个返回值表示整个请求是否失败
+func (this *APIClient) GetArticles(ids []GetArticleReq) ([]*RemoteArticle, []error, error) {
+	req := &GetArticlesReq{
+		Ids: ids,
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	respBytes, err := this.post(APIGetArticles, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp := &GetArticlesResp{}
+	if err = json.Unmarshal(respBytes, resp); err != nil {
+		return nil, nil, err
+	}
+
+	if resp.ErrCode != ErrCodeSuccess {
+		return nil, nil, newAPIError(resp.ErrCode, resp.ErrMsg)
+	}
+
+	articles := make([]*RemoteArticle, len(resp.Results))
+	errs := make([]error, len(resp.Results))
+	for i, result := range resp.Results {
+		if result.ErrCode != ErrCodeSuccess {
+			errs[i] = newAPIError(result.ErrCode, result.ErrMsg)
+		} else {
+			articles[i] = result.RemoteArticle
+		}
+	}
+
+	return articles, errs, nil
+}
+
+// 批量增加文章，每一项的参数同 AddArticleWithTerms，最多 MaxAddArticlesCount 项
+// 返回的结果和 articles 一一对应，每一项都有自己的 ErrCode，最后一个返回值表示整个请求是否失败
+func (this *APIClient) AddArticles(articles []AddArticleReq) ([]*AddArticlesResult, error) {
+	req := &AddArticlesReq{
+		Articles: articles,
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	respBytes, err := this.post(APIAddArticles, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &AddArticlesResp{}
+	if err = json.Unmarshal(respBytes, resp); err != nil {
+		return nil, err
+	}
+
+	if resp.ErrCode != ErrCodeSuccess {
+		return nil, newAPIError(resp.ErrCode, resp.ErrMsg)
+	}
+
+	return resp.Results, nil
+}
+
+// 修改文章的自定义文章ID，旧的自定义文章ID在一段时间内重定向到新的，返回的重定向为nil表示没有保留
+func (this *APIClient) UpdateCustomId(articleId uint64, customArticleId string, newCustomArticleId string) (*gmodel.IdRedirect, error) {
+	req := &UpdateCustomIdReq{
+		ArticleId:          articleId,
+		CustomArticleId:    customArticleId,
+		NewCustomArticleId: newCustomArticleId,
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	respBytes, err := this.post(APIUpdateCustomId, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &UpdateCustomIdResp{}
+	if err = json.Unmarshal(respBytes, resp); err != nil {
+		return nil, err
+	}
+
+	if resp.ErrCode != ErrCodeSuccess {
+		return nil, newAPIError(resp.ErrCode, resp.ErrMsg)
+	}
+
+	if resp.RedirectFrom == "" {
+		return nil, nil
+	}
+	return &gmodel.IdRedirect{
+		From:     resp.RedirectFrom,
+		To:       resp.CustomArticleId,
+		IntId:    resp.ArticleId,
+		ExpireAt: time.Unix(resp.RedirectExpireAt, 0),
+	}, nil
+}
+
+// 增加分类，不需要先有文章，taxonomy 为空表示分类，否则表示指定分类法的分类，下同
+func (this *APIClient) AddTag(taxonomy string, name string) (*RemoteTag, error) {
+	req := &AddTagReq{
+		Taxonomy: taxonomy,
+		TagName:  name,
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	respBytes, err := this.post(APIAddTag, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &AddTagResp{}
+	if err = json.Unmarshal(respBytes, resp); err != nil {
+		return nil, err
+	}
+
+	if resp.ErrCode != ErrCodeSuccess {
+		return nil, newAPIError(resp.ErrCode, resp.ErrMsg)
+	}
+
+	return resp.RemoteTag, nil
+}
+
+// 删除分类，分类下的文章不会删除，只是不再属于该分类
+func (this *APIClient) DeleteTag(taxonomy string, name string) error {
+	req := &DeleteTagReq{
+		Taxonomy: taxonomy,
+		TagName:  name,
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	respBytes, err := this.post(APIDeleteTag, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return err
+	}
+
+	resp := &DeleteTagResp{}
+	if err = json.Unmarshal(respBytes, resp); err != nil {
+		return err
+	}
+
+	if resp.ErrCode != ErrCodeSuccess {
+		return newAPIError(resp.ErrCode, resp.ErrMsg)
+	}
+
+	return nil
+}
+
+// 返回分类数量，和 GetTagCount 不同，可以指定分类法，并且返回错误
+func (this *APIClient) GetTermCount(taxonomy string) (uint64, error) {
+	req := &GetTagCountReq{
+		Taxonomy: taxonomy,
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	respBytes, err := this.post(APIGetTagCount, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return 0, err
+	}
+
+	resp := &GetTagCountResp{}
+	if err = json.Unmarshal(respBytes, resp); err != nil {
+		return 0, err
+	}
+
+	if resp.ErrCode != ErrCodeSuccess {
+		return 0, newAPIError(resp.ErrCode, resp.ErrMsg)
+	}
+
+	return resp.TagCount, nil
+}
+
+// 返回文章的自定义文章ID
+func (this *APIClient) GetCustomId(articleId uint64) (string, error) {
+	req := &GetCustomIdReq{
+		ArticleId: articleId,
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	respBytes, err := this.post(APIGetCustomId, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return "", err
+	}
+
+	resp := &GetCustomIdResp{}
+	if err = json.Unmarshal(respBytes, resp); err != nil {
+		return "", err
+	}
+
+	if resp.ErrCode != ErrCodeSuccess {
+		return "", newAPIError(resp.ErrCode, resp.ErrMsg)
+	}
+
+	return resp.CustomArticleId, nil
+}
+
+// 返回自定义文章ID对应的文章ID和当前的自定义文章ID，customArticleId 是旧的自定义文章ID时两者不同
+func (this *APIClient) ResolveCustomId(customArticleId string) (uint64, string, error) {
+	req := &ResolveCustomIdReq{
+		CustomArticleId: customArticleId,
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	respBytes, err := this.post(APIResolveCustomId, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return 0, "", err
+	}
+
+	resp := &ResolveCustomIdResp{}
+	if err = json.Unmarshal(respBytes, resp); err != nil {
+		return 0, "", err
+	}
+
+	if resp.ErrCode != ErrCodeSuccess {
+		return 0, "", newAPIError(resp.ErrCode, resp.ErrMsg)
+	}
+
+	return resp.ArticleId, resp.CustomArticleId, nil
+}
+
+// 给还没有自定义文章ID的文章设置自定义文章ID，修改使用 UpdateCustomId
+func (this *APIClient) SetCustomId(articleId uint64, customArticleId string) error {
+	req := &SetCustomIdReq{
+		ArticleId:       articleId,
+		CustomArticleId: customArticleId,
+	}
+	reqBytes, _ := json.Marshal(req)
+
+	respBytes, err := this.post(APISetCustomId, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return err
+	}
+
+	resp := &SetCustomIdResp{}
+	if err = json.Unmarshal(respBytes, resp); err != nil {
+		return err
+	}
+
+	if resp.ErrCode != ErrCodeSuccess {
+		return newAPIError(resp.ErrCode, resp.ErrMsg)
+	}
+
+	return nil
+}
+
+// 以下为带 context 的版本，实现 Model，详见 WithContext
+
+func (this *APIClient) GetModelInfoContext(ctx context.Context) (uint64, uint64, uint64, error) {
+	return this.WithContext(ctx).GetModelInfo()
+}
+
+func (this *APIClient) GetArticleContext(ctx context.Context, articleId uint64, customArticleId string) (*RemoteArticle, error) {
+	return this.WithContext(ctx).GetArticle(articleId, customArticleId)
+}
+
+func (this *APIClient) GetArticlesContext(ctx context.Context, ids []GetArticleReq) ([]*RemoteArticle, []error, error) {
+	return this.WithContext(ctx).GetArticles(ids)
+}
+
+func (this *APIClient) AddArticleContext(ctx context.Context, tags []string, terms map[string][]string, data string, customArticleId string) (uint64, string, error) {
+	return this.WithContext(ctx).AddArticleWithTerms(tags, terms, data, customArticleId)
+}
+
+func (this *APIClient) AddArticlesContext(ctx context.Context, articles []AddArticleReq) ([]uint64, []string, []error, error) {
+	results, err := this.WithContext(ctx).AddArticles(articles)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	articleIds := make([]uint64, len(results))
+	customArticleIds := make([]string, len(results))
+	errs := make([]error, len(results))
+	for i, result := range results {
+		articleIds[i], customArticleIds[i] = result.ArticleId, result.CustomArticleId
+		if result.ErrCode != ErrCodeSuccess {
+			errs[i] = newAPIError(result.ErrCode, result.ErrMsg)
+		}
+	}
+	return articleIds, customArticleIds, errs, nil
+}
+
+func (this *APIClient) UpdateArticleContext(ctx context.Context, articleId uint64, customArticleId string, newTags []string, newTerms map[string][]string, newData string) error {
+	return this.WithContext(ctx).UpdateArticleWithTerms(articleId, customArticleId, newTags, newTerms, newData)
+}
+
+func (this *APIClient) DeleteArticleContext(ctx context.Context, articleId uint64, customArticleId string) error {
+	return this.WithContext(ctx).DeleteArticle(articleId, customArticleId)
+}
+
+func (this *APIClient) ListArticlesContext(ctx context.Context, cursor string, n int, order string) (*gmodel.Page[*RemoteArticle], error) {
+	return this.WithContext(ctx).ListArticles(cursor, n, order)
+}
+
+func (this *APIClient) ListArticlesByTagContext(ctx context.Context, taxonomy string, tagName string, cursor string, n int, order string) (*gmodel.Page[*RemoteArticle], error) {
+	return this.WithContext(ctx).ListArticlesByTag(taxonomy, tagName, cursor, n, order)
+}
+
+func (this *APIClient) GetTagByNameContext(ctx context.Context, name string) (*RemoteTag, error) {
+	return this.WithContext(ctx).GetTagByName(name)
+}
+
+func (this *APIClient) GetTagBySlugContext(ctx context.Context, slug string) (*RemoteTag, error) {
+	return this.WithContext(ctx).GetTagBySlug(slug)
+}
+
+func (this *APIClient) ListTagsContext(ctx context.Context, cursor string, n int, order string) (*gmodel.Page[*RemoteTag], error) {
+	return this.WithContext(ctx).ListTags(cursor, n, order)
+}
+
+func (this *APIClient) ListTopTagsContext(ctx context.Context, cursor string, n int) (*gmodel.Page[*RemoteTag], error) {
+	return this.WithContext(ctx).ListTopTags(cursor, n)
+}
+
+func (this *APIClient) SearchTagsContext(ctx context.Context, prefix string, cursor string, n int, ignoreCase bool) (*gmodel.Page[*RemoteTag], error) {
+	return this.WithContext(ctx).SearchTags(prefix, cursor, n, ignoreCase)
+}
+
+func (this *APIClient) RenameTagContext(ctx context.Context, oldName, newName string) error {
+	return this.WithContext(ctx).RenameTag(oldName, newName)
+}
+
+func (this *APIClient) AddTagContext(ctx context.Context, taxonomy string, name string) (*RemoteTag, error) {
+	return this.WithContext(ctx).AddTag(taxonomy, name)
+}
+
+func (this *APIClient) DeleteTagContext(ctx context.Context, taxonomy string, name string) error {
+	return this.WithContext(ctx).DeleteTag(taxonomy, name)
+}
+
+func (this *APIClient) GetTermCountContext(ctx context.Context, taxonomy string) (uint64, error) {
+	return this.WithContext(ctx).GetTermCount(taxonomy)
+}
+
+func (this *APIClient) GetCustomIdContext(ctx context.Context, articleId uint64) (string, error) {
+	return this.WithContext(ctx).GetCustomId(articleId)
+}
+
+func (this *APIClient) ResolveCustomIdContext(ctx context.Context, customArticleId string) (uint64, string, error) {
+	return this.WithContext(ctx).ResolveCustomId(customArticleId)
+}
+
+func (this *APIClient) SetCustomIdContext(ctx context.Context, articleId uint64, customArticleId string) error {
+	return this.WithContext(ctx).SetCustomId(articleId, customArticleId)
+}
+
+func (this *APIClient) UpdateCustomIdContext(ctx context.Context, articleId uint64, customArticleId string, newCustomArticleId string) (*gmodel.IdRedirect, error) {
+	return this.WithContext(ctx).UpdateCustomId(articleId, customArticleId, newCustomArticleId)
+}
+
+func (this *APIClient) UpsertArticleContext(ctx context.Context, tags []string, terms map[string][]string, data string, customArticleId string) (uint64, gmodel.UpsertResult, error) {
+	return this.WithContext(ctx).UpsertArticle(tags, terms, data, customArticleId)
+}
@@ -2,10 +2,14 @@ package remote
 
 import (
 	"context"
+	"errors"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -18,6 +22,16 @@ const (
 	ErrCodeSuccess = 0
 	ErrCodeFailed  = -1
 	ErrMsgSuccess  = "success"
+
+	ErrCodeUnauthorized = -2 // 没有鉴权或者鉴权失败，HTTP状态码为401
+	ErrCodeForbidden    = -3 // 角色没有权限，HTTP状态码为403
+
+	// gmodel 的错误类型，详见 errors.go
+	ErrCodeNotFound        = -4 // gmodel.ErrNotFound，HTTP状态码为404
+	ErrCodeExists          = -5 // gmodel.ErrExists，HTTP状态码为409
+	ErrCodeInvalidArgument = -6 // gmodel.ErrInvalidArgument 和请求参数错误，HTTP状态码为400
+	ErrCodeReadOnly        = -7 // gmodel.ErrReadOnly，HTTP状态码为403
+	ErrCodeIdExhausted     = -8 // gmodel.ErrIdExhausted，HTTP状态码为503
 )
 
 type APIServerConfig struct {
@@ -31,87 +45,316 @@ type APIServerConfig struct {
 	// 如果不使用自定义ID，则随机产生一个字符串ID
 	// 这样做的原因避免ID为整数，然后导致网站被遍历抓取
 	// 用自定义ID还有一个好处，比如将文章的标题作为文章的自定义ID，自带去重效果
+	// 设置了 IdSecret 时可以为空，详见 IdSecret
 	IdDBPath string
 
+	// 不为空时新文章的字符串ID由密钥转换生成（gmodel.IdCodec），不需要保存，字符集为 CustomIdGen.Alphabet
+	// IdDBPath 为空时不使用数据库，不支持自定义ID；不为空时已有的映射和自定义ID继续有效，详见 gmodel.IdMgr.SetCodec
+	// 密钥至少16个字节，一旦使用就不能修改，否则之前的字符串ID全部失效
+	IdSecret string
+
+	// 删除文章时自定义ID的处理方式，为空时使用 CustomIdRemove，详见 CustomIdPolicy
+	CustomIdPolicy CustomIdPolicy
+
+	// 修改自定义ID之后，旧的自定义ID重定向到新的时间，为0时使用 gmodel.DefaultIdRedirectTTL，小于0时不保留
+	CustomIdRedirectTTL time.Duration
+
+	// 自动生成的自定义ID的规则（长度、字符集、冲突重试次数），字段为空时使用默认值，详见 gmodel.IdGenConfig
+	CustomIdGen gmodel.IdGenConfig
+
 	// 监听地址
 	ListeningAddr string
 
 	// 是否开启Gzip
 	// 建议：如果服务器之间走内网（内网带宽非常大），则无需开启
 	UseGzip bool
+
+	// 是否规范化分类名称（NFKC、合并空白字符、大小写折叠），比如 "Go"、"go " 和 "Ｇｏ" 是同一个分类
+	// 开启后第一次启动时会自动合并冲突的分类，之后跳过，详见 gmodel.GModel.MigrateTagNames
+	NormalizeTagNames bool
+
+	// 游标签名的密钥，为空时每次启动随机生成，重启之后旧的游标会失效
+	// 多台服务器共用游标时需要设置相同的密钥
+	CursorSecret string
+
+	// 额外的分类法，比如关键词、作者、系列等，每个分类法使用独立的数据库
+	Taxonomies []TaxonomyConfig
+
+	// 访问密钥，为空时不鉴权（只能在可信的内网中使用），详见 auth.go
+	APIKeys []APIKeyConfig
+
+	// 签名时间戳允许的误差，为0时使用 DefaultAuthMaxSkew
+	AuthMaxSkew time.Duration
+
+	// 鉴权时读取的请求体的最大字节数，超过时返回413，为0时使用 DefaultAuthMaxBodySize
+	AuthMaxBodySize int64
+
+	// 公开的只读API的监听地址，为空时不开启，只用于 Start，详见 public_server.go
+	PublicListeningAddr string
+
+	// 公开的只读API的缓存时间（Cache-Control: max-age），为0时使用默认值，小于0时为 max-age=0
+	PublicCacheMaxAge time.Duration
+
+	// 读写超时和退出时等待请求处理完的时间，为0时使用默认值
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	ShutdownTimeout time.Duration
+}
+
+const (
+	DefaultReadTimeout     = 30 * time.Second
+	DefaultWriteTimeout    = 30 * time.Second
+	DefaultShutdownTimeout = 5 * time.Second
+)
+
+func durationOrDefault(d, defaultValue time.Duration) time.Duration {
+	if d <= 0 {
+		return defaultValue
+	}
+	return d
+}
+
+type TaxonomyConfig struct {
+	Name        string
+	TagDBPath   string
+	IndexDBPath string
 }
 
 type APIServer struct {
 	model   *gmodel.GModel
 	idMgr   *gmodel.IdMgr
-	useGzip bool
+	local   *LocalModel
+	useGzip atomic.Bool
+	auth    atomic.Pointer[authenticator]
+	nonces  nonceCache
+
+	config      *APIServerConfig
+	handler     http.Handler
+	handlerOnce sync.Once
+
+	publicHandler     http.Handler
+	publicHandlerOnce sync.Once
+	lastModified      atomic.Int64 // 最后一次写入的时间（Unix秒），用于公开API的 Last-Modified
 }
 
-func (this *APIServer) Start(config *APIServerConfig) {
+// 打开数据库，创建服务器，不监听端口，出错时已经打开的数据库会关闭
+// 之后调用 Serve 或者把 Handler 挂到自己的路由上，不再使用时调用 Close
+func NewAPIServer(config *APIServerConfig) (*APIServer, error) {
+	server := &APIServer{}
+	if err := server.open(config); err != nil {
+		return nil, err
+	}
+	return server, nil
+}
+
+func (this *APIServer) open(config *APIServerConfig) error {
+	this.config = config
+
+	if err := checkAPIKeys(config.APIKeys); err != nil {
+		return err
+	}
+	if err := checkCustomIdPolicy(config.CustomIdPolicy); err != nil {
+		return err
+	}
+	if err := gmodel.CheckIdGenConfig(config.CustomIdGen); err != nil {
+		return err
+	}
+	var codec *gmodel.IdCodec
+	if config.IdSecret != "" {
+		var err error
+		if codec, err = gmodel.NewIdCodec([]byte(config.IdSecret), config.CustomIdGen.Alphabet); err != nil {
+			return err
+		}
+	} else if config.IdDBPath == "" {
+		return errors.New("IdDBPath must not empty when IdSecret is empty")
+	}
+	if len(config.APIKeys) == 0 {
+		log.Println("APIKeys is empty, all APIs are unauthenticated")
+	}
+	this.auth.Store(newAuthenticator(config))
+	this.lastModified.Store(time.Now().Unix())
+
 	// 打开数据库
 	this.model = &gmodel.GModel{}
 	if err := this.model.Open(config.ArticleDBPath, config.TagDBPath, config.IndexDBPath); err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	this.idMgr = &gmodel.IdMgr{}
-	if err := this.idMgr.Open(config.IdDBPath); err != nil {
-		log.Fatal(err)
+	for _, taxonomy := range config.Taxonomies {
+		if err := this.model.OpenTaxonomy(taxonomy.Name, taxonomy.TagDBPath, taxonomy.IndexDBPath); err != nil {
+			this.model.Close()
+			return err
+		}
 	}
-	this.useGzip = config.UseGzip
 
-	// 执行退出逻辑，用于保存数据
-	defer func() {
+	if config.NormalizeTagNames {
+		this.model.SetTagNormalizer(gmodel.DefaultTagNormalizer)
+		merged, err := this.model.MigrateTagNames()
+		if err != nil {
+			this.model.Close()
+			return err
+		}
+		log.Println("normalize tag names, merged:", merged)
+	}
+
+	if config.CursorSecret != "" {
+		if err := this.model.SetCursorSecret([]byte(config.CursorSecret)); err != nil {
+			this.model.Close()
+			return err
+		}
+	}
+
+	this.idMgr = &gmodel.IdMgr{}
+	if config.IdDBPath == "" {
+		if err := this.idMgr.OpenStateless(codec); err != nil {
+			this.model.Close()
+			return err
+		}
+	} else if err := this.idMgr.Open(config.IdDBPath); err != nil {
 		this.model.Close()
+		return err
+	} else if codec != nil {
+		this.idMgr.SetCodec(codec)
+	}
+	this.idMgr.SetRedirectTTL(config.CustomIdRedirectTTL)
+	if err := this.idMgr.SetIdGenConfig(config.CustomIdGen); err != nil {
 		this.idMgr.Close()
-	}()
+		this.model.Close()
+		return err
+	}
+	this.local = NewLocalModel(this.model, this.idMgr)
+	this.local.customIdPolicy = config.CustomIdPolicy
+	this.useGzip.Store(config.UseGzip)
+
+	return nil
+}
+
+// 关闭数据库，需要在 Serve 返回之后调用
+func (this *APIServer) Close() error {
+	this.model.Close()
+	return this.idMgr.Close()
+}
+
+// 返回处理全部API的 http.Handler，可以挂到自己的服务器上
+func (this *APIServer) Handler() http.Handler {
+	this.handlerOnce.Do(func() {
+		// Gin包设置Release模式
+		gin.SetMode(gin.ReleaseMode)
+		this.handler = this.newHandler()
+	})
+	return this.handler
+}
+
+// 在 listener 上处理请求，直到 ctx 取消，然后等待正在处理的请求完成（最多 ShutdownTimeout）
+// ctx 取消之后正常退出时返回nil，其他情况返回错误，listener 会被关闭
+func (this *APIServer) Serve(ctx context.Context, listener net.Listener) error {
+	return this.serve(ctx, listener, this.Handler())
+}
 
-	// Gin包设置Release模式
-	gin.SetMode(gin.ReleaseMode)
+// 和 Serve 一样，但是处理的是公开的只读API，详见 PublicHandler
+func (this *APIServer) ServePublic(ctx context.Context, listener net.Listener) error {
+	return this.serve(ctx, listener, this.PublicHandler())
+}
 
-	// 创建服务器并监听端口
+func (this *APIServer) serve(ctx context.Context, listener net.Listener, handler http.Handler) error {
 	server := &http.Server{
-		Addr:         config.ListeningAddr,
-		Handler:      this.newHandler(),
-		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 30 * time.Second,
+		Handler:      handler,
+		ReadTimeout:  durationOrDefault(this.config.ReadTimeout, DefaultReadTimeout),
+		WriteTimeout: durationOrDefault(this.config.WriteTimeout, DefaultWriteTimeout),
 	}
+
+	shutdown := make(chan error, 1)
 	go func() {
-		log.Println("start listening:", config.ListeningAddr)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		<-ctx.Done()
+		timeoutCtx, cancel := context.WithTimeout(context.Background(), durationOrDefault(this.config.ShutdownTimeout, DefaultShutdownTimeout))
+		defer cancel()
+		shutdown <- server.Shutdown(timeoutCtx)
+	}()
+
+	if err := server.Serve(listener); err != http.ErrServerClosed {
+		return err
+	}
+	return <-shutdown
+}
+
+// 打开数据库并监听端口，收到 SIGINT、SIGTERM 时退出，出错时直接退出进程
+//
+// Deprecated: 使用 NewAPIServer、Serve、Close，由调用方控制监听、退出和错误处理
+func (this *APIServer) Start(config *APIServerConfig) {
+	if err := this.open(config); err != nil {
+		log.Fatal(err)
+	}
+
+	// 执行退出逻辑，用于保存数据
+	defer this.Close()
+
+	listener, err := net.Listen("tcp", config.ListeningAddr)
+	if err != nil {
+		log.Fatalf("listen: %v\n", err)
+	}
+	log.Println("start listening:", config.ListeningAddr)
+
+	// 关闭数据库之前需要等公开API的请求处理完
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if config.PublicListeningAddr != "" {
+		publicListener, err := net.Listen("tcp", config.PublicListeningAddr)
+		if err != nil {
 			log.Fatalf("listen: %v\n", err)
 		}
-	}()
+		log.Println("start public listening:", config.PublicListeningAddr)
 
-	// Wait for interrupt signal to gracefully shutdown the server with
-	// a timeout of 5 seconds.
-	quit := make(chan os.Signal)
-	signal.Notify(quit, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
-	log.Println("signal: ", <-quit)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := this.ServePublic(ctx, publicListener); err != nil {
+				log.Println("public server shutdown:", err)
+			}
+		}()
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := server.Shutdown(ctx); err != nil {
-		log.Println("server shutdowm:", err)
+	if err := this.Serve(ctx, listener); err != nil {
+		log.Println("server shutdown:", err)
 		return
 	}
+	log.Println("server exiting")
+}
 
-	// catching ctx.Done(). timeout of 5 seconds.
-	// select {
-	// case <-ctx.Done():
-	// 	log.Println("timeout of 5 seconds")
-	// }
+// 运行中修改配置，只修改不需要重新打开数据库和监听端口的配置：UseGzip、CursorSecret、APIKeys、AuthMaxSkew、AuthMaxBodySize
+// 其他配置需要重启才能生效，配置有错误时不做任何修改
+func (this *APIServer) Reload(config *APIServerConfig) error {
+	if err := checkAPIKeys(config.APIKeys); err != nil {
+		return err
+	}
 
-	log.Println("server exiting")
+	this.auth.Store(newAuthenticator(config))
+	this.useGzip.Store(config.UseGzip)
+	if config.CursorSecret != "" {
+		this.model.SetCursorSecret([]byte(config.CursorSecret))
+	}
+	return nil
 }
 
 func (this *APIServer) newHandler() *gin.Engine {
 	router := gin.Default()
 
-	// gzip
-	if this.useGzip {
-		router.Use(gzip.Gzip(gzip.DefaultCompression))
-	}
+	// 鉴权
+	router.Use(this.authHandler)
+	router.Use(this.lastModifiedHandler)
+
+	// gzip，每个请求都判断一次，这样 Reload 之后马上生效
+	gzipHandler := gzip.Gzip(gzip.DefaultCompression)
+	router.Use(func(c *gin.Context) {
+		if this.useGzip.Load() {
+			gzipHandler(c)
+		} else {
+			c.Next()
+		}
+	})
 
 	// api
 	router.POST(APIGetModelInfo, this.getModelInfoHandler)
@@ -129,6 +372,27 @@ func (this *APIServer) newHandler() *gin.Engine {
 	router.POST(APIGetPrevTags, this.getPrevTagsHandler)
 	router.POST(APIRenameTag, this.renameTagHandler)
 	router.POST(APIGetArticleCountByTag, this.getArticleCountByTagHandler)
+	router.POST(APIGetTagBySlug, this.getTagBySlugHandler)
+	router.POST(APIUpdateTagMeta, this.updateTagMetaHandler)
+	router.POST(APIGetTopTags, this.getTopTagsHandler)
+	router.POST(APISearchTags, this.searchTagsHandler)
+	router.POST(APIGetNextArticlesByTerm, this.getNextArticlesByTermHandler)
+	router.POST(APIGetPrevArticlesByTerm, this.getPrevArticlesByTermHandler)
+	router.POST(APIGetArticlesByTagPage, this.getArticlesByTagPageHandler)
+	router.POST(APIListArticles, this.listArticlesHandler)
+	router.POST(APIListArticlesByTag, this.listArticlesByTagHandler)
+	router.POST(APIListTags, this.listTagsHandler)
+	router.POST(APIListTopTags, this.listTopTagsHandler)
+	router.POST(APIGetArticles, this.getArticlesHandler)
+	router.POST(APIAddArticles, this.addArticlesHandler)
+	router.POST(APIUpsertArticle, this.upsertArticleHandler)
+	router.POST(APIGetCustomId, this.getCustomIdHandler)
+	router.POST(APIResolveCustomId, this.resolveCustomIdHandler)
+	router.POST(APISetCustomId, this.setCustomIdHandler)
+	router.POST(APIUpdateCustomId, this.updateCustomIdHandler)
+	router.POST(APIAddTag, this.addTagHandler)
+	router.POST(APIDeleteTag, this.deleteTagHandler)
+	router.POST(APIGetTagCount, this.getTagCountHandler)
 
 	return router
 }
@@ -141,7 +405,7 @@ func (this *APIServer) getModelInfoHandler(c *gin.Context) {
 	resp.TagCount = this.model.GetTagCount()
 	resp.MaxArticleId = this.model.GetMaxArticleId()
 
-	c.JSON(http.StatusOK, resp)
+	c.JSON(httpStatus(resp.ErrCode), resp)
 }
 
 func (this *APIServer) addArticleHandler(c *gin.Context) {
@@ -151,50 +415,48 @@ func (this *APIServer) addArticleHandler(c *gin.Context) {
 
 	var req AddArticleReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		resp.ErrCode = ErrCodeFailed
+		resp.ErrCode = ErrCodeInvalidArgument
 		resp.ErrMsg = err.Error()
-		c.JSON(http.StatusOK, resp)
+		c.JSON(httpStatus(resp.ErrCode), resp)
 		return
 	}
 
-	// 判断自定义ID是否已经存在，如果已经存在，则返回
-	if req.CustomArticleId != "" {
-		if _, exist := this.idMgr.GetIntId(req.CustomArticleId); exist {
-			resp.ErrCode = ErrCodeFailed
-			resp.ErrMsg = "CustomArticleId is exist"
-			c.JSON(http.StatusOK, resp)
-			return
-		}
+	articleId, customArticleId, err := this.local.AddArticleContext(c.Request.Context(), req.Tags, req.Terms, req.Data, req.CustomArticleId)
+	if err != nil {
+		resp.ErrCode = errorCode(err)
+		resp.ErrMsg = err.Error()
 	}
+	resp.ArticleId = articleId
+	resp.CustomArticleId = customArticleId
 
-	// 保存新文章
-	articleId, err := this.model.AddArticle(req.Tags, req.Data)
-	if err != nil {
-		resp.ErrCode = ErrCodeFailed
-		resp.ErrMsg = "AddArticle failed: " + err.Error()
-		c.JSON(http.StatusOK, resp)
+	c.JSON(httpStatus(resp.ErrCode), resp)
+}
+
+func (this *APIServer) upsertArticleHandler(c *gin.Context) {
+	resp := &UpsertArticleResp{}
+	resp.ErrCode = ErrCodeSuccess
+	resp.ErrMsg = ErrMsgSuccess
+
+	var req UpsertArticleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		resp.ErrCode = ErrCodeInvalidArgument
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
 		return
 	}
 
-	// 自定义ID
-	if req.CustomArticleId != "" {
-		resp.CustomArticleId = req.CustomArticleId
-		if err := this.idMgr.SetIdMap(articleId, req.CustomArticleId); err != nil {
-			resp.ErrCode = ErrCodeFailed
-			resp.ErrMsg = "SetIdMap failed: " + err.Error()
-		}
-	} else {
-		customId, err := this.idMgr.AddIntId(articleId)
-		if err != nil {
-			resp.ErrCode = ErrCodeFailed
-			resp.ErrMsg = "AddIntId failed: " + err.Error()
-		} else {
-			resp.CustomArticleId = customId
-		}
+	articleId, result, err := this.local.UpsertArticleContext(c.Request.Context(), req.Tags, req.Terms, req.Data, req.CustomArticleId)
+	if err != nil {
+		resp.ErrCode = errorCode(err)
+		resp.ErrMsg = err.Error()
+	}
+	if articleId != 0 {
+		resp.ArticleId = articleId
+		resp.CustomArticleId, _ = this.idMgr.GetStringId(articleId)
+		resp.Result = result.String()
 	}
 
-	resp.ArticleId = articleId
-	c.JSON(http.StatusOK, resp)
+	c.JSON(httpStatus(resp.ErrCode), resp)
 }
 
 func (this *APIServer) deleteArticleHandler(c *gin.Context) {
@@ -204,25 +466,19 @@ func (this *APIServer) deleteArticleHandler(c *gin.Context) {
 
 	var req DeleteArticleReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		resp.ErrCode = ErrCodeFailed
+		resp.ErrCode = ErrCodeInvalidArgument
 		resp.ErrMsg = err.Error()
-		c.JSON(http.StatusOK, resp)
+		c.JSON(httpStatus(resp.ErrCode), resp)
 		return
 	}
 
-	articleId := req.ArticleId
-	if req.CustomArticleId != "" {
-		if intId, ok := this.idMgr.GetIntId(req.CustomArticleId); ok {
-			articleId = intId
-		}
-	}
-
-	if err := this.model.DeleteArticle(articleId); err != nil {
-		resp.ErrCode = ErrCodeFailed
-		resp.ErrMsg = "DeleteArticle failed: " + err.Error()
+	err := this.local.DeleteArticleContext(c.Request.Context(), req.ArticleId, req.CustomArticleId)
+	if err != nil {
+		resp.ErrCode = errorCode(err)
+		resp.ErrMsg = err.Error()
 	}
 
-	c.JSON(http.StatusOK, resp)
+	c.JSON(httpStatus(resp.ErrCode), resp)
 }
 
 func (this *APIServer) getArticleHandler(c *gin.Context) {
@@ -232,47 +488,23 @@ func (this *APIServer) getArticleHandler(c *gin.Context) {
 
 	var req GetArticleReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		resp.ErrCode = ErrCodeFailed
+		resp.ErrCode = ErrCodeInvalidArgument
 		resp.ErrMsg = err.Error()
-		c.JSON(http.StatusOK, resp)
+		c.JSON(httpStatus(resp.ErrCode), resp)
 		return
 	}
 
-	articleId := req.ArticleId
-	customArticleId := req.CustomArticleId
-	if customArticleId != "" {
-		if intId, ok := this.idMgr.GetIntId(customArticleId); ok {
-			articleId = intId
-		}
-	} else {
-		if stringId, ok := this.idMgr.GetStringId(articleId); ok {
-			customArticleId = stringId
-		}
-	}
-
-	article, err := this.model.GetArticle(articleId)
+	remoteArticle, err := this.local.GetArticleContext(c.Request.Context(), req.ArticleId, req.CustomArticleId)
 	if err != nil {
-		resp.ErrCode = ErrCodeFailed
-		resp.ErrMsg = "GetArticle failed: " + err.Error()
-		c.JSON(http.StatusOK, resp)
+		resp.ErrCode = errorCode(err)
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
 		return
 	}
 
-	// 获取分类名称
-	tagNameArray := make([]string, 0)
-	for _, tagId := range article.TagIds {
-		if tag, err := this.model.GetTagById(tagId); err == nil {
-			tagNameArray = append(tagNameArray, tag.Name)
-		}
-	}
-
-	resp.RemoteArticle = &RemoteArticle{
-		Article:         article,
-		CustomArticleId: customArticleId,
-		TagNameArray:    tagNameArray,
-	}
+	resp.RemoteArticle = remoteArticle
 
-	c.JSON(http.StatusOK, resp)
+	c.JSON(httpStatus(resp.ErrCode), resp)
 }
 
 func (this *APIServer) getNextArticlesHandler(c *gin.Context) {
@@ -282,9 +514,9 @@ func (this *APIServer) getNextArticlesHandler(c *gin.Context) {
 
 	var req GetNextArticlesReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		resp.ErrCode = ErrCodeFailed
+		resp.ErrCode = ErrCodeInvalidArgument
 		resp.ErrMsg = err.Error()
-		c.JSON(http.StatusOK, resp)
+		c.JSON(httpStatus(resp.ErrCode), resp)
 		return
 	}
 
@@ -299,26 +531,14 @@ func (this *APIServer) getNextArticlesHandler(c *gin.Context) {
 
 	articles := this.model.GetNextArticles(articleId, req.N)
 	for _, article := range articles {
-		// 获取分类名称
-		tagNameArray := make([]string, 0)
-		for _, tagId := range article.TagIds {
-			if tag, err := this.model.GetTagById(tagId); err == nil {
-				tagNameArray = append(tagNameArray, tag.Name)
-			}
-		}
-
 		// 获取自定义文章ID
 		if stringId, ok := this.idMgr.GetStringId(article.Id); ok {
-			remoteArticles = append(remoteArticles, &RemoteArticle{
-				Article:         article,
-				CustomArticleId: stringId,
-				TagNameArray:    tagNameArray,
-			})
+			remoteArticles = append(remoteArticles, this.local.newRemoteArticle(article, stringId))
 		}
 	}
 
 	resp.RemoteArticles = remoteArticles
-	c.JSON(http.StatusOK, resp)
+	c.JSON(httpStatus(resp.ErrCode), resp)
 }
 
 func (this *APIServer) getPrevArticlesHandler(c *gin.Context) {
@@ -328,9 +548,9 @@ func (this *APIServer) getPrevArticlesHandler(c *gin.Context) {
 
 	var req GetPrevArticlesReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		resp.ErrCode = ErrCodeFailed
+		resp.ErrCode = ErrCodeInvalidArgument
 		resp.ErrMsg = err.Error()
-		c.JSON(http.StatusOK, resp)
+		c.JSON(httpStatus(resp.ErrCode), resp)
 		return
 	}
 
@@ -345,26 +565,14 @@ func (this *APIServer) getPrevArticlesHandler(c *gin.Context) {
 
 	articles := this.model.GetPrevArticles(articleId, req.N)
 	for _, article := range articles {
-		// 获取分类名称
-		tagNameArray := make([]string, 0)
-		for _, tagId := range article.TagIds {
-			if tag, err := this.model.GetTagById(tagId); err == nil {
-				tagNameArray = append(tagNameArray, tag.Name)
-			}
-		}
-
 		// 获取自定义文章ID
 		if stringId, ok := this.idMgr.GetStringId(article.Id); ok {
-			remoteArticles = append(remoteArticles, &RemoteArticle{
-				Article:         article,
-				CustomArticleId: stringId,
-				TagNameArray:    tagNameArray,
-			})
+			remoteArticles = append(remoteArticles, this.local.newRemoteArticle(article, stringId))
 		}
 	}
 
 	resp.RemoteArticles = remoteArticles
-	c.JSON(http.StatusOK, resp)
+	c.JSON(httpStatus(resp.ErrCode), resp)
 }
 
 func (this *APIServer) getNextArticlesByTagHandler(c *gin.Context) {
@@ -374,9 +582,9 @@ func (this *APIServer) getNextArticlesByTagHandler(c *gin.Context) {
 
 	var req GetNextArticlesByTagReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		resp.ErrCode = ErrCodeFailed
+		resp.ErrCode = ErrCodeInvalidArgument
 		resp.ErrMsg = err.Error()
-		c.JSON(http.StatusOK, resp)
+		c.JSON(httpStatus(resp.ErrCode), resp)
 		return
 	}
 
@@ -391,26 +599,14 @@ func (this *APIServer) getNextArticlesByTagHandler(c *gin.Context) {
 
 	articles := this.model.GetNextArticlesByTag(req.Tag, articleId, req.N)
 	for _, article := range articles {
-		// 获取分类名称
-		tagNameArray := make([]string, 0)
-		for _, tagId := range article.TagIds {
-			if tag, err := this.model.GetTagById(tagId); err == nil {
-				tagNameArray = append(tagNameArray, tag.Name)
-			}
-		}
-
 		// 获取自定义文章ID
 		if stringId, ok := this.idMgr.GetStringId(article.Id); ok {
-			remoteArticles = append(remoteArticles, &RemoteArticle{
-				Article:         article,
-				CustomArticleId: stringId,
-				TagNameArray:    tagNameArray,
-			})
+			remoteArticles = append(remoteArticles, this.local.newRemoteArticle(article, stringId))
 		}
 	}
 
 	resp.RemoteArticles = remoteArticles
-	c.JSON(http.StatusOK, resp)
+	c.JSON(httpStatus(resp.ErrCode), resp)
 }
 
 func (this *APIServer) getPrevArticlesByTagHandler(c *gin.Context) {
@@ -420,9 +616,9 @@ func (this *APIServer) getPrevArticlesByTagHandler(c *gin.Context) {
 
 	var req GetPrevArticlesByTagReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		resp.ErrCode = ErrCodeFailed
+		resp.ErrCode = ErrCodeInvalidArgument
 		resp.ErrMsg = err.Error()
-		c.JSON(http.StatusOK, resp)
+		c.JSON(httpStatus(resp.ErrCode), resp)
 		return
 	}
 
@@ -437,26 +633,14 @@ func (this *APIServer) getPrevArticlesByTagHandler(c *gin.Context) {
 
 	articles := this.model.GetPrevArticlesByTag(req.Tag, articleId, req.N)
 	for _, article := range articles {
-		// 获取分类名称
-		tagNameArray := make([]string, 0)
-		for _, tagId := range article.TagIds {
-			if tag, err := this.model.GetTagById(tagId); err == nil {
-				tagNameArray = append(tagNameArray, tag.Name)
-			}
-		}
-
 		// 获取自定义文章ID
 		if stringId, ok := this.idMgr.GetStringId(article.Id); ok {
-			remoteArticles = append(remoteArticles, &RemoteArticle{
-				Article:         article,
-				CustomArticleId: stringId,
-				TagNameArray:    tagNameArray,
-			})
+			remoteArticles = append(remoteArticles, this.local.newRemoteArticle(article, stringId))
 		}
 	}
 
 	resp.RemoteArticles = remoteArticles
-	c.JSON(http.StatusOK, resp)
+	c.JSON(httpStatus(resp.ErrCode), resp)
 }
 
 func (this *APIServer) updateArticleHandler(c *gin.Context) {
@@ -466,26 +650,19 @@ func (this *APIServer) updateArticleHandler(c *gin.Context) {
 
 	var req UpdateArticleReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		resp.ErrCode = ErrCodeFailed
+		resp.ErrCode = ErrCodeInvalidArgument
 		resp.ErrMsg = err.Error()
-		c.JSON(http.StatusOK, resp)
+		c.JSON(httpStatus(resp.ErrCode), resp)
 		return
 	}
 
-	articleId := req.ArticleId
-	if req.CustomArticleId != "" {
-		if intId, ok := this.idMgr.GetIntId(req.CustomArticleId); ok {
-			articleId = intId
-		}
-	}
-
-	err := this.model.UpdateArticle(articleId, req.NewTags, req.NewData)
+	err := this.local.UpdateArticleContext(c.Request.Context(), req.ArticleId, req.CustomArticleId, req.NewTags, req.NewTerms, req.NewData)
 	if err != nil {
-		resp.ErrCode = ErrCodeFailed
-		resp.ErrMsg = "UpdateArticle failed: " + err.Error()
+		resp.ErrCode = errorCode(err)
+		resp.ErrMsg = err.Error()
 	}
 
-	c.JSON(http.StatusOK, resp)
+	c.JSON(httpStatus(resp.ErrCode), resp)
 }
 
 func (this *APIServer) getTagByIdHandler(c *gin.Context) {
@@ -495,15 +672,15 @@ func (this *APIServer) getTagByIdHandler(c *gin.Context) {
 
 	var req GetTagByIdReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		resp.ErrCode = ErrCodeFailed
+		resp.ErrCode = ErrCodeInvalidArgument
 		resp.ErrMsg = err.Error()
-		c.JSON(http.StatusOK, resp)
+		c.JSON(httpStatus(resp.ErrCode), resp)
 		return
 	}
 
 	tag, err := this.model.GetTagById(req.TagId)
 	if err != nil {
-		resp.ErrCode = ErrCodeFailed
+		resp.ErrCode = errorCode(err)
 		resp.ErrMsg = "GetTagById failed: " + err.Error()
 	}
 
@@ -511,7 +688,7 @@ func (this *APIServer) getTagByIdHandler(c *gin.Context) {
 		Tag: tag,
 	}
 
-	c.JSON(http.StatusOK, resp)
+	c.JSON(httpStatus(resp.ErrCode), resp)
 }
 
 func (this *APIServer) getTagByNameHandler(c *gin.Context) {
@@ -521,20 +698,23 @@ func (this *APIServer) getTagByNameHandler(c *gin.Context) {
 
 	var req GetTagByNameReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		resp.ErrCode = ErrCodeFailed
+		resp.ErrCode = ErrCodeInvalidArgument
 		resp.ErrMsg = err.Error()
-		c.JSON(http.StatusOK, resp)
+		c.JSON(httpStatus(resp.ErrCode), resp)
 		return
 	}
 
 	tag, err := this.model.GetTagByName(req.TagName)
 	if err != nil {
-		resp.ErrCode = ErrCodeFailed
+		resp.ErrCode = errorCode(err)
 		resp.ErrMsg = "GetTagByName failed: " + err.Error()
 	}
 
-	resp.Tag = tag
-	c.JSON(http.StatusOK, resp)
+	resp.RemoteTag = &RemoteTag{
+		Tag: tag,
+	}
+
+	c.JSON(httpStatus(resp.ErrCode), resp)
 }
 
 func (this *APIServer) getNextTagsHandler(c *gin.Context) {
@@ -544,9 +724,9 @@ func (this *APIServer) getNextTagsHandler(c *gin.Context) {
 
 	var req GetNextTagsReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		resp.ErrCode = ErrCodeFailed
+		resp.ErrCode = ErrCodeInvalidArgument
 		resp.ErrMsg = err.Error()
-		c.JSON(http.StatusOK, resp)
+		c.JSON(httpStatus(resp.ErrCode), resp)
 		return
 	}
 
@@ -560,7 +740,7 @@ func (this *APIServer) getNextTagsHandler(c *gin.Context) {
 	}
 
 	resp.RemoteTags = remoteTags
-	c.JSON(http.StatusOK, resp)
+	c.JSON(httpStatus(resp.ErrCode), resp)
 }
 
 func (this *APIServer) getPrevTagsHandler(c *gin.Context) {
@@ -570,9 +750,9 @@ func (this *APIServer) getPrevTagsHandler(c *gin.Context) {
 
 	var req GetPrevTagsReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		resp.ErrCode = ErrCodeFailed
+		resp.ErrCode = ErrCodeInvalidArgument
 		resp.ErrMsg = err.Error()
-		c.JSON(http.StatusOK, resp)
+		c.JSON(httpStatus(resp.ErrCode), resp)
 		return
 	}
 
@@ -586,7 +766,7 @@ func (this *APIServer) getPrevTagsHandler(c *gin.Context) {
 	}
 
 	resp.RemoteTags = remoteTags
-	c.JSON(http.StatusOK, resp)
+	c.JSON(httpStatus(resp.ErrCode), resp)
 }
 
 func (this *APIServer) renameTagHandler(c *gin.Context) {
@@ -596,19 +776,19 @@ func (this *APIServer) renameTagHandler(c *gin.Context) {
 
 	var req RenameTagReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		resp.ErrCode = ErrCodeFailed
+		resp.ErrCode = ErrCodeInvalidArgument
 		resp.ErrMsg = err.Error()
-		c.JSON(http.StatusOK, resp)
+		c.JSON(httpStatus(resp.ErrCode), resp)
 		return
 	}
 
-	err := this.model.RenameTag(req.OldName, req.NewName)
+	err := this.local.RenameTagContext(c.Request.Context(), req.OldName, req.NewName)
 	if err != nil {
-		resp.ErrCode = ErrCodeFailed
-		resp.ErrMsg = "RenameTag failed: " + err.Error()
+		resp.ErrCode = errorCode(err)
+		resp.ErrMsg = err.Error()
 	}
 
-	c.JSON(http.StatusOK, resp)
+	c.JSON(httpStatus(resp.ErrCode), resp)
 }
 
 func (this *APIServer) getArticleCountByTagHandler(c *gin.Context) {
@@ -618,12 +798,599 @@ func (this *APIServer) getArticleCountByTagHandler(c *gin.Context) {
 
 	var req GetArticleCountByTagReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		resp.ErrCode = ErrCodeFailed
+		resp.ErrCode = ErrCodeInvalidArgument
 		resp.ErrMsg = err.Error()
-		c.JSON(http.StatusOK, resp)
+		c.JSON(httpStatus(resp.ErrCode), resp)
 		return
 	}
 
 	resp.ArticleCount = this.model.GetArticleCountByTag(req.TagName)
-	c.JSON(http.StatusOK, resp)
+	c.JSON(httpStatus(resp.ErrCode), resp)
+}
+
+func (this *APIServer) getTagBySlugHandler(c *gin.Context) {
+	resp := &GetTagBySlugResp{}
+	resp.ErrCode = ErrCodeSuccess
+	resp.ErrMsg = ErrMsgSuccess
+
+	var req GetTagBySlugReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		resp.ErrCode = ErrCodeInvalidArgument
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
+		return
+	}
+
+	tag, err := this.model.GetTagBySlug(req.Slug)
+	if err != nil {
+		resp.ErrCode = errorCode(err)
+		resp.ErrMsg = "GetTagBySlug failed: " + err.Error()
+	}
+
+	resp.RemoteTag = &RemoteTag{
+		Tag: tag,
+	}
+
+	c.JSON(httpStatus(resp.ErrCode), resp)
+}
+
+func (this *APIServer) updateTagMetaHandler(c *gin.Context) {
+	resp := &UpdateTagMetaResp{}
+	resp.ErrCode = ErrCodeSuccess
+	resp.ErrMsg = ErrMsgSuccess
+
+	var req UpdateTagMetaReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		resp.ErrCode = ErrCodeInvalidArgument
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
+		return
+	}
+
+	err := this.model.UpdateTagMeta(req.TagName, &gmodel.TagMeta{
+		Slug:        req.Slug,
+		Description: req.Description,
+		Aliases:     req.Aliases,
+		Attrs:       req.Attrs,
+	})
+	if err != nil {
+		resp.ErrCode = errorCode(err)
+		resp.ErrMsg = "UpdateTagMeta failed: " + err.Error()
+	}
+
+	c.JSON(httpStatus(resp.ErrCode), resp)
+}
+
+func (this *APIServer) getTopTagsHandler(c *gin.Context) {
+	resp := &GetTopTagsResp{}
+	resp.ErrCode = ErrCodeSuccess
+	resp.ErrMsg = ErrMsgSuccess
+
+	var req GetTopTagsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		resp.ErrCode = ErrCodeInvalidArgument
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
+		return
+	}
+
+	remoteTags := make([]*RemoteTag, 0)
+
+	tags := this.model.GetNextTopTags(req.TagName, req.N)
+	for _, tag := range tags {
+		remoteTags = append(remoteTags, &RemoteTag{
+			Tag: tag,
+		})
+	}
+
+	resp.RemoteTags = remoteTags
+	c.JSON(httpStatus(resp.ErrCode), resp)
+}
+
+func (this *APIServer) searchTagsHandler(c *gin.Context) {
+	resp := &SearchTagsResp{}
+	resp.ErrCode = ErrCodeSuccess
+	resp.ErrMsg = ErrMsgSuccess
+
+	var req SearchTagsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		resp.ErrCode = ErrCodeInvalidArgument
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
+		return
+	}
+
+	page, err := this.local.SearchTagsContext(c.Request.Context(), req.Prefix, req.Cursor, req.N, req.IgnoreCase)
+	if err != nil {
+		resp.ErrCode = errorCode(err)
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
+		return
+	}
+
+	resp.Page = *page
+	c.JSON(httpStatus(resp.ErrCode), resp)
+}
+
+func (this *APIServer) getNextArticlesByTermHandler(c *gin.Context) {
+	resp := &GetNextArticlesByTermResp{}
+	resp.ErrCode = ErrCodeSuccess
+	resp.ErrMsg = ErrMsgSuccess
+
+	var req GetNextArticlesByTermReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		resp.ErrCode = ErrCodeInvalidArgument
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
+		return
+	}
+
+	articleId := req.ArticleId
+	if req.CustomArticleId != "" {
+		if intId, ok := this.idMgr.GetIntId(req.CustomArticleId); ok {
+			articleId = intId
+		}
+	}
+
+	remoteArticles := make([]*RemoteArticle, 0)
+
+	articles := this.model.GetNextArticlesByTerm(req.Taxonomy, req.Term, articleId, req.N)
+	for _, article := range articles {
+		// 获取自定义文章ID
+		if stringId, ok := this.idMgr.GetStringId(article.Id); ok {
+			remoteArticles = append(remoteArticles, this.local.newRemoteArticle(article, stringId))
+		}
+	}
+
+	resp.RemoteArticles = remoteArticles
+	c.JSON(httpStatus(resp.ErrCode), resp)
+}
+
+func (this *APIServer) getPrevArticlesByTermHandler(c *gin.Context) {
+	resp := &GetPrevArticlesByTermResp{}
+	resp.ErrCode = ErrCodeSuccess
+	resp.ErrMsg = ErrMsgSuccess
+
+	var req GetPrevArticlesByTermReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		resp.ErrCode = ErrCodeInvalidArgument
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
+		return
+	}
+
+	articleId := req.ArticleId
+	if req.CustomArticleId != "" {
+		if intId, ok := this.idMgr.GetIntId(req.CustomArticleId); ok {
+			articleId = intId
+		}
+	}
+
+	remoteArticles := make([]*RemoteArticle, 0)
+
+	articles := this.model.GetPrevArticlesByTerm(req.Taxonomy, req.Term, articleId, req.N)
+	for _, article := range articles {
+		// 获取自定义文章ID
+		if stringId, ok := this.idMgr.GetStringId(article.Id); ok {
+			remoteArticles = append(remoteArticles, this.local.newRemoteArticle(article, stringId))
+		}
+	}
+
+	resp.RemoteArticles = remoteArticles
+	c.JSON(httpStatus(resp.ErrCode), resp)
+}
+
+func (this *APIServer) getArticlesByTagPageHandler(c *gin.Context) {
+	resp := &GetArticlesByTagPageResp{}
+	resp.ErrCode = ErrCodeSuccess
+	resp.ErrMsg = ErrMsgSuccess
+
+	var req GetArticlesByTagPageReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		resp.ErrCode = ErrCodeInvalidArgument
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
+		return
+	}
+
+	var page *gmodel.ArticlePage
+	var err error
+	if req.Taxonomy == "" {
+		page, err = this.model.GetArticlesByTagPage(req.Tag, req.Page, req.Size, parseOrder(req.Order))
+	} else {
+		page, err = this.model.GetArticlesByTermPage(req.Taxonomy, req.Tag, req.Page, req.Size, parseOrder(req.Order))
+	}
+	if err != nil {
+		resp.ErrCode = errorCode(err)
+		resp.ErrMsg = "GetArticlesByTagPage failed: " + err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
+		return
+	}
+
+	remoteArticles := make([]*RemoteArticle, 0)
+	for _, article := range page.Articles {
+		// 获取自定义文章ID
+		if stringId, ok := this.idMgr.GetStringId(article.Id); ok {
+			remoteArticles = append(remoteArticles, this.local.newRemoteArticle(article, stringId))
+		}
+	}
+
+	resp.RemoteArticlePage = &RemoteArticlePage{
+		RemoteArticles: remoteArticles,
+		Page:           page.Page,
+		Size:           page.Size,
+		Total:          page.Total,
+		PageCount:      page.PageCount,
+	}
+	c.JSON(httpStatus(resp.ErrCode), resp)
+}
+
+func (this *APIServer) listArticlesHandler(c *gin.Context) {
+	resp := &ListArticlesResp{}
+	resp.ErrCode = ErrCodeSuccess
+	resp.ErrMsg = ErrMsgSuccess
+
+	var req ListArticlesReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		resp.ErrCode = ErrCodeInvalidArgument
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
+		return
+	}
+
+	page, err := this.local.ListArticlesContext(c.Request.Context(), req.Cursor, req.N, req.Order)
+	if err != nil {
+		resp.ErrCode = errorCode(err)
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
+		return
+	}
+
+	resp.Page = *page
+	c.JSON(httpStatus(resp.ErrCode), resp)
+}
+
+func (this *APIServer) listArticlesByTagHandler(c *gin.Context) {
+	resp := &ListArticlesByTagResp{}
+	resp.ErrCode = ErrCodeSuccess
+	resp.ErrMsg = ErrMsgSuccess
+
+	var req ListArticlesByTagReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		resp.ErrCode = ErrCodeInvalidArgument
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
+		return
+	}
+
+	page, err := this.local.ListArticlesByTagContext(c.Request.Context(), req.Taxonomy, req.Tag, req.Cursor, req.N, req.Order)
+	if err != nil {
+		resp.ErrCode = errorCode(err)
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
+		return
+	}
+
+	resp.Page = *page
+	c.JSON(httpStatus(resp.ErrCode), resp)
+}
+
+func (this *APIServer) listTagsHandler(c *gin.Context) {
+	resp := &ListTagsResp{}
+	resp.ErrCode = ErrCodeSuccess
+	resp.ErrMsg = ErrMsgSuccess
+
+	var req ListTagsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		resp.ErrCode = ErrCodeInvalidArgument
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
+		return
+	}
+
+	page, err := this.local.ListTagsContext(c.Request.Context(), req.Cursor, req.N, req.Order)
+	if err != nil {
+		resp.ErrCode = errorCode(err)
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
+		return
+	}
+
+	resp.Page = *page
+	c.JSON(httpStatus(resp.ErrCode), resp)
+}
+
+func (this *APIServer) listTopTagsHandler(c *gin.Context) {
+	resp := &ListTopTagsResp{}
+	resp.ErrCode = ErrCodeSuccess
+	resp.ErrMsg = ErrMsgSuccess
+
+	var req ListTopTagsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		resp.ErrCode = ErrCodeInvalidArgument
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
+		return
+	}
+
+	page, err := this.local.ListTopTagsContext(c.Request.Context(), req.Cursor, req.N)
+	if err != nil {
+		resp.ErrCode = errorCode(err)
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
+		return
+	}
+
+	resp.Page = *page
+	c.JSON(httpStatus(resp.ErrCode), resp)
+}
+
+func (this *APIServer) getArticlesHandler(c *gin.Context) {
+	resp := &GetArticlesResp{}
+	resp.ErrCode = ErrCodeSuccess
+	resp.ErrMsg = ErrMsgSuccess
+
+	var req GetArticlesReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		resp.ErrCode = ErrCodeInvalidArgument
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
+		return
+	}
+
+	remoteArticles, errs, err := this.local.GetArticlesContext(c.Request.Context(), req.Ids)
+	if err != nil {
+		resp.ErrCode = errorCode(err)
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
+		return
+	}
+
+	resp.Results = make([]*GetArticlesResult, len(remoteArticles))
+	for i, remoteArticle := range remoteArticles {
+		result := &GetArticlesResult{ErrCode: ErrCodeSuccess, ErrMsg: ErrMsgSuccess}
+		if errs[i] != nil {
+			result.ErrCode = errorCode(errs[i])
+			result.ErrMsg = errs[i].Error()
+		} else {
+			result.RemoteArticle = remoteArticle
+		}
+		resp.Results[i] = result
+	}
+
+	c.JSON(httpStatus(resp.ErrCode), resp)
+}
+
+func (this *APIServer) addArticlesHandler(c *gin.Context) {
+	resp := &AddArticlesResp{}
+	resp.ErrCode = ErrCodeSuccess
+	resp.ErrMsg = ErrMsgSuccess
+
+	var req AddArticlesReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		resp.ErrCode = ErrCodeInvalidArgument
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
+		return
+	}
+
+	articleIds, customArticleIds, errs, err := this.local.AddArticlesContext(c.Request.Context(), req.Articles)
+	if err != nil {
+		resp.ErrCode = errorCode(err)
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
+		return
+	}
+
+	resp.Results = make([]*AddArticlesResult, len(articleIds))
+	for i := range articleIds {
+		result := &AddArticlesResult{}
+		result.ErrCode = ErrCodeSuccess
+		result.ErrMsg = ErrMsgSuccess
+		result.ArticleId = articleIds[i]
+		result.CustomArticleId = customArticleIds[i]
+		if errs[i] != nil {
+			result.ErrCode = errorCode(errs[i])
+			result.ErrMsg = errs[i].Error()
+		}
+		resp.Results[i] = result
+	}
+
+	c.JSON(httpStatus(resp.ErrCode), resp)
+}
+
+func newRemoteTagPage(page *gmodel.Page[*gmodel.Tag]) gmodel.Page[*RemoteTag] {
+	remoteTags := make([]*RemoteTag, 0)
+	for _, tag := range page.Items {
+		remoteTags = append(remoteTags, &RemoteTag{
+			Tag: tag,
+		})
+	}
+
+	return gmodel.Page[*RemoteTag]{
+		Items:      remoteTags,
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+	}
+}
+
+// "asc" 表示按ID从小到大，其他都表示从大到小
+func parseOrder(order string) gmodel.Order {
+	if order == "asc" {
+		return gmodel.OrderAsc
+	}
+	return gmodel.OrderDesc
+}
+
+func (this *APIServer) getCustomIdHandler(c *gin.Context) {
+	resp := &GetCustomIdResp{}
+	resp.ErrCode = ErrCodeSuccess
+	resp.ErrMsg = ErrMsgSuccess
+
+	var req GetCustomIdReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		resp.ErrCode = ErrCodeInvalidArgument
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
+		return
+	}
+
+	customArticleId, err := this.local.GetCustomIdContext(c.Request.Context(), req.ArticleId)
+	if err != nil {
+		resp.ErrCode = errorCode(err)
+		resp.ErrMsg = err.Error()
+	} else {
+		resp.ArticleId = req.ArticleId
+		resp.CustomArticleId = customArticleId
+	}
+
+	c.JSON(httpStatus(resp.ErrCode), resp)
+}
+
+func (this *APIServer) resolveCustomIdHandler(c *gin.Context) {
+	resp := &ResolveCustomIdResp{}
+	resp.ErrCode = ErrCodeSuccess
+	resp.ErrMsg = ErrMsgSuccess
+
+	var req ResolveCustomIdReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		resp.ErrCode = ErrCodeInvalidArgument
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
+		return
+	}
+
+	articleId, customArticleId, err := this.local.ResolveCustomIdContext(c.Request.Context(), req.CustomArticleId)
+	if err != nil {
+		resp.ErrCode = errorCode(err)
+		resp.ErrMsg = err.Error()
+	} else {
+		resp.ArticleId = articleId
+		resp.CustomArticleId = customArticleId
+	}
+
+	c.JSON(httpStatus(resp.ErrCode), resp)
+}
+
+func (this *APIServer) setCustomIdHandler(c *gin.Context) {
+	resp := &SetCustomIdResp{}
+	resp.ErrCode = ErrCodeSuccess
+	resp.ErrMsg = ErrMsgSuccess
+
+	var req SetCustomIdReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		resp.ErrCode = ErrCodeInvalidArgument
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
+		return
+	}
+
+	err := this.local.SetCustomIdContext(c.Request.Context(), req.ArticleId, req.CustomArticleId)
+	if err != nil {
+		resp.ErrCode = errorCode(err)
+		resp.ErrMsg = err.Error()
+	}
+
+	c.JSON(httpStatus(resp.ErrCode), resp)
+}
+
+func (this *APIServer) updateCustomIdHandler(c *gin.Context) {
+	resp := &UpdateCustomIdResp{}
+	resp.ErrCode = ErrCodeSuccess
+	resp.ErrMsg = ErrMsgSuccess
+
+	var req UpdateCustomIdReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		resp.ErrCode = ErrCodeInvalidArgument
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
+		return
+	}
+
+	redirect, err := this.local.UpdateCustomIdContext(c.Request.Context(), req.ArticleId, req.CustomArticleId, req.NewCustomArticleId)
+	if err != nil {
+		resp.ErrCode = errorCode(err)
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
+		return
+	}
+
+	resp.CustomArticleId = req.NewCustomArticleId
+	resp.ArticleId, _ = this.idMgr.GetIntId(req.NewCustomArticleId)
+	if redirect != nil {
+		resp.RedirectFrom = redirect.From
+		resp.RedirectExpireAt = redirect.ExpireAt.Unix()
+	}
+
+	c.JSON(httpStatus(resp.ErrCode), resp)
+}
+
+func (this *APIServer) addTagHandler(c *gin.Context) {
+	resp := &AddTagResp{}
+	resp.ErrCode = ErrCodeSuccess
+	resp.ErrMsg = ErrMsgSuccess
+
+	var req AddTagReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		resp.ErrCode = ErrCodeInvalidArgument
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
+		return
+	}
+
+	remoteTag, err := this.local.AddTagContext(c.Request.Context(), req.Taxonomy, req.TagName)
+	if err != nil {
+		resp.ErrCode = errorCode(err)
+		resp.ErrMsg = err.Error()
+	} else {
+		resp.RemoteTag = remoteTag
+	}
+
+	c.JSON(httpStatus(resp.ErrCode), resp)
+}
+
+func (this *APIServer) deleteTagHandler(c *gin.Context) {
+	resp := &DeleteTagResp{}
+	resp.ErrCode = ErrCodeSuccess
+	resp.ErrMsg = ErrMsgSuccess
+
+	var req DeleteTagReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		resp.ErrCode = ErrCodeInvalidArgument
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
+		return
+	}
+
+	err := this.local.DeleteTagContext(c.Request.Context(), req.Taxonomy, req.TagName)
+	if err != nil {
+		resp.ErrCode = errorCode(err)
+		resp.ErrMsg = err.Error()
+	}
+
+	c.JSON(httpStatus(resp.ErrCode), resp)
+}
+
+func (this *APIServer) getTagCountHandler(c *gin.Context) {
+	resp := &GetTagCountResp{}
+	resp.ErrCode = ErrCodeSuccess
+	resp.ErrMsg = ErrMsgSuccess
+
+	var req GetTagCountReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		resp.ErrCode = ErrCodeInvalidArgument
+		resp.ErrMsg = err.Error()
+		c.JSON(httpStatus(resp.ErrCode), resp)
+		return
+	}
+
+	tagCount, err := this.local.GetTermCountContext(c.Request.Context(), req.Taxonomy)
+	if err != nil {
+		resp.ErrCode = errorCode(err)
+		resp.ErrMsg = err.Error()
+	} else {
+		resp.TagCount = tagCount
+	}
+
+	c.JSON(httpStatus(resp.ErrCode), resp)
 }
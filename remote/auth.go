@@ -0,0 +1,309 @@
+package remote
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 鉴权
+// 每个请求都要带上签名，签名的内容为：
+//
+//	方法 + "\n" + 路径 + "\n" + 时间戳 + "\n" + 随机数 + "\n" + hex(sha256(请求体))
+//
+// 使用 HMAC-SHA256 和密钥签名，结果用 hex 编码，放在以下请求头中：
+//
+//	X-GModel-Key-Id：密钥ID
+//	X-GModel-Timestamp：Unix时间戳（秒），和服务器的时间误差不能超过 AuthMaxSkew
+//	X-GModel-Nonce：随机字符串，同一个密钥在误差时间内不能重复，用于防止重放
+//	X-GModel-Signature：签名
+//
+// 密钥配置了 AllowBearer 时，也可以直接用 "Authorization: Bearer 密钥" 访问，方便调试，但是没有防重放
+// APIClient 调用 SetAPIKey 之后会自动签名
+
+const (
+	HeaderKeyId     = "X-GModel-Key-Id"
+	HeaderTimestamp = "X-GModel-Timestamp"
+	HeaderNonce     = "X-GModel-Nonce"
+	HeaderSignature = "X-GModel-Signature"
+)
+
+// 时间戳允许的默认误差
+const DefaultAuthMaxSkew = 5 * time.Minute
+
+// 鉴权时读取的请求体默认的最大字节数，批量增加文章的请求也足够
+const DefaultAuthMaxBodySize = 32 << 20
+
+// 角色，权限依次增加，高的角色拥有低的角色的全部权限
+type Role string
+
+const (
+	RoleReader Role = "reader" // 只读，比如网站前台
+	RoleWriter Role = "writer" // 读和增加、修改文章，比如爬虫
+	RoleAdmin  Role = "admin"  // 全部权限，包括删除文章、修改分类
+)
+
+func (this Role) level() int {
+	switch this {
+	case RoleReader:
+		return 1
+	case RoleWriter:
+		return 2
+	case RoleAdmin:
+		return 3
+	}
+	return 0
+}
+
+type APIKeyConfig struct {
+	Id          string
+	Secret      string
+	Role        Role
+	AllowBearer bool // 是否允许不签名，直接用 Authorization: Bearer 密钥 访问
+}
+
+// 每个接口需要的角色，不在这里的接口需要 RoleAdmin
+var apiRoles = map[string]Role{
+	APIGetModelInfo:          RoleReader,
+	APIGetArticle:            RoleReader,
+	APIGetArticles:           RoleReader,
+	APIGetNextArticles:       RoleReader,
+	APIGetPrevArticles:       RoleReader,
+	APIGetNextArticlesByTag:  RoleReader,
+	APIGetPrevArticlesByTag:  RoleReader,
+	APIGetTagById:            RoleReader,
+	APIGetTagByName:          RoleReader,
+	APIGetNextTags:           RoleReader,
+	APIGetPrevTags:           RoleReader,
+	APIGetArticleCountByTag:  RoleReader,
+	APIGetTagBySlug:          RoleReader,
+	APIGetTopTags:            RoleReader,
+	APISearchTags:            RoleReader,
+	APIGetNextArticlesByTerm: RoleReader,
+	APIGetPrevArticlesByTerm: RoleReader,
+	APIGetArticlesByTagPage:  RoleReader,
+	APIListArticles:          RoleReader,
+	APIListArticlesByTag:     RoleReader,
+	APIListTags:              RoleReader,
+	APIListTopTags:           RoleReader,
+	APIGetCustomId:           RoleReader,
+	APIResolveCustomId:       RoleReader,
+	APIGetTagCount:           RoleReader,
+
+	APIAddArticle:    RoleWriter,
+	APIAddArticles:   RoleWriter,
+	APIUpdateArticle: RoleWriter,
+	APIUpsertArticle: RoleWriter,
+	APIAddTag:        RoleWriter,
+
+	APIDeleteArticle: RoleAdmin,
+	APIRenameTag:     RoleAdmin,
+	APIUpdateTagMeta: RoleAdmin,
+	APIDeleteTag:     RoleAdmin,
+	APISetCustomId:   RoleAdmin,
+
+	APIUpdateCustomId: RoleAdmin,
+}
+
+// 检查密钥配置
+func checkAPIKeys(keys []APIKeyConfig) error {
+	ids := make(map[string]bool)
+	for i, key := range keys {
+		if key.Id == "" || key.Secret == "" {
+			return errors.New(fmt.Sprintf("APIKeys[%v] id and secret must not empty", i))
+		}
+		if ids[key.Id] {
+			return errors.New(fmt.Sprintf("APIKeys[%v] id [%v] is duplicated", i, key.Id))
+		}
+		if key.Role.level() == 0 {
+			return errors.New(fmt.Sprintf("APIKeys[%v] role [%v] is invalid, want reader, writer or admin", i, key.Role))
+		}
+		ids[key.Id] = true
+	}
+	return nil
+}
+
+// 计算签名
+func signRequest(secret, method, path, timestamp, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(method + "\n" + path + "\n" + timestamp + "\n" + nonce + "\n" + hex.EncodeToString(bodyHash[:])))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// 给请求加上签名
+func setRequestSignature(req *http.Request, keyId, secret string, body []byte) {
+	nonceBytes := make([]byte, 16)
+	rand.Read(nonceBytes)
+	nonce := hex.EncodeToString(nonceBytes)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req.Header.Set(HeaderKeyId, keyId)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderNonce, nonce)
+	req.Header.Set(HeaderSignature, signRequest(secret, req.Method, req.URL.Path, timestamp, nonce, body))
+}
+
+// 记录用过的随机数，过期之后删除，时间戳已经过期的请求本来就会被拒绝
+type nonceCache struct {
+	nonces    map[string]time.Time // 随机数 -> 过期时间
+	lastSweep time.Time
+	mutex     sync.Mutex
+}
+
+// 随机数没有用过时记录下来并返回true
+func (this *nonceCache) add(nonce string, now time.Time, ttl time.Duration) bool {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.nonces == nil {
+		this.nonces = make(map[string]time.Time)
+	}
+
+	// 定期清理过期的随机数
+	if now.Sub(this.lastSweep) > ttl {
+		for key, expire := range this.nonces {
+			if now.After(expire) {
+				delete(this.nonces, key)
+			}
+		}
+		this.lastSweep = now
+	}
+
+	if expire, exist := this.nonces[nonce]; exist && !now.After(expire) {
+		return false
+	}
+	this.nonces[nonce] = now.Add(ttl)
+	return true
+}
+
+// 运行中可以替换，详见 APIServer.Reload
+type authenticator struct {
+	keys        []APIKeyConfig
+	maxSkew     time.Duration
+	maxBodySize int64
+}
+
+func newAuthenticator(config *APIServerConfig) *authenticator {
+	maxBodySize := config.AuthMaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultAuthMaxBodySize
+	}
+	return &authenticator{
+		keys:        config.APIKeys,
+		maxSkew:     durationOrDefault(config.AuthMaxSkew, DefaultAuthMaxSkew),
+		maxBodySize: maxBodySize,
+	}
+}
+
+// 返回请求的密钥，失败时返回的错误可以直接给调用方看
+func (this *authenticator) authenticate(req *http.Request, body []byte, nonces *nonceCache) (*APIKeyConfig, error) {
+	// 不签名，直接带密钥，每个密钥都比较一次，避免泄露是哪个密钥
+	if auth := req.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		secret := []byte(strings.TrimPrefix(auth, "Bearer "))
+		var found *APIKeyConfig
+		for i := range this.keys {
+			if subtle.ConstantTimeCompare(secret, []byte(this.keys[i].Secret)) == 1 && this.keys[i].AllowBearer {
+				found = &this.keys[i]
+			}
+		}
+		if found == nil {
+			return nil, errors.New("Invalid bearer token")
+		}
+		return found, nil
+	}
+
+	keyId := req.Header.Get(HeaderKeyId)
+	timestamp := req.Header.Get(HeaderTimestamp)
+	nonce := req.Header.Get(HeaderNonce)
+	signature := req.Header.Get(HeaderSignature)
+	if keyId == "" || timestamp == "" || nonce == "" || signature == "" {
+		return nil, errors.New("Missing authentication headers")
+	}
+
+	var key *APIKeyConfig
+	for i := range this.keys {
+		if this.keys[i].Id == keyId {
+			key = &this.keys[i]
+			break
+		}
+	}
+	if key == nil {
+		return nil, errors.New("Invalid key id")
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, errors.New("Invalid timestamp")
+	}
+	now := time.Now()
+	if skew := now.Sub(time.Unix(seconds, 0)); skew > this.maxSkew || skew < -this.maxSkew {
+		return nil, errors.New("Timestamp expired")
+	}
+
+	expected := signRequest(key.Secret, req.Method, req.URL.Path, timestamp, nonce, body)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return nil, errors.New("Invalid signature")
+	}
+
+	// 签名正确之后再记录随机数，避免随便发的请求占用内存
+	// 时间戳在前后 maxSkew 内都有效，所以随机数需要保存 2*maxSkew
+	if !nonces.add(keyId+"\n"+nonce, now, 2*this.maxSkew) {
+		return nil, errors.New("Nonce already used")
+	}
+
+	return key, nil
+}
+
+// 鉴权中间件，没有配置密钥时不鉴权
+func (this *APIServer) authHandler(c *gin.Context) {
+	auth := this.auth.Load()
+	if len(auth.keys) == 0 {
+		c.Next()
+		return
+	}
+
+	// 读取请求体用于签名，再放回去给后面的处理函数，超过 maxBodySize 时返回413，避免占用大量内存
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, auth.maxBodySize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, &BaseResp{ErrCode: ErrCodeInvalidArgument, ErrMsg: fmt.Sprintf("Request body too large, max is %v bytes", auth.maxBodySize)})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusBadRequest, &BaseResp{ErrCode: ErrCodeInvalidArgument, ErrMsg: err.Error()})
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	key, err := auth.authenticate(c.Request, body, &this.nonces)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, &BaseResp{ErrCode: ErrCodeUnauthorized, ErrMsg: err.Error()})
+		return
+	}
+
+	role, ok := apiRoles[c.Request.URL.Path]
+	if !ok {
+		role = RoleAdmin
+	}
+	if key.Role.level() < role.level() {
+		c.AbortWithStatusJSON(http.StatusForbidden, &BaseResp{ErrCode: ErrCodeForbidden, ErrMsg: fmt.Sprintf("Role [%v] is not allowed", key.Role)})
+		return
+	}
+
+	c.Next()
+}
@@ -0,0 +1,48 @@
+package remote
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gansidui/gmodel"
+)
+
+// gmodel 的错误类型和 errcode 一一对应，服务器用 errorCode 转换，APIClient 用 APIError.Unwrap 转换回来
+var errorKinds = map[int]error{
+	ErrCodeNotFound:        gmodel.ErrNotFound,
+	ErrCodeExists:          gmodel.ErrExists,
+	ErrCodeInvalidArgument: gmodel.ErrInvalidArgument,
+	ErrCodeReadOnly:        gmodel.ErrReadOnly,
+	ErrCodeIdExhausted:     gmodel.ErrIdExhausted,
+}
+
+// 返回错误对应的 errcode，没有错误类型的返回 ErrCodeFailed
+func errorCode(err error) int {
+	for errCode, kind := range errorKinds {
+		if errors.Is(err, kind) {
+			return errCode
+		}
+	}
+	return ErrCodeFailed
+}
+
+// 返回 errcode 对应的HTTP状态码
+func httpStatus(errCode int) int {
+	switch errCode {
+	case ErrCodeSuccess:
+		return http.StatusOK
+	case ErrCodeNotFound:
+		return http.StatusNotFound
+	case ErrCodeExists:
+		return http.StatusConflict
+	case ErrCodeInvalidArgument:
+		return http.StatusBadRequest
+	case ErrCodeReadOnly, ErrCodeForbidden:
+		return http.StatusForbidden
+	case ErrCodeUnauthorized:
+		return http.StatusUnauthorized
+	case ErrCodeIdExhausted:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
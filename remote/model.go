@@ -0,0 +1,775 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/gansidui/gmodel"
+)
+
+// 网站常用的操作，嵌入时使用 LocalModel，和服务器分离时使用 APIClient，网站代码只需要写一遍
+// 文章统一使用 RemoteArticle，带有自定义文章ID和分类名称
+// articleId 和 customArticleId 的规则和API一样：customArticleId 优先，customArticleId 为空时才使用 articleId
+// order 为 "asc" 或者 "desc"，默认为 "desc"
+type Model interface {
+	// 返回文章数量、分类数量、最大的文章ID
+	GetModelInfoContext(ctx context.Context) (uint64, uint64, uint64, error)
+
+	GetArticleContext(ctx context.Context, articleId uint64, customArticleId string) (*RemoteArticle, error)
+
+	// 返回的文章和错误都和 ids 一一对应，获取失败时文章为nil，最后一个返回值表示整个请求是否失败
+	GetArticlesContext(ctx context.Context, ids []GetArticleReq) ([]*RemoteArticle, []error, error)
+
+	// customArticleId 为空时自动生成，返回文章ID、自定义文章ID
+	AddArticleContext(ctx context.Context, tags []string, terms map[string][]string, data string, customArticleId string) (uint64, string, error)
+
+	// 批量增加文章，每一项的参数同 AddArticleContext，最多 MaxAddArticlesCount 项
+	// 返回的文章ID、自定义文章ID和错误都和 articles 一一对应，某一项失败不影响其他项，最后一个返回值表示整个请求是否失败
+	// 没有保存自定义ID的文章会被删除，失败的项文章ID为0，重试时不会重复增加
+	AddArticlesContext(ctx context.Context, articles []AddArticleReq) ([]uint64, []string, []error, error)
+
+	// newTerms 中没有出现的分类法保持不变
+	UpdateArticleContext(ctx context.Context, articleId uint64, customArticleId string, newTags []string, newTerms map[string][]string, newData string) error
+
+	DeleteArticleContext(ctx context.Context, articleId uint64, customArticleId string) error
+
+	// 按自定义文章ID增加或者修改文章，customArticleId 不能为空，用于爬虫等重复提交的场景
+	// 自定义文章ID不存在时增加文章，否则修改文章，内容没有变化时不修改，newTerms 中没有出现的分类法保持不变
+	UpsertArticleContext(ctx context.Context, tags []string, terms map[string][]string, data string, customArticleId string) (uint64, gmodel.UpsertResult, error)
+
+	ListArticlesContext(ctx context.Context, cursor string, n int, order string) (*gmodel.Page[*RemoteArticle], error)
+
+	// taxonomy 为空表示按分类查找，否则表示按指定分类法的分类查找
+	ListArticlesByTagContext(ctx context.Context, taxonomy string, tagName string, cursor string, n int, order string) (*gmodel.Page[*RemoteArticle], error)
+
+	GetTagByNameContext(ctx context.Context, name string) (*RemoteTag, error)
+
+	GetTagBySlugContext(ctx context.Context, slug string) (*RemoteTag, error)
+
+	ListTagsContext(ctx context.Context, cursor string, n int, order string) (*gmodel.Page[*RemoteTag], error)
+
+	ListTopTagsContext(ctx context.Context, cursor string, n int) (*gmodel.Page[*RemoteTag], error)
+
+	// 按名称前缀查找分类，ignoreCase 为 true 时忽略大小写
+	SearchTagsContext(ctx context.Context, prefix string, cursor string, n int, ignoreCase bool) (*gmodel.Page[*RemoteTag], error)
+
+	RenameTagContext(ctx context.Context, oldName, newName string) error
+
+	// taxonomy 为空表示分类，否则表示指定分类法的分类，下同
+	AddTagContext(ctx context.Context, taxonomy string, name string) (*RemoteTag, error)
+
+	// 分类下的文章不会删除，只是不再属于该分类
+	DeleteTagContext(ctx context.Context, taxonomy string, name string) error
+
+	GetTermCountContext(ctx context.Context, taxonomy string) (uint64, error)
+
+	// 文章没有自定义文章ID时返回 gmodel.ErrNotFound
+	GetCustomIdContext(ctx context.Context, articleId uint64) (string, error)
+
+	// 返回文章ID和当前的自定义文章ID，customArticleId 是还在重定向的旧的自定义文章ID时两者不同
+	ResolveCustomIdContext(ctx context.Context, customArticleId string) (uint64, string, error)
+
+	// 给还没有自定义文章ID的文章设置自定义文章ID，文章已经有自定义文章ID（修改使用 UpdateCustomIdContext）
+	// 或者 customArticleId 已经被其他文章使用时返回 gmodel.ErrExists
+	SetCustomIdContext(ctx context.Context, articleId uint64, customArticleId string) error
+
+	// 修改文章的自定义文章ID，文章需要已经有自定义文章ID，旧的自定义文章ID在一段时间内重定向到新的
+	// 返回的重定向为nil表示没有保留重定向
+	UpdateCustomIdContext(ctx context.Context, articleId uint64, customArticleId string, newCustomArticleId string) (*gmodel.IdRedirect, error)
+}
+
+var (
+	_ Model = (*LocalModel)(nil)
+	_ Model = (*APIClient)(nil)
+)
+
+// 删除文章时自定义ID的处理方式
+type CustomIdPolicy string
+
+const (
+	// 删除自定义ID，之后可以被新文章使用，比如用标题作为自定义ID去重时，删除之后可以重新发布
+	CustomIdRemove CustomIdPolicy = "remove"
+
+	// 保留墓碑，自定义ID查不到文章，也不能再被使用，避免旧的链接指向新的文章
+	CustomIdTombstone CustomIdPolicy = "tombstone"
+)
+
+// 没有ID数据库（APIServerConfig.IdSecret 不为空并且 IdDBPath 为空）时不能使用自定义ID
+var errCustomIdStateless = &gmodel.Error{Kind: gmodel.ErrReadOnly, Msg: "CustomArticleId is not supported without IdDBPath"}
+
+func checkCustomIdPolicy(policy CustomIdPolicy) error {
+	switch policy {
+	case "", CustomIdRemove, CustomIdTombstone:
+		return nil
+	}
+	return errors.New(fmt.Sprintf("CustomIdPolicy [%v] is invalid, want remove or tombstone", policy))
+}
+
+// 直接使用本地的 GModel 和 IdMgr，结果和通过 APIServer 访问一样，APIServer 内部也是用它实现的
+// 本地操作很快，context 只在开始时检查是否已经取消
+type LocalModel struct {
+	model *gmodel.GModel
+	idMgr *gmodel.IdMgr
+
+	customIdPolicy CustomIdPolicy
+
+	// 增加、修改、删除自定义ID的操作（检查自定义ID、保存文章、保存映射）在这把锁内完成，
+	// 同一个自定义ID不会同时增加两篇文章，也不用先保存文章再因为冲突删除
+	customIdMutex sync.Mutex
+}
+
+// model 和 idMgr 需要已经打开，LocalModel 不负责关闭
+func NewLocalModel(model *gmodel.GModel, idMgr *gmodel.IdMgr) *LocalModel {
+	return &LocalModel{
+		model: model,
+		idMgr: idMgr,
+	}
+}
+
+// 设置删除文章时自定义ID的处理方式，默认为 CustomIdRemove，需要在使用之前设置
+func (this *LocalModel) SetCustomIdPolicy(policy CustomIdPolicy) error {
+	if err := checkCustomIdPolicy(policy); err != nil {
+		return err
+	}
+	this.customIdPolicy = policy
+	return nil
+}
+
+func (this *LocalModel) GetModelInfoContext(ctx context.Context) (uint64, uint64, uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, 0, 0, err
+	}
+	return this.model.GetArticleCount(), this.model.GetTagCount(), this.model.GetMaxArticleId(), nil
+}
+
+// 自定义ID优先，自定义ID不存在时返回错误
+func (this *LocalModel) getArticleId(articleId uint64, customArticleId string) (uint64, error) {
+	if customArticleId == "" {
+		return articleId, nil
+	}
+	if intId, ok := this.idMgr.GetIntId(customArticleId); ok {
+		return intId, nil
+	}
+	return 0, &gmodel.Error{Kind: gmodel.ErrNotFound, Msg: fmt.Sprintf("CustomArticleId[%v] not found", customArticleId)}
+}
+
+func (this *LocalModel) GetArticleContext(ctx context.Context, articleId uint64, customArticleId string) (*RemoteArticle, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	articleId, err := this.getArticleId(articleId, customArticleId)
+	if err != nil {
+		return nil, err
+	}
+
+	article, err := this.model.GetArticle(articleId)
+	if err != nil {
+		return nil, fmt.Errorf("GetArticle failed: %w", err)
+	}
+
+	return this.newRemoteArticles([]*gmodel.Article{article})[0], nil
+}
+
+func (this *LocalModel) GetArticlesContext(ctx context.Context, ids []GetArticleReq) ([]*RemoteArticle, []error, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	if len(ids) > MaxGetArticlesCount {
+		return nil, nil, &gmodel.Error{Kind: gmodel.ErrInvalidArgument, Msg: fmt.Sprintf("Too many ids, max is %v", MaxGetArticlesCount)}
+	}
+
+	// 批量将自定义ID转换成文章ID
+	customArticleIds := make([]string, 0)
+	for _, id := range ids {
+		if id.CustomArticleId != "" {
+			customArticleIds = append(customArticleIds, id.CustomArticleId)
+		}
+	}
+	intIds := this.idMgr.GetIntIds(customArticleIds)
+
+	// 自定义ID不存在的项直接返回错误，不再获取文章
+	results := make([]*RemoteArticle, len(ids))
+	resultErrs := make([]error, len(ids))
+	articleIds := make([]uint64, 0, len(ids))
+	indexes := make([]int, 0, len(ids))
+	for i, id := range ids {
+		articleId := id.ArticleId
+		if id.CustomArticleId != "" {
+			intId, exist := intIds[id.CustomArticleId]
+			if !exist {
+				resultErrs[i] = &gmodel.Error{Kind: gmodel.ErrNotFound, Msg: fmt.Sprintf("CustomArticleId[%v] not found", id.CustomArticleId)}
+				continue
+			}
+			articleId = intId
+		}
+		articleIds = append(articleIds, articleId)
+		indexes = append(indexes, i)
+	}
+
+	articles, errs := this.model.GetArticles(articleIds)
+
+	found := make([]*gmodel.Article, 0, len(articles))
+	for _, article := range articles {
+		if article != nil {
+			found = append(found, article)
+		}
+	}
+	remoteArticles := this.newRemoteArticles(found)
+
+	for j, article := range articles {
+		i := indexes[j]
+		if article == nil {
+			resultErrs[i] = fmt.Errorf("GetArticle failed: %w", errs[j])
+		} else {
+			results[i] = remoteArticles[0]
+			remoteArticles = remoteArticles[1:]
+		}
+	}
+
+	return results, resultErrs, nil
+}
+
+func (this *LocalModel) AddArticleContext(ctx context.Context, tags []string, terms map[string][]string, data string, customArticleId string) (uint64, string, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, "", err
+	}
+
+	// 判断自定义ID是否已经存在，保留了墓碑的也不能再使用
+	if customArticleId != "" {
+		this.customIdMutex.Lock()
+		defer this.customIdMutex.Unlock()
+
+		if this.idMgr.Stateless() {
+			return 0, "", errCustomIdStateless
+		}
+		if this.idMgr.HasStringId(customArticleId) {
+			return 0, "", &gmodel.Error{Kind: gmodel.ErrExists, Msg: "CustomArticleId is exist"}
+		}
+	}
+
+	// 保存新文章
+	articleId, err := this.model.AddArticleWithTerms(tags, terms, data)
+	if err != nil {
+		return 0, "", fmt.Errorf("AddArticle failed: %w", err)
+	}
+
+	// 自定义ID冲突时文章已经删除，其他错误时文章已经保存，仍然返回文章ID
+	customArticleId, err = this.bindCustomId(articleId, customArticleId)
+	if errors.Is(err, gmodel.ErrExists) {
+		return 0, "", err
+	}
+	return articleId, customArticleId, err
+}
+
+func (this *LocalModel) AddArticlesContext(ctx context.Context, articles []AddArticleReq) ([]uint64, []string, []error, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, nil, err
+	}
+	if len(articles) > MaxAddArticlesCount {
+		return nil, nil, nil, &gmodel.Error{Kind: gmodel.ErrInvalidArgument, Msg: fmt.Sprintf("Too many articles, max is %v", MaxAddArticlesCount)}
+	}
+
+	articleIds := make([]uint64, len(articles))
+	customArticleIds := make([]string, len(articles))
+	errs := make([]error, len(articles))
+
+	// 和 AddArticleContext 一样，检查自定义ID、保存文章、保存映射在一把锁内完成
+	this.customIdMutex.Lock()
+	defer this.customIdMutex.Unlock()
+
+	// 判断自定义ID是否已经存在（包括墓碑和重定向），批量中重复的自定义ID也算已经存在
+	seen := make(map[string]bool)
+	newArticles := make([]gmodel.NewArticle, 0, len(articles))
+	indexes := make([]int, 0, len(articles))
+	for i, article := range articles {
+		if article.CustomArticleId != "" {
+			if this.idMgr.Stateless() {
+				errs[i] = errCustomIdStateless
+				continue
+			}
+			if seen[article.CustomArticleId] || this.idMgr.HasStringId(article.CustomArticleId) {
+				errs[i] = &gmodel.Error{Kind: gmodel.ErrExists, Msg: "CustomArticleId is exist"}
+				continue
+			}
+			seen[article.CustomArticleId] = true
+		}
+
+		newArticles = append(newArticles, gmodel.NewArticle{
+			Tags:  article.Tags,
+			Terms: article.Terms,
+			Data:  article.Data,
+		})
+		indexes = append(indexes, i)
+	}
+
+	// 保存新文章，已经保存、但是索引写入失败的文章也保存自定义ID，避免重试时重复增加
+	ids, addErrs := this.model.AddArticles(newArticles)
+	intIds := make([]uint64, 0, len(ids))
+	stringIds := make([]string, 0, len(ids))
+	added := make([]int, 0, len(ids))
+	for j, i := range indexes {
+		if addErrs[j] != nil {
+			errs[i] = fmt.Errorf("AddArticle failed: %w", addErrs[j])
+		}
+		if ids[j] == 0 {
+			continue
+		}
+		articleIds[i] = ids[j]
+		intIds = append(intIds, ids[j])
+		stringIds = append(stringIds, articles[i].CustomArticleId)
+		added = append(added, i)
+	}
+
+	// 批量保存自定义ID，为空的自动生成
+	result, err := this.idMgr.AddIdMaps(intIds, stringIds)
+	for k, i := range added {
+		if err == nil {
+			customArticleIds[i] = result[k]
+			continue
+		}
+
+		// 整批都没有写入，冲突时改为逐个保存，冲突的文章会被删除
+		itemErr := fmt.Errorf("AddIdMaps failed: %w", err)
+		if errors.Is(err, gmodel.ErrExists) {
+			customArticleIds[i], itemErr = this.bindCustomId(intIds[k], stringIds[k])
+		}
+		if itemErr != nil {
+			// 没有自定义ID的文章删除，返回的文章ID为0，重试时不会重复增加
+			if !errors.Is(itemErr, gmodel.ErrExists) {
+				this.model.DeleteArticle(intIds[k])
+			}
+			articleIds[i] = 0
+			errs[i] = itemErr
+		}
+	}
+
+	return articleIds, customArticleIds, errs, nil
+}
+
+// 保存新文章的自定义ID，为空时自动生成，指定了自定义ID时调用者需要持有 customIdMutex
+// 绕过 LocalModel 直接修改 IdMgr 时仍然可能冲突，冲突时删除刚保存的文章，返回 gmodel.ErrExists
+func (this *LocalModel) bindCustomId(articleId uint64, customArticleId string) (string, error) {
+	if customArticleId == "" {
+		customArticleId, err := this.idMgr.AddIntId(articleId)
+		if err != nil {
+			return "", fmt.Errorf("AddIntId failed: %w", err)
+		}
+		return customArticleId, nil
+	}
+
+	if err := this.idMgr.SetIdMap(articleId, customArticleId); err != nil {
+		if errors.Is(err, gmodel.ErrExists) {
+			this.model.DeleteArticle(articleId)
+		}
+		return "", fmt.Errorf("SetIdMap failed: %w", err)
+	}
+	return customArticleId, nil
+}
+
+func (this *LocalModel) UpdateArticleContext(ctx context.Context, articleId uint64, customArticleId string, newTags []string, newTerms map[string][]string, newData string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	articleId, err := this.getArticleId(articleId, customArticleId)
+	if err != nil {
+		return err
+	}
+
+	// 只修改 newTerms 中出现的分类法，有分类法没有打开时什么都不修改
+	if err := this.model.UpdateArticleWithTerms(articleId, newTags, newTerms, newData); err != nil {
+		return fmt.Errorf("UpdateArticle failed: %w", err)
+	}
+	return nil
+}
+
+func (this *LocalModel) UpsertArticleContext(ctx context.Context, tags []string, terms map[string][]string, data string, customArticleId string) (uint64, gmodel.UpsertResult, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	if customArticleId == "" {
+		return 0, 0, &gmodel.Error{Kind: gmodel.ErrInvalidArgument, Msg: "CustomArticleId must not empty"}
+	}
+
+	// 查找、修改或者增加文章、保存自定义ID在一把锁内完成，不会和其他请求交叉
+	this.customIdMutex.Lock()
+	defer this.customIdMutex.Unlock()
+
+	articleId, ok := this.idMgr.GetIntId(customArticleId)
+	if !ok {
+		// 旧的自定义文章ID还在重定向时，修改重定向到的文章
+		if redirect, found := this.idMgr.GetRedirect(customArticleId); found {
+			articleId, ok = redirect.IntId, true
+		}
+	}
+	if ok {
+		// codec 转换的字符串ID对应的文章还不存在（或者已经删除）时，也不能给其他文章使用
+		if _, err := this.model.GetArticle(articleId); errors.Is(err, gmodel.ErrNotFound) {
+			return 0, 0, &gmodel.Error{Kind: gmodel.ErrExists, Msg: fmt.Sprintf("CustomArticleId[%v] is reserved for Article ID[%v]", customArticleId, articleId)}
+		}
+		articleId, result, err := this.model.UpsertArticle(articleId, tags, terms, data)
+		if err != nil {
+			return 0, 0, fmt.Errorf("UpsertArticle failed: %w", err)
+		}
+		return articleId, result, nil
+	}
+
+	// 保留了墓碑的自定义ID不能再使用
+	if this.idMgr.HasStringId(customArticleId) {
+		return 0, 0, &gmodel.Error{Kind: gmodel.ErrExists, Msg: "CustomArticleId is deleted"}
+	}
+	if this.idMgr.Stateless() {
+		return 0, 0, errCustomIdStateless
+	}
+
+	articleId, _, err := this.model.UpsertArticle(0, tags, terms, data)
+	if err != nil {
+		return 0, 0, fmt.Errorf("UpsertArticle failed: %w", err)
+	}
+	// 和 AddArticleContext 一样，自定义ID冲突以外的错误时文章已经保存，仍然返回文章ID
+	if _, err = this.bindCustomId(articleId, customArticleId); errors.Is(err, gmodel.ErrExists) {
+		return 0, 0, err
+	}
+	return articleId, gmodel.UpsertCreated, err
+}
+
+func (this *LocalModel) DeleteArticleContext(ctx context.Context, articleId uint64, customArticleId string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	this.customIdMutex.Lock()
+	defer this.customIdMutex.Unlock()
+
+	articleId, err := this.getArticleId(articleId, customArticleId)
+	if err != nil {
+		return err
+	}
+
+	if err := this.model.DeleteArticle(articleId); err != nil {
+		return fmt.Errorf("DeleteArticle failed: %w", err)
+	}
+
+	// 文章已经删除，没有自定义ID的文章不算错误
+	err = this.idMgr.DeleteByIntId(articleId, this.customIdPolicy == CustomIdTombstone)
+	if err != nil && !errors.Is(err, gmodel.ErrNotFound) {
+		return fmt.Errorf("DeleteByIntId failed: %w", err)
+	}
+	return nil
+}
+
+func (this *LocalModel) ListArticlesContext(ctx context.Context, cursor string, n int, order string) (*gmodel.Page[*RemoteArticle], error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	page, err := this.model.ListArticles(cursor, n, parseOrder(order))
+	if err != nil {
+		return nil, fmt.Errorf("ListArticles failed: %w", err)
+	}
+
+	remotePage := this.newRemoteArticlePage(page)
+	return &remotePage, nil
+}
+
+func (this *LocalModel) ListArticlesByTagContext(ctx context.Context, taxonomy string, tagName string, cursor string, n int, order string) (*gmodel.Page[*RemoteArticle], error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var page *gmodel.Page[*gmodel.Article]
+	var err error
+	if taxonomy == "" {
+		page, err = this.model.ListArticlesByTag(tagName, cursor, n, parseOrder(order))
+	} else {
+		page, err = this.model.ListArticlesByTerm(taxonomy, tagName, cursor, n, parseOrder(order))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ListArticlesByTag failed: %w", err)
+	}
+
+	remotePage := this.newRemoteArticlePage(page)
+	return &remotePage, nil
+}
+
+func (this *LocalModel) GetTagByNameContext(ctx context.Context, name string) (*RemoteTag, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	tag, err := this.model.GetTagByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("GetTagByName failed: %w", err)
+	}
+	return &RemoteTag{Tag: tag}, nil
+}
+
+func (this *LocalModel) GetTagBySlugContext(ctx context.Context, slug string) (*RemoteTag, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	tag, err := this.model.GetTagBySlug(slug)
+	if err != nil {
+		return nil, fmt.Errorf("GetTagBySlug failed: %w", err)
+	}
+	return &RemoteTag{Tag: tag}, nil
+}
+
+func (this *LocalModel) ListTagsContext(ctx context.Context, cursor string, n int, order string) (*gmodel.Page[*RemoteTag], error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	page, err := this.model.ListTags(cursor, n, parseOrder(order))
+	if err != nil {
+		return nil, fmt.Errorf("ListTags failed: %w", err)
+	}
+
+	remotePage := newRemoteTagPage(page)
+	return &remotePage, nil
+}
+
+func (this *LocalModel) ListTopTagsContext(ctx context.Context, cursor string, n int) (*gmodel.Page[*RemoteTag], error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	page, err := this.model.ListTopTags(cursor, n)
+	if err != nil {
+		return nil, fmt.Errorf("ListTopTags failed: %w", err)
+	}
+
+	remotePage := newRemoteTagPage(page)
+	return &remotePage, nil
+}
+
+func (this *LocalModel) SearchTagsContext(ctx context.Context, prefix string, cursor string, n int, ignoreCase bool) (*gmodel.Page[*RemoteTag], error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	page, err := this.model.SearchTagsByPrefix(prefix, cursor, n, ignoreCase)
+	if err != nil {
+		return nil, fmt.Errorf("SearchTagsByPrefix failed: %w", err)
+	}
+
+	remotePage := newRemoteTagPage(page)
+	return &remotePage, nil
+}
+
+func (this *LocalModel) RenameTagContext(ctx context.Context, oldName, newName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := this.model.RenameTag(oldName, newName); err != nil {
+		return fmt.Errorf("RenameTag failed: %w", err)
+	}
+	return nil
+}
+
+func (this *LocalModel) AddTagContext(ctx context.Context, taxonomy string, name string) (*RemoteTag, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var tag *gmodel.Tag
+	var err error
+	if taxonomy == "" {
+		tag, err = this.model.AddTag(name)
+	} else {
+		tag, err = this.model.AddTerm(taxonomy, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("AddTag failed: %w", err)
+	}
+	return &RemoteTag{Tag: tag}, nil
+}
+
+func (this *LocalModel) DeleteTagContext(ctx context.Context, taxonomy string, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var err error
+	if taxonomy == "" {
+		err = this.model.DeleteTag(name)
+	} else {
+		err = this.model.DeleteTerm(taxonomy, name)
+	}
+	if err != nil {
+		return fmt.Errorf("DeleteTag failed: %w", err)
+	}
+	return nil
+}
+
+func (this *LocalModel) GetTermCountContext(ctx context.Context, taxonomy string) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if taxonomy == "" {
+		return this.model.GetTagCount(), nil
+	}
+	count, err := this.model.GetTermCount(taxonomy)
+	if err != nil {
+		return 0, fmt.Errorf("GetTermCount failed: %w", err)
+	}
+	return count, nil
+}
+
+func (this *LocalModel) GetCustomIdContext(ctx context.Context, articleId uint64) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	if customArticleId, ok := this.idMgr.GetStringId(articleId); ok {
+		return customArticleId, nil
+	}
+	return "", &gmodel.Error{Kind: gmodel.ErrNotFound, Msg: fmt.Sprintf("CustomArticleId of ArticleId[%v] not found", articleId)}
+}
+
+func (this *LocalModel) ResolveCustomIdContext(ctx context.Context, customArticleId string) (uint64, string, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, "", err
+	}
+
+	if customArticleId == "" {
+		return 0, "", &gmodel.Error{Kind: gmodel.ErrInvalidArgument, Msg: "CustomArticleId must not empty"}
+	}
+	if articleId, ok := this.idMgr.GetIntId(customArticleId); ok {
+		return articleId, customArticleId, nil
+	}
+	if redirect, ok := this.idMgr.GetRedirect(customArticleId); ok {
+		return redirect.IntId, redirect.To, nil
+	}
+	return 0, "", &gmodel.Error{Kind: gmodel.ErrNotFound, Msg: fmt.Sprintf("CustomArticleId[%v] not found", customArticleId)}
+}
+
+func (this *LocalModel) SetCustomIdContext(ctx context.Context, articleId uint64, customArticleId string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	this.customIdMutex.Lock()
+	defer this.customIdMutex.Unlock()
+
+	if _, err := this.model.GetArticle(articleId); err != nil {
+		return fmt.Errorf("GetArticle failed: %w", err)
+	}
+	// 只能给还没有自定义ID的文章设置，修改自定义ID使用 UpdateCustomIdContext，旧ID会重定向到新ID
+	if err := this.idMgr.SetIdMap(articleId, customArticleId); err != nil {
+		var conflict *gmodel.IdConflictError
+		if errors.As(err, &conflict) && conflict.BoundStringId != "" {
+			return &gmodel.Error{Kind: gmodel.ErrExists, Msg: fmt.Sprintf("Article ID[%v] already has CustomArticleId[%v], use update-custom-id to change it", articleId, conflict.BoundStringId)}
+		}
+		return fmt.Errorf("SetIdMap failed: %w", err)
+	}
+	return nil
+}
+
+func (this *LocalModel) UpdateCustomIdContext(ctx context.Context, articleId uint64, customArticleId string, newCustomArticleId string) (*gmodel.IdRedirect, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	this.customIdMutex.Lock()
+	defer this.customIdMutex.Unlock()
+
+	articleId, err := this.getArticleId(articleId, customArticleId)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := this.model.GetArticle(articleId); err != nil {
+		return nil, fmt.Errorf("GetArticle failed: %w", err)
+	}
+
+	redirect, err := this.idMgr.Rebind(articleId, newCustomArticleId)
+	if err != nil {
+		return nil, fmt.Errorf("Rebind failed: %w", err)
+	}
+	return redirect, nil
+}
+
+// 批量生成 RemoteArticle，自定义文章ID和分类名称都是批量获取的，每个分类只获取一次
+// 返回结果和 articles 一一对应，没有自定义文章ID时 CustomArticleId 为空
+func (this *LocalModel) newRemoteArticles(articles []*gmodel.Article) []*RemoteArticle {
+	articleIds := make([]uint64, 0, len(articles))
+	tagIds := make([]uint64, 0)
+	termIds := make(map[string][]uint64)
+	for _, article := range articles {
+		articleIds = append(articleIds, article.Id)
+		tagIds = append(tagIds, article.TagIds...)
+		for taxonomyName, ids := range article.Terms {
+			termIds[taxonomyName] = append(termIds[taxonomyName], ids...)
+		}
+	}
+
+	customArticleIds := this.idMgr.GetStringIds(articleIds)
+	tags := this.model.GetTagsByIds(tagIds)
+	terms := make(map[string]map[uint64]*gmodel.Tag)
+	for taxonomyName, ids := range termIds {
+		terms[taxonomyName] = this.model.GetTermsByIds(taxonomyName, ids)
+	}
+
+	remoteArticles := make([]*RemoteArticle, 0, len(articles))
+	for _, article := range articles {
+		tagNameArray := make([]string, 0)
+		for _, tagId := range article.TagIds {
+			if tag, exist := tags[tagId]; exist {
+				tagNameArray = append(tagNameArray, tag.Name)
+			}
+		}
+
+		var termNames map[string][]string
+		for taxonomyName, ids := range article.Terms {
+			names := make([]string, 0)
+			for _, termId := range ids {
+				if term, exist := terms[taxonomyName][termId]; exist {
+					names = append(names, term.Name)
+				}
+			}
+			if termNames == nil {
+				termNames = make(map[string][]string)
+			}
+			termNames[taxonomyName] = names
+		}
+
+		remoteArticles = append(remoteArticles, &RemoteArticle{
+			Article:         article,
+			CustomArticleId: customArticleIds[article.Id],
+			TagNameArray:    tagNameArray,
+			TermNames:       termNames,
+		})
+	}
+
+	return remoteArticles
+}
+
+// 游标原样返回，没有自定义文章ID的文章会被过滤掉
+func (this *LocalModel) newRemoteArticlePage(page *gmodel.Page[*gmodel.Article]) gmodel.Page[*RemoteArticle] {
+	remoteArticles := make([]*RemoteArticle, 0)
+	for _, remoteArticle := range this.newRemoteArticles(page.Items) {
+		if remoteArticle.CustomArticleId != "" {
+			remoteArticles = append(remoteArticles, remoteArticle)
+		}
+	}
+
+	return gmodel.Page[*RemoteArticle]{
+		Items:      remoteArticles,
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+	}
+}
+
+// 获取分类名称，并按分类法分组获取其他分类法的名称，详见 newRemoteArticles
+func (this *LocalModel) newRemoteArticle(article *gmodel.Article, customArticleId string) *RemoteArticle {
+	remoteArticle := this.newRemoteArticles([]*gmodel.Article{article})[0]
+	remoteArticle.CustomArticleId = customArticleId
+	return remoteArticle
+}
@@ -0,0 +1,656 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	gm "github.com/gansidui/gmodel"
+)
+
+// LocalModel 和 APIClient 运行同样的测试，保证两种方式的结果一样
+func TestLocalModel(t *testing.T) {
+	defer removeModelTestDBs("local")
+
+	model := &gm.GModel{}
+	if err := model.Open("./article_local_model_test.db", "./tag_local_model_test.db", "./index_local_model_test.db"); err != nil {
+		t.Fatal(err)
+	}
+	defer model.Close()
+	idMgr := &gm.IdMgr{}
+	if err := idMgr.Open("./id_local_model_test.db"); err != nil {
+		t.Fatal(err)
+	}
+	defer idMgr.Close()
+
+	testModel(t, NewLocalModel(model, idMgr))
+}
+
+func TestAPIClientModel(t *testing.T) {
+	defer removeModelTestDBs("remote")
+
+	_, addr, stop := startTestServer(t, &APIServerConfig{
+		ArticleDBPath: "./article_remote_model_test.db",
+		TagDBPath:     "./tag_remote_model_test.db",
+		IndexDBPath:   "./index_remote_model_test.db",
+		IdDBPath:      "./id_remote_model_test.db",
+	})
+	defer stop()
+
+	testModel(t, NewAPIClient(addr, nil))
+
+	// 错误类型对应的HTTP状态码
+	for body, status := range map[string]int{
+		`{"custom_article_id": "not_exist"}`: http.StatusNotFound,
+		`{"article_id": "1"}`:                http.StatusBadRequest,
+	} {
+		resp, err := http.Post(addr+APIGetArticle, "application/json", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Fatal(body, resp.StatusCode)
+		}
+	}
+}
+
+func TestLocalModelCustomIdPolicy(t *testing.T) {
+	defer removeModelTestDBs("policy")
+
+	model := &gm.GModel{}
+	if err := model.Open("./article_policy_model_test.db", "./tag_policy_model_test.db", "./index_policy_model_test.db"); err != nil {
+		t.Fatal(err)
+	}
+	defer model.Close()
+	idMgr := &gm.IdMgr{}
+	if err := idMgr.Open("./id_policy_model_test.db"); err != nil {
+		t.Fatal(err)
+	}
+	defer idMgr.Close()
+
+	ctx := context.Background()
+	m := NewLocalModel(model, idMgr)
+	if err := m.SetCustomIdPolicy("keep"); err == nil {
+		t.Fatal()
+	}
+
+	// 默认删除自定义ID，可以被新文章使用
+	if _, _, err := m.AddArticleContext(ctx, nil, nil, "data", "title"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.DeleteArticleContext(ctx, 0, "title"); err != nil {
+		t.Fatal(err)
+	}
+	if idMgr.Count() != 0 {
+		t.Fatal(idMgr.Count())
+	}
+	id, _, err := m.AddArticleContext(ctx, nil, nil, "data", "title")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 保留墓碑，自定义ID不能再使用
+	if err = m.SetCustomIdPolicy(CustomIdTombstone); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.DeleteArticleContext(ctx, id, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = m.ResolveCustomIdContext(ctx, "title"); !errors.Is(err, gm.ErrNotFound) {
+		t.Fatal(err)
+	}
+	if _, _, err = m.AddArticleContext(ctx, nil, nil, "data", "title"); !errors.Is(err, gm.ErrExists) {
+		t.Fatal(err)
+	}
+	id, _, err = m.AddArticleContext(ctx, nil, nil, "data", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = m.SetCustomIdContext(ctx, id, "title"); !errors.Is(err, gm.ErrExists) {
+		t.Fatal(err)
+	}
+
+	// 没有自定义ID的文章可以设置
+	id, err = model.AddArticle(nil, "data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = m.SetCustomIdContext(ctx, id, "title_new"); err != nil {
+		t.Fatal(err)
+	}
+	if customId, err := m.GetCustomIdContext(ctx, id); err != nil || customId != "title_new" {
+		t.Fatal(err, customId)
+	}
+
+	// 修改过自定义ID的文章删除后，还在重定向的旧ID也保留墓碑
+	if _, err = m.UpdateCustomIdContext(ctx, id, "", "title_v2"); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.DeleteArticleContext(ctx, id, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = m.ResolveCustomIdContext(ctx, "title_new"); !errors.Is(err, gm.ErrNotFound) {
+		t.Fatal(err)
+	}
+	for _, customId := range []string{"title_new", "title_v2"} {
+		if _, _, err = m.AddArticleContext(ctx, nil, nil, "data", customId); !errors.Is(err, gm.ErrExists) {
+			t.Fatal(customId, err)
+		}
+	}
+}
+
+// 批量增加文章时保存自定义ID失败，刚保存的文章删除，不会在重试时重复增加
+func TestLocalModelAddArticlesIdMapsFailed(t *testing.T) {
+	defer removeModelTestDBs("batch")
+
+	model := &gm.GModel{}
+	if err := model.Open("./article_batch_model_test.db", "./tag_batch_model_test.db", "./index_batch_model_test.db"); err != nil {
+		t.Fatal(err)
+	}
+	defer model.Close()
+	idMgr := &gm.IdMgr{}
+	if err := idMgr.Open("./id_batch_model_test.db"); err != nil {
+		t.Fatal(err)
+	}
+	defer idMgr.Close()
+
+	// 只有16个可用的字符串ID，17篇文章一定会用完
+	if err := idMgr.SetIdGenConfig(gm.IdGenConfig{Length: 4, Alphabet: "ab"}); err != nil {
+		t.Fatal(err)
+	}
+	m := NewLocalModel(model, idMgr)
+	articles := make([]AddArticleReq, 17)
+	for i := range articles {
+		articles[i] = AddArticleReq{Tags: []string{"go"}, Data: "data"}
+	}
+	articleIds, _, errs, err := m.AddArticlesContext(context.Background(), articles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range articles {
+		if !errors.Is(errs[i], gm.ErrIdExhausted) || articleIds[i] != 0 {
+			t.Fatal(i, errs[i], articleIds[i])
+		}
+	}
+	if model.GetArticleCount() != 0 || model.GetArticleCountByTag("go") != 0 || idMgr.Count() != 0 {
+		t.Fatal(model.GetArticleCount(), idMgr.Count())
+	}
+}
+
+// 多个请求同时使用同一个自定义ID增加文章，只有一个成功，失败的不留下文章
+func TestLocalModelConcurrentCustomId(t *testing.T) {
+	defer removeModelTestDBs("concurrent")
+
+	model := &gm.GModel{}
+	if err := model.Open("./article_concurrent_model_test.db", "./tag_concurrent_model_test.db", "./index_concurrent_model_test.db"); err != nil {
+		t.Fatal(err)
+	}
+	defer model.Close()
+	idMgr := &gm.IdMgr{}
+	if err := idMgr.Open("./id_concurrent_model_test.db"); err != nil {
+		t.Fatal(err)
+	}
+	defer idMgr.Close()
+
+	m := NewLocalModel(model, idMgr)
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		go func() {
+			_, _, err := m.AddArticleContext(context.Background(), []string{"go"}, nil, "data", "same")
+			errs <- err
+		}()
+	}
+
+	success := 0
+	for i := 0; i < 10; i++ {
+		if err := <-errs; err == nil {
+			success++
+		} else if !errors.Is(err, gm.ErrExists) {
+			t.Fatal(err)
+		}
+	}
+	if success != 1 || model.GetArticleCount() != 1 || model.GetArticleCountByTag("go") != 1 || idMgr.Count() != 1 {
+		t.Fatal(success, model.GetArticleCount(), idMgr.Count())
+	}
+}
+
+func TestLocalModelConcurrentUpsert(t *testing.T) {
+	defer removeModelTestDBs("upsert")
+
+	model := &gm.GModel{}
+	if err := model.Open("./article_upsert_model_test.db", "./tag_upsert_model_test.db", "./index_upsert_model_test.db"); err != nil {
+		t.Fatal(err)
+	}
+	defer model.Close()
+	idMgr := &gm.IdMgr{}
+	if err := idMgr.Open("./id_upsert_model_test.db"); err != nil {
+		t.Fatal(err)
+	}
+	defer idMgr.Close()
+
+	// 同时提交同一个自定义ID，只增加一篇文章，其他的都没有变化
+	m := NewLocalModel(model, idMgr)
+	results := make(chan gm.UpsertResult, 10)
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		go func() {
+			_, result, err := m.UpsertArticleContext(context.Background(), []string{"go"}, nil, "data", "same")
+			results <- result
+			errs <- err
+		}()
+	}
+
+	created := 0
+	for i := 0; i < 10; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+		switch <-results {
+		case gm.UpsertCreated:
+			created++
+		case gm.UpsertUnchanged:
+		default:
+			t.Fatal()
+		}
+	}
+	if created != 1 || model.GetArticleCount() != 1 || model.GetArticleCountByTag("go") != 1 || idMgr.Count() != 1 {
+		t.Fatal(created, model.GetArticleCount(), idMgr.Count())
+	}
+
+	// 保留了墓碑的自定义ID不能再使用
+	if err := m.SetCustomIdPolicy(CustomIdTombstone); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.DeleteArticleContext(context.Background(), 0, "same"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := m.UpsertArticleContext(context.Background(), nil, nil, "data", "same"); !errors.Is(err, gm.ErrExists) {
+		t.Fatal(err)
+	}
+}
+
+// 只设置 IdSecret 时不需要ID数据库，自定义文章ID由密钥转换，不能指定
+func TestAPIClientStateless(t *testing.T) {
+	defer removeModelTestDBs("stateless")
+
+	secret := "0123456789abcdef"
+	_, addr, stop := startTestServer(t, &APIServerConfig{
+		ArticleDBPath: "./article_stateless_model_test.db",
+		TagDBPath:     "./tag_stateless_model_test.db",
+		IndexDBPath:   "./index_stateless_model_test.db",
+		IdSecret:      secret,
+	})
+	defer stop()
+
+	ctx := context.Background()
+	m := NewAPIClient(addr, nil)
+	codec, _ := gm.NewIdCodec([]byte(secret), "")
+	id, customId, err := m.AddArticleContext(ctx, nil, nil, "data", "")
+	if err != nil || customId != codec.Encode(id) || len(customId) != codec.Length() {
+		t.Fatal(err, id, customId)
+	}
+	article, err := m.GetArticleContext(ctx, 0, customId)
+	if err != nil || article.Id != id || article.CustomArticleId != customId {
+		t.Fatal(err, article)
+	}
+	if _, err = m.GetArticleContext(ctx, 0, codec.Encode(id+1)); !errors.Is(err, gm.ErrNotFound) {
+		t.Fatal(err)
+	}
+
+	if _, _, err = m.AddArticleContext(ctx, nil, nil, "data", "title"); !errors.Is(err, gm.ErrReadOnly) {
+		t.Fatal(err)
+	}
+	if _, _, err = m.UpsertArticleContext(ctx, nil, nil, "data", "title"); !errors.Is(err, gm.ErrReadOnly) {
+		t.Fatal(err)
+	}
+	results, err := m.AddArticles([]AddArticleReq{{Data: "data", CustomArticleId: "title"}, {Data: "data"}})
+	if err != nil || results[0].ErrCode != ErrCodeReadOnly || results[1].ErrCode != ErrCodeSuccess {
+		t.Fatal(err, results)
+	}
+
+	// 按 codec 转换的自定义ID更新
+	if _, result, err := m.UpsertArticleContext(ctx, nil, nil, "new data", customId); err != nil || result != gm.UpsertUpdated {
+		t.Fatal(err, result)
+	}
+	if err = m.DeleteArticleContext(ctx, 0, customId); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.GetArticleContext(ctx, 0, customId); !errors.Is(err, gm.ErrNotFound) {
+		t.Fatal(err)
+	}
+
+	// 文章不存在时 codec 转换的自定义ID也不能用来增加文章
+	if _, _, err = m.UpsertArticleContext(ctx, nil, nil, "data", customId); !errors.Is(err, gm.ErrExists) {
+		t.Fatal(err)
+	}
+}
+
+func removeModelTestDBs(name string) {
+	for _, db := range []string{"article", "tag", "index", "id"} {
+		os.RemoveAll("./" + db + "_" + name + "_model_test.db")
+	}
+}
+
+func testModel(t *testing.T, m Model) {
+	ctx := context.Background()
+
+	// 增加文章，自定义ID为空时自动生成，重复的自定义ID失败
+	id1, customId1, err := m.AddArticleContext(ctx, []string{"go", "db"}, nil, "data_1", "article_1")
+	if err != nil || id1 == 0 || customId1 != "article_1" {
+		t.Fatal(err, id1, customId1)
+	}
+	id2, customId2, err := m.AddArticleContext(ctx, []string{"go"}, nil, "data_2", "")
+	if err != nil || id2 <= id1 || customId2 == "" {
+		t.Fatal(err, id2, customId2)
+	}
+	id3, _, err := m.AddArticleContext(ctx, []string{"go"}, nil, "data_3", "article_3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = m.AddArticleContext(ctx, nil, nil, "data", "article_1"); !errors.Is(err, gm.ErrExists) {
+		t.Fatal(err)
+	}
+
+	articleCount, tagCount, maxArticleId, err := m.GetModelInfoContext(ctx)
+	if err != nil || articleCount != 3 || tagCount != 2 || maxArticleId != id3 {
+		t.Fatal(err, articleCount, tagCount, maxArticleId)
+	}
+
+	// 获取文章，自定义ID优先
+	article, err := m.GetArticleContext(ctx, id1, "")
+	if err != nil || article.CustomArticleId != "article_1" || article.Data != "data_1" || !isEqual(article.TagNameArray, []string{"go", "db"}) {
+		t.Fatal(err, article)
+	}
+	article, err = m.GetArticleContext(ctx, id1, customId2)
+	if err != nil || article.Id != id2 || article.Data != "data_2" {
+		t.Fatal(err, article)
+	}
+	if _, err = m.GetArticleContext(ctx, 0, "not_exist"); !errors.Is(err, gm.ErrNotFound) {
+		t.Fatal(err)
+	}
+	if _, err = m.GetArticleContext(ctx, 10000, ""); !errors.Is(err, gm.ErrNotFound) {
+		t.Fatal(err)
+	}
+
+	articles, errs, err := m.GetArticlesContext(ctx, []GetArticleReq{{ArticleId: id3}, {CustomArticleId: "not_exist"}, {CustomArticleId: "article_1"}})
+	if err != nil || len(articles) != 3 || len(errs) != 3 {
+		t.Fatal(err, articles, errs)
+	}
+	if errs[0] != nil || articles[0].CustomArticleId != "article_3" || !errors.Is(errs[1], gm.ErrNotFound) || articles[1] != nil || errs[2] != nil || articles[2].Id != id1 {
+		t.Fatal(articles, errs)
+	}
+	if !strings.Contains(errs[1].Error(), "not_exist") {
+		t.Fatal(errs[1])
+	}
+
+	// 修改文章
+	if err = m.UpdateArticleContext(ctx, 0, "article_3", []string{"db"}, nil, "data_3_new"); err != nil {
+		t.Fatal(err)
+	}
+	article, err = m.GetArticleContext(ctx, id3, "")
+	if err != nil || article.Data != "data_3_new" || !isEqual(article.TagNameArray, []string{"db"}) {
+		t.Fatal(err, article)
+	}
+	if err = m.UpdateArticleContext(ctx, 0, "not_exist", nil, nil, "data"); !errors.Is(err, gm.ErrNotFound) {
+		t.Fatal(err)
+	}
+
+	// 有分类法没有打开时什么都不修改
+	err = m.UpdateArticleContext(ctx, id3, "", []string{"go"}, map[string][]string{"not_exist": {"x"}}, "data_3_bad")
+	if !errors.Is(err, gm.ErrNotFound) {
+		t.Fatal(err)
+	}
+	article, err = m.GetArticleContext(ctx, id3, "")
+	if err != nil || article.Data != "data_3_new" || !isEqual(article.TagNameArray, []string{"db"}) {
+		t.Fatal(err, article)
+	}
+
+	// 游标分页
+	page, err := m.ListArticlesContext(ctx, "", 2, "desc")
+	if err != nil || len(page.Items) != 2 || page.Items[0].Id != id3 || page.Items[1].Id != id2 || page.NextCursor == "" {
+		t.Fatal(err, page)
+	}
+	page, err = m.ListArticlesContext(ctx, page.NextCursor, 2, "desc")
+	if err != nil || len(page.Items) != 1 || page.Items[0].Id != id1 || page.NextCursor != "" {
+		t.Fatal(err, page)
+	}
+	if _, err = m.ListArticlesContext(ctx, "invalid", 2, "desc"); !errors.Is(err, gm.ErrInvalidArgument) {
+		t.Fatal(err)
+	}
+	page, err = m.ListArticlesByTagContext(ctx, "", "db", "", 10, "asc")
+	if err != nil || len(page.Items) != 2 || page.Items[0].Id != id1 || page.Items[1].Id != id3 {
+		t.Fatal(err, page)
+	}
+	if _, err = m.ListArticlesByTagContext(ctx, "not_exist", "db", "", 10, "asc"); !errors.Is(err, gm.ErrNotFound) {
+		t.Fatal(err)
+	}
+
+	// 分类
+	tag, err := m.GetTagByNameContext(ctx, "go")
+	if err != nil || tag.Name != "go" || tag.ArticleCount != 2 {
+		t.Fatal(err, tag)
+	}
+	if _, err = m.GetTagByNameContext(ctx, "not_exist"); !errors.Is(err, gm.ErrNotFound) {
+		t.Fatal(err)
+	}
+	if _, err = m.GetTagBySlugContext(ctx, "not_exist"); !errors.Is(err, gm.ErrNotFound) {
+		t.Fatal(err)
+	}
+	tagPage, err := m.ListTagsContext(ctx, "", 10, "asc")
+	if err != nil || len(tagPage.Items) != 2 || tagPage.Items[0].Name != "go" || tagPage.Items[1].Name != "db" {
+		t.Fatal(err, tagPage)
+	}
+	tagPage, err = m.ListTopTagsContext(ctx, "", 1)
+	if err != nil || len(tagPage.Items) != 1 || tagPage.NextCursor == "" {
+		t.Fatal(err, tagPage)
+	}
+	if err = m.RenameTagContext(ctx, "go", "db"); !errors.Is(err, gm.ErrExists) {
+		t.Fatal(err)
+	}
+	if err = m.RenameTagContext(ctx, "go", "golang"); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.RenameTagContext(ctx, "not_exist", "new_name"); !errors.Is(err, gm.ErrNotFound) {
+		t.Fatal(err)
+	}
+	article, err = m.GetArticleContext(ctx, 0, "article_1")
+	if err != nil || !isEqual(article.TagNameArray, []string{"golang", "db"}) {
+		t.Fatal(err, article)
+	}
+
+	// 自定义文章ID
+	customId, err := m.GetCustomIdContext(ctx, id1)
+	if err != nil || customId != "article_1" {
+		t.Fatal(err, customId)
+	}
+	if _, err = m.GetCustomIdContext(ctx, 10000); !errors.Is(err, gm.ErrNotFound) {
+		t.Fatal(err)
+	}
+	articleId, customId, err := m.ResolveCustomIdContext(ctx, "article_3")
+	if err != nil || articleId != id3 || customId != "article_3" {
+		t.Fatal(err, articleId, customId)
+	}
+	if _, _, err = m.ResolveCustomIdContext(ctx, "not_exist"); !errors.Is(err, gm.ErrNotFound) {
+		t.Fatal(err)
+	}
+	if _, _, err = m.ResolveCustomIdContext(ctx, ""); !errors.Is(err, gm.ErrInvalidArgument) {
+		t.Fatal(err)
+	}
+	if err = m.SetCustomIdContext(ctx, id3, "article_1"); !errors.Is(err, gm.ErrExists) {
+		t.Fatal(err)
+	}
+	if err = m.SetCustomIdContext(ctx, 10000, "article_new"); !errors.Is(err, gm.ErrNotFound) {
+		t.Fatal(err)
+	}
+	// 已经有自定义ID的文章不能用 set-custom-id 修改，需要用 update-custom-id
+	if err = m.SetCustomIdContext(ctx, id3, "article_3_new"); !errors.Is(err, gm.ErrExists) || !strings.Contains(err.Error(), "update-custom-id") {
+		t.Fatal(err)
+	}
+	if err = m.SetCustomIdContext(ctx, id3, "article_3"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.GetArticleContext(ctx, 0, "article_3_new"); !errors.Is(err, gm.ErrNotFound) {
+		t.Fatal(err)
+	}
+
+	if _, err = m.UpdateCustomIdContext(ctx, id3, "", "article_3_new"); err != nil {
+		t.Fatal(err)
+	}
+	article, err = m.GetArticleContext(ctx, 0, "article_3_new")
+	if err != nil || article.Id != id3 || article.CustomArticleId != "article_3_new" {
+		t.Fatal(err, article)
+	}
+	if _, err = m.GetArticleContext(ctx, 0, "article_3"); !errors.Is(err, gm.ErrNotFound) {
+		t.Fatal(err)
+	}
+
+	// 修改自定义文章ID，旧的重定向到新的
+	redirect, err := m.UpdateCustomIdContext(ctx, 0, "article_3_new", "article_3_v2")
+	if err != nil || redirect == nil || redirect.From != "article_3_new" || redirect.To != "article_3_v2" || redirect.IntId != id3 || !redirect.ExpireAt.After(time.Now()) {
+		t.Fatal(err, redirect)
+	}
+	articleId, customId, err = m.ResolveCustomIdContext(ctx, "article_3_new")
+	if err != nil || articleId != id3 || customId != "article_3_v2" {
+		t.Fatal(err, articleId, customId)
+	}
+	if _, err = m.GetArticleContext(ctx, 0, "article_3_new"); !errors.Is(err, gm.ErrNotFound) {
+		t.Fatal(err)
+	}
+
+	// 还在重定向的旧ID不能被其他文章使用，但是可以改回去
+	if _, _, err = m.AddArticleContext(ctx, nil, nil, "data", "article_3_new"); !errors.Is(err, gm.ErrExists) {
+		t.Fatal(err)
+	}
+	if _, err = m.UpdateCustomIdContext(ctx, id2, "", "article_3_new"); !errors.Is(err, gm.ErrExists) {
+		t.Fatal(err)
+	}
+	if _, err = m.UpdateCustomIdContext(ctx, 0, "not_exist", "article_new"); !errors.Is(err, gm.ErrNotFound) {
+		t.Fatal(err)
+	}
+	redirect, err = m.UpdateCustomIdContext(ctx, id3, "", "article_3_new")
+	if err != nil || redirect == nil || redirect.From != "article_3_v2" {
+		t.Fatal(err, redirect)
+	}
+	articleId, customId, err = m.ResolveCustomIdContext(ctx, "article_3_v2")
+	if err != nil || articleId != id3 || customId != "article_3_new" {
+		t.Fatal(err, articleId, customId)
+	}
+
+	// 增加、删除分类
+	tag, err = m.AddTagContext(ctx, "", "rust")
+	if err != nil || tag.Name != "rust" || tag.ArticleCount != 0 {
+		t.Fatal(err, tag)
+	}
+	if _, err = m.AddTagContext(ctx, "", "rust"); !errors.Is(err, gm.ErrExists) {
+		t.Fatal(err)
+	}
+	if _, err = m.AddTagContext(ctx, "not_exist", "rust"); !errors.Is(err, gm.ErrNotFound) {
+		t.Fatal(err)
+	}
+	if err = m.DeleteTagContext(ctx, "", "db"); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.DeleteTagContext(ctx, "", "db"); !errors.Is(err, gm.ErrNotFound) {
+		t.Fatal(err)
+	}
+	article, err = m.GetArticleContext(ctx, 0, "article_1")
+	if err != nil || !isEqual(article.TagNameArray, []string{"golang"}) {
+		t.Fatal(err, article)
+	}
+
+	// 没有其他分类的文章变成未分类，未分类也算一个分类
+	tagCount, err = m.GetTermCountContext(ctx, "")
+	if err != nil || tagCount != 3 {
+		t.Fatal(err, tagCount)
+	}
+	if _, err = m.GetTermCountContext(ctx, "not_exist"); !errors.Is(err, gm.ErrNotFound) {
+		t.Fatal(err)
+	}
+
+	// 删除文章
+	if err = m.DeleteArticleContext(ctx, 0, "article_1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.GetArticleContext(ctx, id1, ""); err == nil {
+		t.Fatal()
+	}
+	if err = m.DeleteArticleContext(ctx, 0, "not_exist"); !errors.Is(err, gm.ErrNotFound) {
+		t.Fatal(err)
+	}
+
+	// 默认删除自定义ID，可以被新文章使用
+	if _, _, err = m.ResolveCustomIdContext(ctx, "article_1"); !errors.Is(err, gm.ErrNotFound) {
+		t.Fatal(err)
+	}
+	if _, _, err = m.AddArticleContext(ctx, nil, nil, "data", "article_1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 按自定义ID增加或者修改文章
+	upsertId, result, err := m.UpsertArticleContext(ctx, []string{"go"}, nil, "data_upsert", "upsert_1")
+	if err != nil || upsertId == 0 || result != gm.UpsertCreated {
+		t.Fatal(err, upsertId, result)
+	}
+	articleId, result, err = m.UpsertArticleContext(ctx, []string{"go"}, nil, "data_upsert", "upsert_1")
+	if err != nil || articleId != upsertId || result != gm.UpsertUnchanged {
+		t.Fatal(err, articleId, result)
+	}
+	articleId, result, err = m.UpsertArticleContext(ctx, []string{"go"}, nil, "data_upsert_2", "upsert_1")
+	if err != nil || articleId != upsertId || result != gm.UpsertUpdated {
+		t.Fatal(err, articleId, result)
+	}
+	article, err = m.GetArticleContext(ctx, 0, "upsert_1")
+	if err != nil || article.Id != upsertId || article.Data != "data_upsert_2" {
+		t.Fatal(err, article)
+	}
+
+	// 还在重定向的旧ID修改重定向到的文章
+	articleId, result, err = m.UpsertArticleContext(ctx, []string{"go"}, nil, "data_3_new", "article_3_v2")
+	if err != nil || articleId != id3 || result != gm.UpsertUpdated {
+		t.Fatal(err, articleId, result)
+	}
+	if _, _, err = m.UpsertArticleContext(ctx, nil, nil, "data", ""); !errors.Is(err, gm.ErrInvalidArgument) {
+		t.Fatal(err)
+	}
+
+	// 批量增加文章，失败的项不影响其他项
+	batchIds, batchCustomIds, batchErrs, err := m.AddArticlesContext(ctx, []AddArticleReq{
+		{Tags: []string{"batch"}, Data: "batch_1", CustomArticleId: "batch_1"},
+		{Tags: []string{"batch"}, Data: "batch_2", CustomArticleId: "batch_1"},
+		{Tags: []string{"batch"}, Data: "batch_3", CustomArticleId: "article_1"},
+		{Tags: []string{"batch"}, Data: ""},
+		{Tags: []string{"batch"}, Data: "batch_5"},
+	})
+	if err != nil || len(batchIds) != 5 || len(batchCustomIds) != 5 || len(batchErrs) != 5 {
+		t.Fatal(err, batchIds, batchErrs)
+	}
+	if batchErrs[0] != nil || batchIds[0] == 0 || batchCustomIds[0] != "batch_1" || batchErrs[4] != nil || batchIds[4] == 0 || batchCustomIds[4] == "" {
+		t.Fatal(batchIds, batchCustomIds, batchErrs)
+	}
+	if !errors.Is(batchErrs[1], gm.ErrExists) || !errors.Is(batchErrs[2], gm.ErrExists) || !errors.Is(batchErrs[3], gm.ErrInvalidArgument) || batchIds[1] != 0 || batchIds[3] != 0 {
+		t.Fatal(batchIds, batchErrs)
+	}
+	article, err = m.GetArticleContext(ctx, 0, "batch_1")
+	if err != nil || article.Id != batchIds[0] || article.Data != "batch_1" {
+		t.Fatal(err, article)
+	}
+	if _, _, _, err = m.AddArticlesContext(ctx, make([]AddArticleReq, MaxAddArticlesCount+1)); !errors.Is(err, gm.ErrInvalidArgument) {
+		t.Fatal(err)
+	}
+
+	// context 已经取消
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, _, _, err = m.GetModelInfoContext(canceled); !errors.Is(err, context.Canceled) {
+		t.Fatal(err)
+	}
+	if _, _, err = m.AddArticleContext(canceled, nil, nil, "data", ""); !errors.Is(err, context.Canceled) {
+		t.Fatal(err)
+	}
+}
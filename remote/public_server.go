@@ -0,0 +1,211 @@
+package remote
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gansidui/gmodel"
+	"github.com/gin-gonic/gin"
+)
+
+// 公开的只读API，全部是 GET 请求，可以被CDN和浏览器缓存，适合直接放在网站前面的 nginx 后面
+// 只提供读操作，不需要鉴权；文章只用自定义ID（字符串ID）访问，避免通过递增ID遍历网站
+//
+//	GET /articles/:id                                           获取文章，id 为自定义文章ID，旧的自定义文章ID返回301
+//	GET /tags/:name/articles?cursor=&n=&order=&taxonomy=        分类下的文章，游标分页
+//
+// 每个响应都带有 ETag、Last-Modified、Cache-Control，只支持 If-None-Match，没有变化时返回 304。
+// 文章没有保存修改时间，Last-Modified 使用服务器最后一次成功写入的时间，只精确到秒，
+// 同一秒内的多次写入无法区分，所以只作为参考，不根据 If-Modified-Since 返回 304。
+
+const (
+	PublicAPIArticle     = "/articles/:id"
+	PublicAPITagArticles = "/tags/:name/articles"
+)
+
+// 公开API缓存的默认时间
+const DefaultPublicCacheMaxAge = time.Minute
+
+// 公开API每页最多的文章数
+const MaxPublicPageSize = 100
+
+// 公开的文章，不包括整型文章ID和分类ID
+type PublicArticle struct {
+	Id    string              `json:"id"` // 自定义文章ID
+	Tags  []string            `json:"tags"`
+	Terms map[string][]string `json:"terms,omitempty"`
+	Data  string              `json:"data"`
+}
+
+func newPublicArticle(remoteArticle *RemoteArticle) *PublicArticle {
+	return &PublicArticle{
+		Id:    remoteArticle.CustomArticleId,
+		Tags:  remoteArticle.TagNameArray,
+		Terms: remoteArticle.TermNames,
+		Data:  remoteArticle.Data,
+	}
+}
+
+// 返回公开的只读API的 http.Handler，和 Handler 分开监听，不要把 Handler 暴露到公网
+func (this *APIServer) PublicHandler() http.Handler {
+	this.publicHandlerOnce.Do(func() {
+		gin.SetMode(gin.ReleaseMode)
+		this.publicHandler = this.newPublicHandler()
+	})
+	return this.publicHandler
+}
+
+func (this *APIServer) newPublicHandler() *gin.Engine {
+	router := gin.New()
+	router.Use(gin.Recovery())
+
+	// 分类名称中可能有 "/"，需要使用转义之前的路径匹配
+	router.UseRawPath = true
+	router.UnescapePathValues = true
+
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		router.Handle(method, PublicAPIArticle, this.publicArticleHandler)
+		router.Handle(method, PublicAPITagArticles, this.publicTagArticlesHandler)
+	}
+	router.NoRoute(func(c *gin.Context) {
+		this.publicError(c, http.StatusNotFound, "Not found")
+	})
+
+	return router
+}
+
+func (this *APIServer) publicArticleHandler(c *gin.Context) {
+	articleId, ok := this.idMgr.GetIntId(c.Param("id"))
+	if !ok {
+		// 修改过的自定义文章ID，重定向到新的，使用相对路径，挂在其他路径下也可以
+		if redirect, ok := this.idMgr.GetRedirect(c.Param("id")); ok {
+			// 不用 c.Redirect，它会把相对路径转换成绝对路径
+			c.Header("Location", url.PathEscape(redirect.To))
+			c.Status(http.StatusMovedPermanently)
+			return
+		}
+		this.publicError(c, http.StatusNotFound, "Article not found")
+		return
+	}
+
+	article, err := this.model.GetArticle(articleId)
+	if err != nil {
+		this.publicError(c, http.StatusNotFound, "Article not found")
+		return
+	}
+
+	this.publicJSON(c, newPublicArticle(this.local.newRemoteArticles([]*gmodel.Article{article})[0]))
+}
+
+func (this *APIServer) publicTagArticlesHandler(c *gin.Context) {
+	n := 20
+	if s := c.Query("n"); s != "" {
+		var err error
+		if n, err = strconv.Atoi(s); err != nil || n <= 0 || n > MaxPublicPageSize {
+			this.publicError(c, http.StatusBadRequest, fmt.Sprintf("Invalid n, must be 1 ~ %v", MaxPublicPageSize))
+			return
+		}
+	}
+	order := c.DefaultQuery("order", "desc")
+	if order != "desc" && order != "asc" {
+		this.publicError(c, http.StatusBadRequest, "Invalid order, must be desc or asc")
+		return
+	}
+
+	var page *gmodel.Page[*gmodel.Article]
+	var err error
+	if taxonomy := c.Query("taxonomy"); taxonomy == "" {
+		page, err = this.model.ListArticlesByTag(c.Param("name"), c.Query("cursor"), n, parseOrder(order))
+	} else {
+		page, err = this.model.ListArticlesByTerm(taxonomy, c.Param("name"), c.Query("cursor"), n, parseOrder(order))
+	}
+	if err != nil {
+		this.publicError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	remotePage := this.local.newRemoteArticlePage(page)
+	publicPage := &gmodel.Page[*PublicArticle]{
+		Items:      make([]*PublicArticle, 0, len(remotePage.Items)),
+		NextCursor: remotePage.NextCursor,
+		PrevCursor: remotePage.PrevCursor,
+	}
+	for _, remoteArticle := range remotePage.Items {
+		publicPage.Items = append(publicPage.Items, newPublicArticle(remoteArticle))
+	}
+
+	this.publicJSON(c, publicPage)
+}
+
+// 返回可以缓存的结果，ETag 为响应内容的哈希
+func (this *APIServer) publicJSON(c *gin.Context, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		this.publicError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	lastModified := time.Unix(this.lastModified.Load(), 0).UTC()
+
+	header := c.Writer.Header()
+	header.Set("ETag", etag)
+	header.Set("Last-Modified", lastModified.Format(http.TimeFormat))
+	header.Set("Cache-Control", fmt.Sprintf("public, max-age=%v", int(this.publicCacheMaxAge().Seconds())))
+
+	if isNotModified(c.Request, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// 错误不缓存
+func (this *APIServer) publicError(c *gin.Context, status int, message string) {
+	errCode := ErrCodeFailed
+	switch status {
+	case http.StatusNotFound:
+		errCode = ErrCodeNotFound
+	case http.StatusBadRequest:
+		errCode = ErrCodeInvalidArgument
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(status, &BaseResp{ErrCode: errCode, ErrMsg: message})
+}
+
+func (this *APIServer) publicCacheMaxAge() time.Duration {
+	if this.config.PublicCacheMaxAge < 0 {
+		return 0
+	}
+	return durationOrDefault(this.config.PublicCacheMaxAge, DefaultPublicCacheMaxAge)
+}
+
+// 只判断 If-None-Match，ETag 是响应内容的哈希，不会有 Last-Modified 精度不够的问题
+func isNotModified(req *http.Request, etag string) bool {
+	for _, tag := range strings.Split(req.Header.Get("If-None-Match"), ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == etag || tag == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// 记录最后一次成功写入的时间，用于公开API的 Last-Modified，失败的请求（鉴权失败、参数错误等）不算
+func (this *APIServer) lastModifiedHandler(c *gin.Context) {
+	c.Next()
+
+	if role, ok := apiRoles[c.Request.URL.Path]; ok && role != RoleReader && c.Writer.Status() == http.StatusOK {
+		this.lastModified.Store(time.Now().Unix())
+	}
+}
@@ -1,10 +1,22 @@
 package remote
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strconv"
 	"testing"
 	"time"
+
+	gm "github.com/gansidui/gmodel"
 )
 
 func TestRemote(t *testing.T) {
@@ -12,8 +24,12 @@ func TestRemote(t *testing.T) {
 	tagDBPath := "./tag_test.db"
 	indexDBPath := "./index_test.db"
 	idDBPath := "./id_test.db"
+	keywordTagDBPath := "./keyword_tag_test.db"
+	keywordIndexDBPath := "./keyword_index_test.db"
 
 	defer func() {
+		os.RemoveAll(keywordTagDBPath)
+		os.RemoveAll(keywordIndexDBPath)
 		os.RemoveAll(articleDBPath)
 		os.RemoveAll(tagDBPath)
 		os.RemoveAll(indexDBPath)
@@ -21,34 +37,414 @@ func TestRemote(t *testing.T) {
 	}()
 
 	// 启动server
+	config := &APIServerConfig{
+		ArticleDBPath: articleDBPath,
+		TagDBPath:     tagDBPath,
+		IndexDBPath:   indexDBPath,
+		IdDBPath:      idDBPath,
+		UseGzip:       false,
+
+		NormalizeTagNames: true,
+		Taxonomies: []TaxonomyConfig{
+			{Name: "keyword", TagDBPath: keywordTagDBPath, IndexDBPath: keywordIndexDBPath},
+		},
+	}
+	server, err := NewAPIServer(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Serve(ctx, listener)
+	}()
+
+	// 启动client
+	StartClient(t, "http://"+listener.Addr().String())
+
+	// 退出server
+	cancel()
+	if err = <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewAPIServer(t *testing.T) {
+	defer os.RemoveAll("./article_test.db")
+
+	// 数据库打开失败时返回错误，已经打开的数据库会关闭，可以重新打开
+	config := &APIServerConfig{
+		ArticleDBPath: "./article_test.db",
+		TagDBPath:     "./article_test.db",
+	}
+	if _, err := NewAPIServer(config); err == nil {
+		t.Fatal()
+	}
+	model := &gm.GModel{}
+	if err := model.Open("./article_test.db", "./tag_test.db", "./index_test.db"); err != nil {
+		t.Fatal(err)
+	}
+	model.Close()
+	os.RemoveAll("./tag_test.db")
+	os.RemoveAll("./index_test.db")
+}
+
+// 启动测试用的server，返回地址和退出函数
+func startTestServer(t *testing.T, config *APIServerConfig) (*APIServer, string, func()) {
+	server, err := NewAPIServer(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
 	go func() {
-		config := &APIServerConfig{
-			ArticleDBPath: articleDBPath,
-			TagDBPath:     tagDBPath,
-			IndexDBPath:   indexDBPath,
-			IdDBPath:      idDBPath,
-			ListeningAddr: ":9999",
-			UseGzip:       false,
+		done <- server.Serve(ctx, listener)
+	}()
+
+	return server, "http://" + listener.Addr().String(), func() {
+		cancel()
+		<-done
+		server.Close()
+	}
+}
+
+func TestAuth(t *testing.T) {
+	defer func() {
+		os.RemoveAll("./article_auth_test.db")
+		os.RemoveAll("./tag_auth_test.db")
+		os.RemoveAll("./index_auth_test.db")
+		os.RemoveAll("./id_auth_test.db")
+	}()
+
+	config := &APIServerConfig{
+		ArticleDBPath: "./article_auth_test.db",
+		TagDBPath:     "./tag_auth_test.db",
+		IndexDBPath:   "./index_auth_test.db",
+		IdDBPath:      "./id_auth_test.db",
+		APIKeys: []APIKeyConfig{
+			{Id: "website", Secret: "reader_secret", Role: RoleReader, AllowBearer: true},
+			{Id: "spider", Secret: "writer_secret", Role: RoleWriter},
+			{Id: "admin", Secret: "admin_secret", Role: RoleAdmin},
+		},
+	}
+	server, addr, stop := startTestServer(t, config)
+	defer stop()
+
+	newClient := func(keyId, secret string) *APIClient {
+		client := &APIClient{}
+		client.Start(addr)
+		client.SetAPIKey(keyId, secret)
+		return client
+	}
+
+	// 没有密钥、密钥错误
+	if _, _, _, err := newClient("", "").GetModelInfo(); err == nil {
+		t.Fatal()
+	}
+	if _, _, _, err := newClient("website", "wrong").GetModelInfo(); err == nil {
+		t.Fatal()
+	}
+
+	// 按角色限制
+	reader := newClient("website", "reader_secret")
+	writer := newClient("spider", "writer_secret")
+	admin := newClient("admin", "admin_secret")
+	if _, _, _, err := reader.GetModelInfo(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := reader.AddArticle(nil, "data", ""); err == nil {
+		t.Fatal()
+	}
+	articleId, _, err := writer.AddArticle([]string{"tag"}, "data", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = writer.DeleteArticle(articleId, ""); err == nil {
+		t.Fatal()
+	}
+	if err = admin.DeleteArticle(articleId, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重放、过期的请求
+	send := func(req *http.Request) int {
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
 		}
-		server := &APIServer{}
-		server.Start(config)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	newRequest := func() *http.Request {
+		req, _ := http.NewRequest("POST", addr+APIGetModelInfo, bytes.NewReader([]byte("{}")))
+		setRequestSignature(req, "website", "reader_secret", []byte("{}"))
+		return req
+	}
+	req := newRequest()
+	replay := newRequest()
+	replay.Header = req.Header.Clone()
+	if send(req) != http.StatusOK || send(replay) != http.StatusUnauthorized {
+		t.Fatal()
+	}
+	req = newRequest()
+	timestamp := strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, signRequest("reader_secret", "POST", APIGetModelInfo, timestamp, req.Header.Get(HeaderNonce), []byte("{}")))
+	if send(req) != http.StatusUnauthorized {
+		t.Fatal()
+	}
+
+	// 请求体被篡改
+	req = newRequest()
+	req.Body = io.NopCloser(bytes.NewReader([]byte(`{"n":1}`)))
+	req.ContentLength = 7
+	if send(req) != http.StatusUnauthorized {
+		t.Fatal()
+	}
+
+	// 请求体太大
+	server.Reload(&APIServerConfig{APIKeys: config.APIKeys, AuthMaxBodySize: 16})
+	req = newRequest()
+	req.Body = io.NopCloser(bytes.NewReader(bytes.Repeat([]byte(" "), 17)))
+	req.ContentLength = 17
+	if send(req) != http.StatusRequestEntityTooLarge {
+		t.Fatal()
+	}
+	if send(newRequest()) != http.StatusOK {
+		t.Fatal()
+	}
+
+	// Bearer
+	req, _ = http.NewRequest("POST", addr+APIGetModelInfo, nil)
+	req.Header.Set("Authorization", "Bearer reader_secret")
+	if send(req) != http.StatusOK {
+		t.Fatal()
+	}
+	req.Header.Set("Authorization", "Bearer admin_secret")
+	if send(req) != http.StatusUnauthorized {
+		t.Fatal()
+	}
+
+	// 运行中修改密钥
+	if err = server.Reload(&APIServerConfig{APIKeys: []APIKeyConfig{{Id: "x", Role: "root"}}}); err == nil {
+		t.Fatal()
+	}
+	if err = server.Reload(&APIServerConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err = newClient("", "").GetModelInfo(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPublicAPI(t *testing.T) {
+	defer func() {
+		os.RemoveAll("./article_public_test.db")
+		os.RemoveAll("./tag_public_test.db")
+		os.RemoveAll("./index_public_test.db")
+		os.RemoveAll("./id_public_test.db")
 	}()
 
-	// 先等server启动，再启动client
-	time.Sleep(3 * time.Second)
+	config := &APIServerConfig{
+		ArticleDBPath:     "./article_public_test.db",
+		TagDBPath:         "./tag_public_test.db",
+		IndexDBPath:       "./index_public_test.db",
+		IdDBPath:          "./id_public_test.db",
+		PublicCacheMaxAge: 30 * time.Second,
+	}
+	server, addr, stop := startTestServer(t, config)
+	defer stop()
 
-	// 启动client
-	StartClient(t)
+	client := &APIClient{}
+	client.Start(addr)
+	for i := 1; i <= 3; i++ {
+		if _, _, err := client.AddArticle([]string{"go/leveldb"}, "data_"+strconv.Itoa(i), "article_"+strconv.Itoa(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	get := func(method, path string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		for key, value := range header {
+			req.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+		server.PublicHandler().ServeHTTP(w, req)
+		return w
+	}
+
+	// 获取文章，只能用自定义ID
+	w := get("GET", "/articles/article_2", nil)
+	article := &PublicArticle{}
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), article) != nil || article.Id != "article_2" ||
+		article.Data != "data_2" || !isEqual(article.Tags, []string{"go/leveldb"}) {
+		t.Fatal(w.Code, w.Body.String())
+	}
+	if w.Header().Get("Cache-Control") != "public, max-age=30" || w.Header().Get("Last-Modified") == "" {
+		t.Fatal(w.Header())
+	}
+	etag := w.Header().Get("ETag")
+	if w = get("GET", "/articles/article_2", map[string]string{"If-None-Match": etag}); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatal(w.Code)
+	}
+	if w = get("GET", "/articles/article_2", map[string]string{"If-None-Match": `"other", W/` + etag}); w.Code != http.StatusNotModified {
+		t.Fatal(w.Code)
+	}
+	if w = get("GET", "/articles/article_1", map[string]string{"If-None-Match": etag}); w.Code != http.StatusOK {
+		t.Fatal(w.Code)
+	}
+	// 失败的写入不修改 Last-Modified
+	server.lastModified.Store(0)
+	if _, _, err := client.AddArticle(nil, "", ""); err == nil || server.lastModified.Load() != 0 {
+		t.Fatal(err)
+	}
+	if _, _, err := client.AddArticle(nil, "data_4", ""); err != nil || server.lastModified.Load() == 0 {
+		t.Fatal(err)
+	}
+
+	// Last-Modified 只精确到秒，只有 If-Modified-Since 时不返回 304
+	lastModified := w.Header().Get("Last-Modified")
+	if w = get("GET", "/articles/article_1", map[string]string{"If-Modified-Since": lastModified}); w.Code != http.StatusOK {
+		t.Fatal(w.Code)
+	}
+	if w = get("HEAD", "/articles/article_1", nil); w.Code != http.StatusOK || w.Header().Get("ETag") == "" {
+		t.Fatal(w.Code)
+	}
+	if w = get("GET", "/articles/1", nil); w.Code != http.StatusNotFound || w.Header().Get("Cache-Control") != "no-store" {
+		t.Fatal(w.Code)
+	}
+
+	// 修改过的自定义ID重定向到新的
+	if _, err := client.UpdateCustomId(0, "article_3", "article 3"); err != nil {
+		t.Fatal(err)
+	}
+	if w = get("GET", "/articles/article_3", nil); w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "article%203" {
+		t.Fatal(w.Code, w.Header())
+	}
+	if w = get("GET", "/articles/article%203", nil); w.Code != http.StatusOK {
+		t.Fatal(w.Code)
+	}
+
+	// 分类下的文章，分类名称中有 "/"
+	w = get("GET", "/tags/go%2Fleveldb/articles?n=2", nil)
+	page := &gm.Page[*PublicArticle]{}
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), page) != nil || len(page.Items) != 2 ||
+		page.Items[0].Id != "article 3" || page.NextCursor == "" {
+		t.Fatal(w.Code, w.Body.String())
+	}
+	w = get("GET", "/tags/go%2Fleveldb/articles?n=2&cursor="+page.NextCursor, nil)
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), page) != nil || len(page.Items) != 1 || page.Items[0].Id != "article_1" {
+		t.Fatal(w.Code, w.Body.String())
+	}
+	for _, path := range []string{"/tags/go%2Fleveldb/articles?n=1000", "/tags/go%2Fleveldb/articles?order=new", "/tags/go%2Fleveldb/articles?cursor=abc"} {
+		if w = get("GET", path, nil); w.Code != http.StatusBadRequest {
+			t.Fatal(path, w.Code)
+		}
+	}
+
+	// 没有写操作
+	for _, method := range []string{"POST", "PUT", "DELETE"} {
+		if w = get(method, "/articles/article_1", nil); w.Code == http.StatusOK {
+			t.Fatal(method)
+		}
+	}
+	if w = get("POST", APIDeleteArticle, nil); w.Code != http.StatusNotFound {
+		t.Fatal(w.Code)
+	}
+}
+
+func TestAPIClient(t *testing.T) {
+	// 前两次返回 503，之后成功，响应使用 gzip 压缩
+	var count int
+	var lastAcceptEncoding string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		lastAcceptEncoding = r.Header.Get("Accept-Encoding")
+		if count <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		switch r.URL.Path {
+		case APIGetModelInfo:
+			w.Header().Set("Content-Encoding", "gzip")
+			zw := gzip.NewWriter(w)
+			json.NewEncoder(zw).Encode(&GetModelInfoResp{ArticleCount: 3})
+			zw.Close()
+		case APIGetArticle:
+			json.NewEncoder(w).Encode(&BaseResp{ErrCode: ErrCodeFailed, ErrMsg: "Article not found"})
+		case APIGetTagById:
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(&BaseResp{ErrCode: ErrCodeUnauthorized, ErrMsg: "Invalid key id"})
+		}
+	}))
+	defer ts.Close()
+
+	client := NewAPIClient(ts.URL, &APIClientOptions{RetryBackoff: time.Millisecond})
+
+	// 只读接口重试，gzip 解压
+	articleCount, _, _, err := client.GetModelInfo()
+	if err != nil || articleCount != 3 || count != 3 || lastAcceptEncoding != "gzip" {
+		t.Fatal(err, articleCount, count, lastAcceptEncoding)
+	}
+
+	// 写接口不重试
+	count = 0
+	_, _, err = client.AddArticle(nil, "data", "")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable || count != 1 {
+		t.Fatal(err, count)
+	}
+
+	// 重试次数用完
+	count = 0
+	noRetry := NewAPIClient(ts.URL, &APIClientOptions{MaxRetries: -1, DisableGzip: true})
+	if _, _, _, err = noRetry.GetModelInfo(); !errors.As(err, &apiErr) || count != 1 || lastAcceptEncoding != "identity" {
+		t.Fatal(err, count, lastAcceptEncoding)
+	}
 
-	// 等等
-	time.Sleep(5 * time.Second)
+	// ErrCode 和 HTTP 状态码
+	count = 2
+	if _, err = client.GetArticle(1, ""); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusOK || apiErr.ErrCode != ErrCodeFailed || err.Error() != "Article not found" {
+		t.Fatal(err)
+	}
+	count = 2
+	if _, err = client.GetTagById(1); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized || apiErr.ErrCode != ErrCodeUnauthorized {
+		t.Fatal(err)
+	}
+
+	// context 取消
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var transportErr *TransportError
+	if _, _, _, err = client.WithContext(ctx).GetModelInfo(); !errors.As(err, &transportErr) || !errors.Is(err, context.Canceled) {
+		t.Fatal(err)
+	}
+
+	// 连接失败
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	closedAddr := "http://" + listener.Addr().String()
+	listener.Close()
+	if _, _, _, err = NewAPIClient(closedAddr, &APIClientOptions{RetryBackoff: time.Millisecond}).GetModelInfo(); !errors.As(err, &transportErr) || errors.As(err, &apiErr) {
+		t.Fatal(err)
+	}
 }
 
-func StartClient(t *testing.T) {
+func StartClient(t *testing.T, addr string) {
 	// 下面的代码是从 gmodel_test.go 中拷贝过来的
 
 	gmodel := &APIClient{}
-	gmodel.Start("http://127.0.0.1:9999")
+	gmodel.Start(addr)
 
 	if gmodel.GetArticleCount() != 0 {
 		t.Fatal()
@@ -183,6 +579,175 @@ func StartClient(t *testing.T) {
 		t.Fatal()
 	}
 
+	meta := &gm.TagMeta{
+		Slug:        "tag-1024",
+		Description: "description of tag1024",
+		Aliases:     []string{"tag1025"},
+		Attrs:       map[string]string{"icon": "1024.png"},
+	}
+	if gmodel.UpdateTagMeta("tag1024", meta) != nil {
+		t.Fatal()
+	}
+	tag, err := gmodel.GetTagBySlug("tag-1024")
+	if err != nil || tag.Name != "tag1024" || tag.Description != meta.Description || tag.Attrs["icon"] != "1024.png" {
+		t.Fatal(err)
+	}
+	tag, err = gmodel.GetTagByName(" TAG1025 ")
+	if err != nil || tag.Name != "tag1024" {
+		t.Fatal(err)
+	}
+	if gmodel.UpdateTagMeta("tag222", &gm.TagMeta{Aliases: []string{"tag1025"}}) == nil {
+		t.Fatal()
+	}
+
+	// tag1024 有两篇文章，其他分类都只有一篇，文章数相同时按ID排序
+	tagArray = gmodel.GetTopTags("", 2)
+	if len(tagArray) != 2 || tagArray[0].Name != "tag1024" || tagArray[1].Name != "tag4" {
+		t.Fatal()
+	}
+	tagArray = gmodel.GetTopTags("tag1024", 100)
+	if len(tagArray) != 4 || tagArray[0].ArticleCount != 1 {
+		t.Fatal()
+	}
+
+	tagPage, err := gmodel.SearchTags("TAG1", "", 2, false)
+	if err != nil || len(tagPage.Items) != 2 || tagPage.Items[0].Name != "tag1024" || tagPage.Items[1].Name != "tag111" || tagPage.NextCursor != "" {
+		t.Fatal(err)
+	}
+	tagPage, err = gmodel.SearchTags("TAG1", "", 1, true)
+	if err != nil || len(tagPage.Items) != 1 || tagPage.Items[0].Name != "tag1024" || tagPage.NextCursor == "" {
+		t.Fatal(err)
+	}
+	tagPage, err = gmodel.SearchTags("TAG1", tagPage.NextCursor, 1, true)
+	if err != nil || len(tagPage.Items) != 1 || tagPage.Items[0].Name != "tag111" || tagPage.NextCursor != "" {
+		t.Fatal(err)
+	}
+	if _, err = gmodel.SearchTags("TAG1", "invalid", 1, true); !errors.Is(err, gm.ErrInvalidArgument) {
+		t.Fatal(err)
+	}
+
+	// 其他分类法
+	articleId, customArticleId, err = gmodel.AddArticleWithTerms([]string{"tag4"},
+		map[string][]string{"keyword": {"go", "LevelDB"}}, "terms", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	articles = gmodel.GetNextArticlesByTerm("keyword", "leveldb", 0, "", 10)
+	if len(articles) != 1 || articles[0].Id != articleId || !isEqual(articles[0].TermNames["keyword"], []string{"go", "LevelDB"}) {
+		t.Fatal()
+	}
+	if _, _, err = gmodel.AddArticleWithTerms(nil, map[string][]string{"none": {"go"}}, "terms", ""); err == nil {
+		t.Fatal()
+	}
+	if gmodel.UpdateArticleWithTerms(0, customArticleId, []string{"tag4"}, map[string][]string{"keyword": {"go"}}, "new terms") != nil {
+		t.Fatal()
+	}
+	if len(gmodel.GetPrevArticlesByTerm("keyword", "leveldb", 0, "", 10)) != 0 {
+		t.Fatal()
+	}
+	article, err = gmodel.GetArticle(articleId, "")
+	if err != nil || article.Data != "new terms" || !isEqual(article.TermNames["keyword"], []string{"go"}) {
+		t.Fatal(err)
+	}
+
+	// 按页码分页
+	page, err := gmodel.GetArticlesByTagPage("", "tag4", 1, 1, "asc")
+	if err != nil || page.Total != 2 || page.PageCount != 2 || len(page.RemoteArticles) != 1 || page.RemoteArticles[0].Id != 3 {
+		t.Fatal(err)
+	}
+	page, err = gmodel.GetArticlesByTagPage("keyword", "go", 1, 10, "")
+	if err != nil || page.Total != 1 || page.RemoteArticles[0].Id != articleId {
+		t.Fatal(err)
+	}
+	if _, err = gmodel.GetArticlesByTagPage("", "tag4", 0, 10, ""); err == nil {
+		t.Fatal()
+	}
+
+	// 游标分页
+	list, err := gmodel.ListArticles("", 2, "desc")
+	if err != nil || len(list.Items) != 2 || list.Items[0].Id != articleId || list.PrevCursor != "" || list.NextCursor == "" {
+		t.Fatal(err)
+	}
+	list, err = gmodel.ListArticles(list.NextCursor, 2, "desc")
+	if err != nil || len(list.Items) == 0 || list.Items[0].Id >= articleId || list.PrevCursor == "" {
+		t.Fatal(err)
+	}
+	if _, err = gmodel.ListArticles(list.NextCursor+"x", 2, "desc"); err == nil {
+		t.Fatal()
+	}
+	list, err = gmodel.ListArticlesByTag("", "tag4", "", 1, "asc")
+	if err != nil || len(list.Items) != 1 || list.Items[0].Id != 3 || list.NextCursor == "" {
+		t.Fatal(err)
+	}
+	list, err = gmodel.ListArticlesByTag("", "tag4", list.NextCursor, 1, "asc")
+	if err != nil || len(list.Items) != 1 || list.Items[0].Id != articleId || list.NextCursor != "" {
+		t.Fatal(err)
+	}
+	if _, err = gmodel.ListArticlesByTag("keyword", "go", list.PrevCursor, 1, "asc"); err == nil {
+		t.Fatal()
+	}
+	tagList, err := gmodel.ListTags("", 100, "asc")
+	if err != nil || uint64(len(tagList.Items)) != gmodel.GetTagCount() || tagList.NextCursor != "" {
+		t.Fatal(err)
+	}
+	tagList, err = gmodel.ListTopTags("", 1)
+	if err != nil || len(tagList.Items) != 1 || tagList.Items[0].ArticleCount != 2 || tagList.NextCursor == "" {
+		t.Fatal(err)
+	}
+
+	// 批量获取文章
+	batch, errs, err := gmodel.GetArticles([]GetArticleReq{
+		{ArticleId: articleId},
+		{CustomArticleId: "none"},
+		{CustomArticleId: customArticleId},
+		{ArticleId: 1000},
+	})
+	if err != nil || len(batch) != 4 || len(errs) != 4 {
+		t.Fatal(err)
+	}
+	if errs[0] != nil || batch[0].Id != articleId || batch[0].CustomArticleId != customArticleId ||
+		!isEqual(batch[0].TagNameArray, []string{"tag4"}) || !isEqual(batch[0].TermNames["keyword"], []string{"go"}) {
+		t.Fatal(errs[0])
+	}
+	if errs[1] == nil || batch[1] != nil || errs[2] != nil || batch[2].Id != articleId || errs[3] == nil {
+		t.Fatal()
+	}
+	if _, _, err = gmodel.GetArticles(make([]GetArticleReq, MaxGetArticlesCount+1)); err == nil {
+		t.Fatal()
+	}
+
+	// 批量增加文章
+	results, err := gmodel.AddArticles([]AddArticleReq{
+		{Tags: []string{"batch"}, Data: "batch_1"},
+		{Tags: []string{"batch"}, Data: "batch_2", CustomArticleId: "batch_2"},
+		{Tags: []string{"batch"}, Data: "batch_3", CustomArticleId: "batch_2"},
+		{Tags: []string{"batch"}, Data: "batch_4", CustomArticleId: customArticleId},
+		{Tags: []string{"batch"}, Data: ""},
+		{Tags: []string{"batch"}, Terms: map[string][]string{"keyword": {"batch"}}, Data: "batch_6"},
+	})
+	if err != nil || len(results) != 6 {
+		t.Fatal(err)
+	}
+	if results[0].ErrCode != ErrCodeSuccess || results[0].CustomArticleId == "" || results[1].CustomArticleId != "batch_2" {
+		t.Fatal()
+	}
+	if results[2].ErrCode == ErrCodeSuccess || results[3].ErrCode == ErrCodeSuccess || results[4].ErrCode == ErrCodeSuccess {
+		t.Fatal()
+	}
+	if results[5].ErrCode != ErrCodeSuccess || results[5].ArticleId != results[1].ArticleId+1 {
+		t.Fatal()
+	}
+	if gmodel.GetArticleCountByTag("batch") != 3 {
+		t.Fatal()
+	}
+	article, err = gmodel.GetArticle(0, "batch_2")
+	if err != nil || article.Data != "batch_2" || article.Id != results[1].ArticleId {
+		t.Fatal(err)
+	}
+	if _, err = gmodel.AddArticles(make([]AddArticleReq, MaxAddArticlesCount+1)); err == nil {
+		t.Fatal()
+	}
+
 	articles = gmodel.GetNextArticles(0, "", 10)
 	for _, article := range articles {
 		fmt.Println(article.Id, convertTagIds(gmodel, article.TagIds), article.Data)
@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
 	"sync"
 )
 
@@ -12,32 +15,152 @@ type Tag struct {
 	Id           uint64 `json:"id"`            // 分类ID，从1开始自增，唯一标识，不允许修改
 	Name         string `json:"name"`          // 分类名称，唯一标识，允许修改
 	ArticleCount uint64 `json:"article_count"` // 该分类下的文章数量
+	TagMeta
+}
+
+// 分类的附加信息，都可以为空
+type TagMeta struct {
+	Slug        string            `json:"slug,omitempty"`        // URL别名，唯一标识，比如 "javascript"
+	Description string            `json:"description,omitempty"` // 分类描述
+	Aliases     []string          `json:"aliases,omitempty"`     // 分类的别名，按别名查找时返回该分类，比如 "JS"
+	Attrs       map[string]string `json:"attrs,omitempty"`       // 自定义属性，由上层解析
+}
+
+// 是否设置了附加信息
+func (this *TagMeta) hasMeta() bool {
+	return this.Slug != "" || this.Description != "" || len(this.Aliases) > 0 || len(this.Attrs) > 0
 }
 
 var (
 	// 同时将ID和名称作为key保存，ID加上 id_ 前缀，名称加上 name_ 前缀
 	tagKeyPrefixId   = "id_"
 	tagKeyPrefixName = "name_"
+
+	// slug和别名只保存分类ID，slug加上 slug_ 前缀，别名加上 alias_ 前缀
+	tagKeyPrefixSlug  = "slug_"
+	tagKeyPrefixAlias = "alias_"
+
+	// 数据库中不止 id_ 和 name_ 两种key，所以不能再用 key总数/2 作为分类总数，需要单独保存
+	tagKeyCount = []byte("meta_count")
+
+	// 数据格式的版本号，打开旧版本的数据库时会自动升级
+	tagKeyVersion = []byte("meta_version")
+
+	// 已经按规范化函数合并过冲突的分类，详见 GModel.MigrateTagNames
+	tagKeyNamesMigrated = []byte("meta_names_migrated")
+
+	// 按文章数从多到少排序的索引，格式：rank_(MaxUint64-文章数)_分类ID -> 分类ID
+	// 文章数取反并补全到20位，这样文章数越多，key的字典序越小，文章数相同时按ID从小到大排序
+	tagKeyPrefixRank = "rank_"
+
+	// 大小写折叠后的名称索引，用于忽略大小写的前缀查找，格式：fold_折叠后的名称\x00分类ID -> 分类ID
+	// 不同的分类折叠后可能相同，所以需要带上分类ID
+	tagKeyPrefixFold = "fold_"
 )
 
+// 当前数据格式的版本号
+const tagSchemaVersion = 3
+
 // 分类管理器
 type TagMgr struct {
 	db    *KVStore
 	mutex sync.RWMutex
+
+	// 分类名称（包括别名）的规范化函数，为nil时不做规范化，按原始字节比较
+	normalizer TagNormalizer
+
+	// 游标签名的密钥，GModel 中和其他列表共用，单独使用时打开时随机生成
+	cursors *cursorSigner
 }
 
 // 打开数据库文件
 func (this *TagMgr) Open(path string) error {
+	if this.cursors == nil {
+		this.cursors = newCursorSigner()
+	}
 	this.db = &KVStore{}
-	return this.db.Open(path)
+	if err := this.db.Open(path); err != nil {
+		return err
+	}
+	if err := this.upgrade(); err != nil {
+		this.db.Close()
+		return err
+	}
+	return nil
+}
+
+// 升级旧版本的数据库
+func (this *TagMgr) upgrade() error {
+	version := this.getMetaUint(tagKeyVersion)
+	if version >= tagSchemaVersion {
+		return nil
+	}
+
+	if version < 1 {
+		// 旧版本只有 id_ 和 name_ 两种key，统计 id_ 的数量即可
+		var count uint64
+		this.db.Scan([]byte(tagKeyPrefixId), func(key, value []byte) bool {
+			count++
+			return true
+		})
+		if err := this.putMetaUint(tagKeyCount, count); err != nil {
+			return err
+		}
+	}
+
+	if version < 2 {
+		// 建立按文章数排序的索引
+		for _, tag := range this.all() {
+			if err := this.putRankKey(tag); err != nil {
+				return err
+			}
+		}
+	}
+
+	if version < 3 {
+		// 建立大小写折叠后的名称索引
+		for _, tag := range this.all() {
+			if err := this.putFoldKey(tag); err != nil {
+				return err
+			}
+		}
+	}
+
+	return this.putMetaUint(tagKeyVersion, tagSchemaVersion)
 }
 
 func (this *TagMgr) Close() error {
 	return this.db.Close()
 }
 
+// 设置分类名称的规范化函数，需要在Open之后、使用之前设置
+// 如果数据库中已经有分类，修改规范化函数后需要调用 GModel.MigrateTagNames 合并冲突的分类
+func (this *TagMgr) SetNormalizer(normalizer TagNormalizer) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	this.normalizer = normalizer
+}
+
+// 返回规范化后的分类名称
+func (this *TagMgr) Normalize(name string) string {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	return this.normalize(name)
+}
+
+func (this *TagMgr) normalize(name string) string {
+	if this.normalizer == nil {
+		return name
+	}
+	return this.normalizer(name)
+}
+
 // 返回指定分类的后N个分类（不包括当前分类）
 // 如果分类不存在，则表示获取最旧的N个分类
+//
+// Deprecated: 使用 List 代替
 func (this *TagMgr) NextByName(name string, n int) []*Tag {
 	this.mutex.RLock()
 	defer this.mutex.RUnlock()
@@ -52,6 +175,8 @@ func (this *TagMgr) NextByName(name string, n int) []*Tag {
 
 // 返回指定分类ID的后N个分类（不包括当前id）
 // 如果id等于0，则表示获取最旧的N个分类
+//
+// Deprecated: 使用 List 代替
 func (this *TagMgr) Next(id uint64, n int) []*Tag {
 	this.mutex.RLock()
 	defer this.mutex.RUnlock()
@@ -86,6 +211,8 @@ func (this *TagMgr) next(id uint64, n int) []*Tag {
 
 // 返回指定分类的前N个分类（不包括当前分类）
 // 如果分类不存在，则表示获取最新的N个分类
+//
+// Deprecated: 使用 List 代替
 func (this *TagMgr) PrevByName(name string, n int) []*Tag {
 	this.mutex.RLock()
 	defer this.mutex.RUnlock()
@@ -100,6 +227,8 @@ func (this *TagMgr) PrevByName(name string, n int) []*Tag {
 
 // 返回指定分类ID的前N个分类（不包括当前id）
 // 如果id大于CurrentSequence，则表示获取最新的N个分类
+//
+// Deprecated: 使用 List 代替
 func (this *TagMgr) Prev(id uint64, n int) []*Tag {
 	this.mutex.RLock()
 	defer this.mutex.RUnlock()
@@ -144,13 +273,27 @@ func (this *TagMgr) prev(id uint64, n int) []*Tag {
 	return tags
 }
 
+// 按分类ID分页，cursor 为空表示第一页，下一页、上一页传入返回的 NextCursor、PrevCursor
+// OrderDesc 表示最新的分类在前面
+func (this *TagMgr) List(cursor string, n int, order Order) (*Page[*Tag], error) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	return scanPage(this.cursors, this.db, []byte(tagKeyPrefixId), "tag", cursor, n, order, func(key, value []byte) (*Tag, bool) {
+		tag := &Tag{}
+		if err := json.Unmarshal(value, tag); err != nil {
+			return nil, false
+		}
+		return tag, true
+	})
+}
+
 // 获取分类数量
 func (this *TagMgr) Count() uint64 {
 	this.mutex.RLock()
 	defer this.mutex.RUnlock()
 
-	// 注意要除以2
-	return this.db.Count() / 2
+	return this.getMetaUint(tagKeyCount)
 }
 
 // 增加分类，返回分类ID
@@ -170,25 +313,57 @@ func (this *TagMgr) Add(name string) (uint64, error) {
 	}
 	newTag.Name = name
 
-	return newTag.Id, this.putTag(newTag)
+	this.clearNamesMigrated()
+	if err = this.putTag(newTag); err != nil {
+		return 0, err
+	}
+
+	return newTag.Id, this.putMetaUint(tagKeyCount, this.getMetaUint(tagKeyCount)+1)
 }
 
 // 保存分类
 func (this *TagMgr) putTag(tag *Tag) error {
+	batch := &KVBatch{}
+	if err := this.putTagBatch(batch, tag); err != nil {
+		return err
+	}
+	if err := this.db.Write(batch); err != nil {
+		return errors.New(fmt.Sprintf("Tag put [%v %v] failed: %v", tag.Id, tag.Name, err))
+	}
+	return nil
+}
+
+// 把保存分类需要的修改加到 batch 中，和数据库中已有的分类比较，
+// 只有文章数变化时才修改排序索引，只有名称变化时才修改名称索引
+func (this *TagMgr) putTagBatch(batch *KVBatch, tag *Tag) error {
 	value, err := json.Marshal(tag)
 	if err != nil {
 		return err
 	}
 
-	err1 := this.db.Put(this.getKeyFromId(tag.Id), value)
-	err2 := this.db.Put(this.getKeyFromName(tag.Name), value)
-	if err1 != nil || err2 != nil {
-		// 如果有一个失败，则全部清空
-		this.db.Delete(this.getKeyFromId(tag.Id))
-		this.db.Delete(this.getKeyFromName(tag.Name))
-		return errors.New(fmt.Sprintf("Tag put [%v %v] failed", tag.Id, tag.Name))
+	id := []byte(strconv.FormatUint(tag.Id, 10))
+	oldTag, err := this.getById(tag.Id)
+	if err != nil {
+		oldTag = nil
+	}
+	if oldTag == nil || oldTag.ArticleCount != tag.ArticleCount || this.normalize(oldTag.Name) != this.normalize(tag.Name) {
+		if oldTag != nil {
+			batch.Delete(this.getKeyFromRank(oldTag))
+		}
+		// 未分类不参与排序
+		if this.normalize(tag.Name) != "" {
+			batch.Put(this.getKeyFromRank(tag), id)
+		}
+	}
+	if oldTag == nil || !bytes.Equal(this.getKeyFromFold(oldTag), this.getKeyFromFold(tag)) {
+		if oldTag != nil {
+			batch.Delete(this.getKeyFromFold(oldTag))
+		}
+		batch.Put(this.getKeyFromFold(tag), id)
 	}
 
+	batch.Put(this.getKeyFromId(tag.Id), value)
+	batch.Put(this.getKeyFromName(tag.Name), value)
 	return nil
 }
 
@@ -217,9 +392,26 @@ func (this *TagMgr) DeleteById(id uint64) error {
 }
 
 func (this *TagMgr) deleteTag(tag *Tag) error {
-	this.db.Delete(this.getKeyFromId(tag.Id))
-	this.db.Delete(this.getKeyFromName(tag.Name))
-	return nil
+	batch := &KVBatch{}
+	this.deleteTagBatch(batch, tag)
+	if count := this.getMetaUint(tagKeyCount); count > 0 {
+		batch.Put(tagKeyCount, []byte(strconv.FormatUint(count-1, 10)))
+	}
+	return this.db.Write(batch)
+}
+
+// 把删除分类需要的修改加到 batch 中，不修改分类总数
+func (this *TagMgr) deleteTagBatch(batch *KVBatch, tag *Tag) {
+	batch.Delete(this.getKeyFromId(tag.Id))
+	batch.Delete(this.getKeyFromName(tag.Name))
+	batch.Delete(this.getKeyFromRank(tag))
+	batch.Delete(this.getKeyFromFold(tag))
+	if tag.Slug != "" {
+		batch.Delete(this.getKeyFromSlug(tag.Slug))
+	}
+	for _, alias := range tag.Aliases {
+		batch.Delete(this.getKeyFromAlias(alias))
+	}
 }
 
 // 修改分类名字
@@ -232,19 +424,369 @@ func (this *TagMgr) Rename(oldName string, newName string) error {
 		return err
 	}
 
-	_, err = this.getByName(newName)
-	if err == nil {
-		return errors.New(fmt.Sprintf("Tag newName[%v] exist", newName))
+	if tag, err := this.getByName(newName); err == nil {
+		if tag.Id != oldTag.Id || tag.Name == newName {
+			return newError(ErrExists, fmt.Sprintf("Tag newName[%v] exist", newName))
+		}
+
+		// 允许将分类改名为自己的别名，此时别名被删掉
+		// 如果只是规范化后相同（比如只修改了大小写），则只修改显示的名称
+		normalizedName := this.normalize(newName)
+		if normalizedName != this.normalize(oldTag.Name) {
+			this.db.Delete(this.getKeyFromAlias(newName))
+
+			aliases := make([]string, 0)
+			for _, alias := range oldTag.Aliases {
+				if this.normalize(alias) != normalizedName {
+					aliases = append(aliases, alias)
+				}
+			}
+			oldTag.Aliases = aliases
+		}
 	}
 
-	// 删除旧的
-	this.deleteTag(oldTag)
+	// 删除旧的名称，slug和别名只保存了ID，不需要修改
+	this.db.Delete(this.getKeyFromName(oldTag.Name))
+	this.clearNamesMigrated()
 
 	// 增加新的
 	oldTag.Name = newName
 	return this.putTag(oldTag)
 }
 
+// 修改分类的附加信息（slug、描述、别名、自定义属性），会覆盖旧的附加信息
+// slug不能和其他分类的slug重复，别名不能和其他分类的名称或者别名重复
+func (this *TagMgr) SetMeta(name string, meta *TagMeta) error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	tag, err := this.getByName(name)
+	if err != nil {
+		return err
+	}
+
+	if meta.Slug != "" {
+		if id, ok := this.getIdByKey(this.getKeyFromSlug(meta.Slug)); ok && id != tag.Id {
+			return newError(ErrExists, fmt.Sprintf("Tag slug[%v] exist", meta.Slug))
+		}
+	}
+
+	// 别名去重，并且过滤掉空字符串和分类名称本身
+	aliases := make([]string, 0)
+	aliasMark := make(map[string]bool)
+	for _, alias := range meta.Aliases {
+		normalizedAlias := this.normalize(alias)
+		if normalizedAlias == "" || normalizedAlias == this.normalize(tag.Name) || aliasMark[normalizedAlias] {
+			continue
+		}
+		aliasMark[normalizedAlias] = true
+
+		if other, err := this.getByName(alias); err == nil && other.Id != tag.Id {
+			return newError(ErrExists, fmt.Sprintf("Tag alias[%v] exist", alias))
+		}
+		aliases = append(aliases, alias)
+	}
+
+	// 先删除旧的slug和别名，再保存新的
+	this.deleteMetaKeys(tag)
+	this.clearNamesMigrated()
+
+	tag.Slug = meta.Slug
+	tag.Description = meta.Description
+	tag.Aliases = aliases
+	tag.Attrs = meta.Attrs
+
+	value := []byte(strconv.FormatUint(tag.Id, 10))
+	if tag.Slug != "" {
+		if err = this.db.Put(this.getKeyFromSlug(tag.Slug), value); err != nil {
+			return err
+		}
+	}
+	for _, alias := range tag.Aliases {
+		if err = this.db.Put(this.getKeyFromAlias(alias), value); err != nil {
+			return err
+		}
+	}
+
+	return this.putTag(tag)
+}
+
+// 返回文章数最多的N个分类，按文章数从多到少排序，文章数相同时按ID从小到大排序
+// 注意：未分类（名称为空的分类）不参与排序
+func (this *TagMgr) Top(n int) []*Tag {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	return this.top([]byte(tagKeyPrefixRank), n)
+}
+
+// 按文章数从多到少的顺序，返回指定分类的后N个分类（不包括当前分类），用于分页
+// 如果分类不存在，则表示获取文章数最多的N个分类
+//
+// Deprecated: 使用 ListTop 代替
+func (this *TagMgr) TopByName(name string, n int) []*Tag {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	searchKey := []byte(tagKeyPrefixRank)
+	if tag, err := this.getByName(name); err == nil && this.normalize(tag.Name) != "" {
+		searchKey = this.getKeyFromRank(tag)
+	}
+
+	return this.top(searchKey, n)
+}
+
+// 按文章数从多到少的顺序分页，cursor 为空表示第一页，详见 List
+// 注意：翻页期间文章数发生变化的分类可能会重复出现或者被跳过
+func (this *TagMgr) ListTop(cursor string, n int) (*Page[*Tag], error) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	return scanPage(this.cursors, this.db, []byte(tagKeyPrefixRank), "tag_top", cursor, n, OrderAsc, func(key, value []byte) (*Tag, bool) {
+		if id, ok := this.getIdByKey(key); ok {
+			if tag, err := this.getById(id); err == nil {
+				return tag, true
+			}
+		}
+		return nil, false
+	})
+}
+
+func (this *TagMgr) top(searchKey []byte, n int) []*Tag {
+	tags := make([]*Tag, 0)
+	if n == 0 {
+		return tags
+	}
+
+	keys := this.db.Next(searchKey, n)
+	for _, key := range keys {
+		if !bytes.HasPrefix(key, []byte(tagKeyPrefixRank)) {
+			break
+		}
+
+		if id, ok := this.getIdByKey(key); ok {
+			if tag, err := this.getById(id); err == nil {
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	return tags
+}
+
+// 按名称前缀查找分类，cursor 为空表示第一页，下一页、上一页传入返回的 NextCursor、PrevCursor
+// ignoreCase 为 false 时按规范化后的名称的字典序返回，前缀也会先规范化
+// ignoreCase 为 true 时忽略大小写，按大小写折叠后的名称的字典序返回
+// 游标和前缀、ignoreCase 绑定，锚点分类被删除或者改名之后仍然有效；名称为空的分类（未分类）不会返回
+func (this *TagMgr) SearchByPrefix(prefix string, cursor string, n int, ignoreCase bool) (*Page[*Tag], error) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	searchPrefix := this.getKeyFromName(prefix)
+	if ignoreCase {
+		searchPrefix = []byte(tagKeyPrefixFold + FoldCaseNormalizer(this.normalize(prefix)))
+	}
+
+	return scanPage(this.cursors, this.db, searchPrefix, "tag_search_"+string(searchPrefix), cursor, n, OrderAsc, func(key, value []byte) (*Tag, bool) {
+		tag := &Tag{}
+		if ignoreCase {
+			id, err := strconv.ParseUint(string(value), 10, 64)
+			if err != nil {
+				return nil, false
+			}
+			if tag, err = this.getById(id); err != nil {
+				return nil, false
+			}
+		} else if err := json.Unmarshal(value, tag); err != nil {
+			return nil, false
+		}
+
+		if this.normalize(tag.Name) == "" {
+			return nil, false
+		}
+		return tag, true
+	})
+}
+
+// 保存大小写折叠后的名称索引
+func (this *TagMgr) putFoldKey(tag *Tag) error {
+	return this.db.Put(this.getKeyFromFold(tag), []byte(strconv.FormatUint(tag.Id, 10)))
+}
+
+// 保存按文章数排序的索引，未分类不参与排序
+func (this *TagMgr) putRankKey(tag *Tag) error {
+	if this.normalize(tag.Name) == "" {
+		return nil
+	}
+	return this.db.Put(this.getKeyFromRank(tag), []byte(strconv.FormatUint(tag.Id, 10)))
+}
+
+// 返回全部分类，按ID从小到大排序
+func (this *TagMgr) all() []*Tag {
+	tags := make([]*Tag, 0)
+	this.db.Scan([]byte(tagKeyPrefixId), func(key, value []byte) bool {
+		tag := &Tag{}
+		if err := json.Unmarshal(value, tag); err == nil {
+			tags = append(tags, tag)
+		}
+		return true
+	})
+	return tags
+}
+
+// 将 from 分类的附加信息合并到 to 分类，然后删除 from 分类，from 的名称作为 to 的别名
+// to 已有的附加信息优先，文章数和文章索引由调用者负责迁移
+// 注意：名称和别名的key可能已经和 to 冲突，需要在合并完之后调用 rebuildKeys
+func (this *TagMgr) merge(from, to *Tag) error {
+	if to.Slug == "" {
+		to.Slug = from.Slug
+	} else if from.Slug != "" {
+		this.db.Delete(this.getKeyFromSlug(from.Slug))
+	}
+	if to.Description == "" {
+		to.Description = from.Description
+	}
+	to.Aliases = append(to.Aliases, from.Name)
+	to.Aliases = append(to.Aliases, from.Aliases...)
+	for key, value := range from.Attrs {
+		if _, exist := to.Attrs[key]; !exist {
+			if to.Attrs == nil {
+				to.Attrs = make(map[string]string)
+			}
+			to.Attrs[key] = value
+		}
+	}
+
+	this.db.Delete(this.getKeyFromId(from.Id))
+	this.db.Delete(this.getKeyFromRank(from))
+	this.db.Delete(this.getKeyFromFold(from))
+	if count := this.getMetaUint(tagKeyCount); count > 0 {
+		this.putMetaUint(tagKeyCount, count-1)
+	}
+
+	return this.putTag(to)
+}
+
+// 按当前的规范化函数重建所有分类的名称、别名和slug的key
+func (this *TagMgr) rebuildKeys() error {
+	tagExist := func(id uint64) bool {
+		return this.db.Has(this.getKeyFromId(id))
+	}
+
+	// 找出不符合当前规范化函数，或者分类已经不存在的key
+	staleKeys := make([][]byte, 0)
+	this.db.Scan([]byte(tagKeyPrefixName), func(key, value []byte) bool {
+		tag := &Tag{}
+		if err := json.Unmarshal(value, tag); err != nil || !tagExist(tag.Id) ||
+			!bytes.Equal(key, this.getKeyFromName(tag.Name)) {
+			staleKeys = append(staleKeys, key)
+		}
+		return true
+	})
+	this.db.Scan([]byte(tagKeyPrefixAlias), func(key, value []byte) bool {
+		alias := string(key[len(tagKeyPrefixAlias):])
+		id, err := strconv.ParseUint(string(value), 10, 64)
+		if err != nil || !tagExist(id) || !bytes.Equal(key, this.getKeyFromAlias(alias)) {
+			staleKeys = append(staleKeys, key)
+		}
+		return true
+	})
+	// 排序索引（未分类不参与排序）和大小写折叠后的名称索引也依赖规范化函数，全部删掉，下面重新建立
+	for _, prefix := range []string{tagKeyPrefixRank, tagKeyPrefixFold} {
+		this.db.Scan([]byte(prefix), func(key, value []byte) bool {
+			staleKeys = append(staleKeys, key)
+			return true
+		})
+	}
+	for _, key := range staleKeys {
+		this.db.Delete(key)
+	}
+
+	for _, tag := range this.all() {
+		// 别名按规范化后的结果去重
+		value := []byte(strconv.FormatUint(tag.Id, 10))
+		aliases := make([]string, 0)
+		aliasMark := map[string]bool{this.normalize(tag.Name): true}
+		for _, alias := range tag.Aliases {
+			normalizedAlias := this.normalize(alias)
+			if aliasMark[normalizedAlias] {
+				continue
+			}
+			aliasMark[normalizedAlias] = true
+			aliases = append(aliases, alias)
+
+			if err := this.db.Put(this.getKeyFromAlias(alias), value); err != nil {
+				return err
+			}
+		}
+		tag.Aliases = aliases
+
+		if tag.Slug != "" {
+			if err := this.db.Put(this.getKeyFromSlug(tag.Slug), value); err != nil {
+				return err
+			}
+		}
+
+		if err := this.putTag(tag); err != nil {
+			return err
+		}
+		if err := this.putRankKey(tag); err != nil {
+			return err
+		}
+		if err := this.putFoldKey(tag); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// 规范化后的名称和别名，按名称或者别名查找时都会找到这个分类
+func (this *TagMgr) lookupNames(tag *Tag) []string {
+	names := []string{this.normalize(tag.Name)}
+	for _, alias := range tag.Aliases {
+		if normalizedAlias := this.normalize(alias); normalizedAlias != "" {
+			names = append(names, normalizedAlias)
+		}
+	}
+	return names
+}
+
+// 是否已经按规范化函数合并过冲突的分类
+func (this *TagMgr) namesMigrated() bool {
+	return this.db.Has(tagKeyNamesMigrated)
+}
+
+func (this *TagMgr) setNamesMigrated() error {
+	return this.putMetaUint(tagKeyNamesMigrated, 1)
+}
+
+// 没有规范化函数时增加或者修改的名称、别名，规范化之后可能和其他分类冲突，需要重新合并
+func (this *TagMgr) clearNamesMigrated() {
+	if this.normalizer == nil && this.db.Has(tagKeyNamesMigrated) {
+		this.db.Delete(tagKeyNamesMigrated)
+	}
+}
+
+// 删除ID大于 id 的、没有文章也没有附加信息的分类，用于批量增加文章失败时删除新增加的分类
+func (this *TagMgr) deleteEmptyTagsAfter(id uint64) {
+	for id++; id <= this.db.CurrentSequence(); id++ {
+		if tag, err := this.getById(id); err == nil && tag.ArticleCount == 0 && !tag.hasMeta() {
+			this.deleteTag(tag)
+		}
+	}
+}
+
+// 删除分类的slug和别名
+func (this *TagMgr) deleteMetaKeys(tag *Tag) {
+	if tag.Slug != "" {
+		this.db.Delete(this.getKeyFromSlug(tag.Slug))
+	}
+	for _, alias := range tag.Aliases {
+		this.db.Delete(this.getKeyFromAlias(alias))
+	}
+}
+
 // 增加（或减少）指定分类下的文章数量
 // 返回更新后该分类下的文章数量
 func (this *TagMgr) AddArticleCountForName(name string, count int64) (uint64, error) {
@@ -273,6 +815,53 @@ func (this *TagMgr) AddArticleCountForId(id uint64, count int64) (uint64, error)
 	return this.addArticleCount(tag, count)
 }
 
+// 一次写入多个分类的文章数变化，counts 为分类ID到变化量，不存在的分类忽略
+// 文章数减到0并且没有附加信息（slug、描述等）的分类一起删除，用于修改文章时所有分类的文章数一起更新
+func (this *TagMgr) AddArticleCounts(counts map[uint64]int64) error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	// 按分类ID排序，保证每次的写入顺序一样
+	ids := make([]uint64, 0, len(counts))
+	for id, count := range counts {
+		if count != 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	batch := &KVBatch{}
+	deleted := uint64(0)
+	for _, id := range ids {
+		tag, err := this.getById(id)
+		if err != nil {
+			continue
+		}
+
+		num := int64(tag.ArticleCount) + counts[id]
+		if num <= 0 && counts[id] < 0 && !tag.hasMeta() {
+			this.deleteTagBatch(batch, tag)
+			deleted++
+			continue
+		}
+		if num < 0 {
+			num = 0
+		}
+		tag.ArticleCount = uint64(num)
+		if err = this.putTagBatch(batch, tag); err != nil {
+			return err
+		}
+	}
+
+	if count := this.getMetaUint(tagKeyCount); deleted > 0 && count > 0 {
+		if deleted > count {
+			deleted = count
+		}
+		batch.Put(tagKeyCount, []byte(strconv.FormatUint(count-deleted, 10)))
+	}
+	return this.db.Write(batch)
+}
+
 func (this *TagMgr) addArticleCount(tag *Tag, count int64) (uint64, error) {
 	// TODO 不需要考虑溢出情况
 	num := int64(tag.ArticleCount)
@@ -317,10 +906,27 @@ func (this *TagMgr) GetById(id uint64) (*Tag, error) {
 	return this.getById(id)
 }
 
+// 批量获取分类，返回分类ID到分类的映射，不存在的分类ID不在结果中
+func (this *TagMgr) GetByIds(ids []uint64) map[uint64]*Tag {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	tags := make(map[uint64]*Tag)
+	for _, id := range ids {
+		if _, exist := tags[id]; exist {
+			continue
+		}
+		if tag, err := this.getById(id); err == nil {
+			tags[id] = tag
+		}
+	}
+	return tags
+}
+
 func (this *TagMgr) getById(id uint64) (*Tag, error) {
 	value, err := this.db.Get(this.getKeyFromId(id))
 	if err != nil {
-		return nil, errors.New(fmt.Sprintf("Tag ID[%v] not found", id))
+		return nil, newError(ErrNotFound, fmt.Sprintf("Tag ID[%v] not found", id))
 	}
 
 	tag := &Tag{}
@@ -336,10 +942,14 @@ func (this *TagMgr) GetByName(name string) (*Tag, error) {
 	return this.getByName(name)
 }
 
+// 如果名称不存在，再按别名查找
 func (this *TagMgr) getByName(name string) (*Tag, error) {
 	value, err := this.db.Get(this.getKeyFromName(name))
 	if err != nil {
-		return nil, errors.New(fmt.Sprintf("Tag Name[%v] not found", name))
+		if id, ok := this.getIdByKey(this.getKeyFromAlias(name)); ok {
+			return this.getById(id)
+		}
+		return nil, newError(ErrNotFound, fmt.Sprintf("Tag Name[%v] not found", name))
 	}
 
 	tag := &Tag{}
@@ -347,12 +957,67 @@ func (this *TagMgr) getByName(name string) (*Tag, error) {
 	return tag, err
 }
 
+// 根据slug获取分类
+func (this *TagMgr) GetBySlug(slug string) (*Tag, error) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	if id, ok := this.getIdByKey(this.getKeyFromSlug(slug)); ok {
+		return this.getById(id)
+	}
+	return nil, newError(ErrNotFound, fmt.Sprintf("Tag Slug[%v] not found", slug))
+}
+
+// 读取只保存了分类ID的key，比如 slug_ 和 alias_
+func (this *TagMgr) getIdByKey(key []byte) (uint64, bool) {
+	if value, err := this.db.Get(key); err == nil {
+		if id, err := strconv.ParseUint(string(value), 10, 64); err == nil {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
 // 根据分类ID获取存储key
 func (this *TagMgr) getKeyFromId(id uint64) []byte {
 	return []byte(tagKeyPrefixId + GetStringKey(id))
 }
 
-// 根据分类名称获取存储key
+// 根据分类名称获取存储key，使用规范化后的名称
 func (this *TagMgr) getKeyFromName(name string) []byte {
-	return []byte(tagKeyPrefixName + name)
+	return []byte(tagKeyPrefixName + this.normalize(name))
+}
+
+// 根据slug获取存储key
+func (this *TagMgr) getKeyFromSlug(slug string) []byte {
+	return []byte(tagKeyPrefixSlug + slug)
+}
+
+// 根据别名获取存储key，使用规范化后的别名
+func (this *TagMgr) getKeyFromAlias(alias string) []byte {
+	return []byte(tagKeyPrefixAlias + this.normalize(alias))
+}
+
+// 根据分类的文章数获取排序索引的key
+func (this *TagMgr) getKeyFromRank(tag *Tag) []byte {
+	return []byte(fmt.Sprintf("%v%020v_%v", tagKeyPrefixRank, math.MaxUint64-tag.ArticleCount, GetStringKey(tag.Id)))
+}
+
+// 根据分类名称获取大小写折叠后的名称索引的key
+func (this *TagMgr) getKeyFromFold(tag *Tag) []byte {
+	return []byte(tagKeyPrefixFold + FoldCaseNormalizer(this.normalize(tag.Name)) + "\x00" + GetStringKey(tag.Id))
+}
+
+// 读取保存在数据库中的整数，比如分类总数
+func (this *TagMgr) getMetaUint(key []byte) uint64 {
+	if value, err := this.db.Get(key); err == nil {
+		if num, err := strconv.ParseUint(string(value), 10, 64); err == nil {
+			return num
+		}
+	}
+	return 0
+}
+
+func (this *TagMgr) putMetaUint(key []byte, num uint64) error {
+	return this.db.Put(key, []byte(strconv.FormatUint(num, 10)))
 }
@@ -244,3 +244,321 @@ func TestTagId(t *testing.T) {
 	}
 
 }
+
+func TestTagMeta(t *testing.T) {
+	dbPath := "test.db"
+	defer os.RemoveAll(dbPath)
+
+	mgr := &TagMgr{}
+	err := mgr.Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mgr.Close()
+
+	jsId, _ := mgr.Add("JavaScript")
+	goId, _ := mgr.Add("Go")
+
+	meta := &TagMeta{
+		Slug:        "javascript",
+		Description: "JavaScript programming language",
+		Aliases:     []string{"JS", "js", "JS", "JavaScript"},
+		Attrs:       map[string]string{"color": "yellow"},
+	}
+	if err = mgr.SetMeta("JavaScript", meta); err != nil {
+		t.Fatal(err)
+	}
+
+	tag, err := mgr.GetBySlug("javascript")
+	if err != nil || tag.Id != jsId || tag.Description != meta.Description || tag.Attrs["color"] != "yellow" {
+		t.Fatal(err)
+	}
+	if !isEqual(tag.Aliases, []string{"JS", "js"}) {
+		t.Fatal(tag.Aliases)
+	}
+
+	// 按别名查找返回原分类，增加别名等于增加原分类
+	tag, err = mgr.GetByName("JS")
+	if err != nil || tag.Id != jsId || tag.Name != "JavaScript" {
+		t.Fatal(err)
+	}
+	if id, err := mgr.Add("js"); err != nil || id != jsId {
+		t.Fatal()
+	}
+
+	// slug和别名不允许重复
+	if mgr.SetMeta("Go", &TagMeta{Slug: "javascript"}) == nil {
+		t.Fatal()
+	}
+	if mgr.SetMeta("Go", &TagMeta{Aliases: []string{"JS"}}) == nil {
+		t.Fatal()
+	}
+	if mgr.SetMeta("Go", &TagMeta{Aliases: []string{"JavaScript"}}) == nil {
+		t.Fatal()
+	}
+	if mgr.SetMeta("Go", &TagMeta{Slug: "go", Aliases: []string{"golang"}}) != nil {
+		t.Fatal()
+	}
+	if mgr.Rename("Go", "JS") == nil {
+		t.Fatal()
+	}
+
+	// 改名为自己的别名
+	if err = mgr.Rename("Go", "golang"); err != nil {
+		t.Fatal(err)
+	}
+	tag, _ = mgr.GetBySlug("go")
+	if tag.Id != goId || tag.Name != "golang" || len(tag.Aliases) != 0 {
+		t.Fatal()
+	}
+
+	// 覆盖旧的附加信息
+	if err = mgr.SetMeta("JavaScript", &TagMeta{Aliases: []string{"ECMAScript"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = mgr.GetBySlug("javascript"); err == nil {
+		t.Fatal()
+	}
+	if _, err = mgr.GetByName("JS"); err == nil {
+		t.Fatal()
+	}
+
+	if mgr.Count() != 2 {
+		t.Fatal()
+	}
+	if err = mgr.DeleteByName("ECMAScript"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = mgr.GetByName("ECMAScript"); err == nil {
+		t.Fatal()
+	}
+	if mgr.Count() != 1 {
+		t.Fatal()
+	}
+}
+
+func TestTagNormalizer(t *testing.T) {
+	dbPath := "test.db"
+	defer os.RemoveAll(dbPath)
+
+	mgr := &TagMgr{}
+	err := mgr.Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mgr.Close()
+
+	mgr.SetNormalizer(DefaultTagNormalizer)
+
+	goId, _ := mgr.Add("Go")
+	for _, name := range []string{"go ", "Ｇｏ", " GO"} {
+		if id, err := mgr.Add(name); err != nil || id != goId {
+			t.Fatal(name)
+		}
+	}
+	if mgr.Count() != 1 {
+		t.Fatal()
+	}
+
+	// 保留原始名称用于显示
+	tag, err := mgr.GetByName("ｇｏ")
+	if err != nil || tag.Name != "Go" {
+		t.Fatal(err)
+	}
+
+	// 只修改显示的名称
+	if err = mgr.Rename("go", "GO"); err != nil {
+		t.Fatal(err)
+	}
+	tag, _ = mgr.GetById(goId)
+	if tag.Name != "GO" {
+		t.Fatal()
+	}
+
+	if err = mgr.SetMeta("go", &TagMeta{Aliases: []string{"Golang", "GOLANG", "go"}}); err != nil {
+		t.Fatal(err)
+	}
+	tag, _ = mgr.GetByName("golang ")
+	if tag == nil || tag.Id != goId || len(tag.Aliases) != 1 {
+		t.Fatal()
+	}
+
+	mgr.Add("Rust")
+	if mgr.Rename("rust", "golang") == nil {
+		t.Fatal()
+	}
+	if mgr.Rename("Rust", " RUST ") != nil {
+		t.Fatal()
+	}
+	if mgr.Count() != 2 {
+		t.Fatal()
+	}
+}
+
+func TestTagTop(t *testing.T) {
+	dbPath := "test.db"
+	defer os.RemoveAll(dbPath)
+
+	mgr := &TagMgr{}
+	err := mgr.Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mgr.Close()
+
+	// tag1 ~ tag9 的文章数分别为 1 ~ 9
+	for i := 1; i <= 9; i++ {
+		name := "tag" + strconv.Itoa(i)
+		mgr.Add(name)
+		mgr.AddArticleCountForName(name, int64(i))
+	}
+	mgr.Add("tag0")
+	mgr.Add("")
+	mgr.AddArticleCountForName("", 100)
+
+	tags := mgr.Top(3)
+	if len(tags) != 3 || tags[0].Name != "tag9" || tags[1].Name != "tag8" || tags[2].Name != "tag7" {
+		t.Fatal()
+	}
+
+	// 分页
+	tags = mgr.TopByName("tag7", 100)
+	if len(tags) != 7 || tags[0].Name != "tag6" || tags[5].Name != "tag1" || tags[6].Name != "tag0" {
+		t.Fatal()
+	}
+	tags = mgr.TopByName("", 1)
+	if len(tags) != 1 || tags[0].Name != "tag9" {
+		t.Fatal()
+	}
+
+	// 文章数变化后排序也跟着变化，文章数相同时按ID排序
+	mgr.AddArticleCountForName("tag1", 8)
+	mgr.AddArticleCountForName("tag8", -1)
+	tags = mgr.Top(4)
+	if tags[0].Name != "tag1" || tags[1].Name != "tag9" || tags[2].Name != "tag7" || tags[3].Name != "tag8" {
+		t.Fatal()
+	}
+
+	mgr.Rename("tag1", "tag100")
+	mgr.DeleteByName("tag9")
+	tags = mgr.Top(2)
+	if tags[0].Name != "tag100" || tags[1].Name != "tag7" {
+		t.Fatal()
+	}
+	if len(mgr.Top(100)) != 9 {
+		t.Fatal()
+	}
+
+	// 每个分类只有一个排序索引和一个名称索引，修改附加信息不影响
+	mgr.SetMeta("tag100", &TagMeta{Aliases: []string{"t100"}})
+	mgr.AddArticleCountForName("tag100", 0)
+	for prefix, want := range map[string]int{tagKeyPrefixRank: 9, tagKeyPrefixFold: 10} {
+		count := 0
+		mgr.db.Scan([]byte(prefix), func(key, value []byte) bool {
+			count++
+			return true
+		})
+		if count != want {
+			t.Fatal(prefix, count)
+		}
+	}
+}
+
+func TestTagSearchByPrefix(t *testing.T) {
+	dbPath := "test.db"
+	defer os.RemoveAll(dbPath)
+
+	mgr := &TagMgr{}
+	err := mgr.Open(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mgr.Close()
+
+	for _, name := range []string{"Java", "JavaScript", "java8", "Go", "javac", "jav"} {
+		mgr.Add(name)
+	}
+
+	search := func(prefix string, cursor string, n int, ignoreCase bool) *Page[*Tag] {
+		page, err := mgr.SearchByPrefix(prefix, cursor, n, ignoreCase)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return page
+	}
+
+	page := search("jav", "", 10, false)
+	if !isEqual(tagNames(page.Items), []string{"jav", "java8", "javac"}) || page.NextCursor != "" || page.PrevCursor != "" {
+		t.Fatal(tagNames(page.Items))
+	}
+	page = search("Java", "", 10, false)
+	if !isEqual(tagNames(page.Items), []string{"Java", "JavaScript"}) {
+		t.Fatal(tagNames(page.Items))
+	}
+
+	// 忽略大小写并分页
+	page = search("JAVA", "", 2, true)
+	if !isEqual(tagNames(page.Items), []string{"Java", "java8"}) || page.NextCursor == "" {
+		t.Fatal(tagNames(page.Items))
+	}
+	first := page
+	page = search("JAVA", page.NextCursor, 2, true)
+	if !isEqual(tagNames(page.Items), []string{"javac", "JavaScript"}) || page.NextCursor != "" || page.PrevCursor == "" {
+		t.Fatal(tagNames(page.Items))
+	}
+	page = search("JAVA", page.PrevCursor, 2, true)
+	if !isEqual(tagNames(page.Items), []string{"Java", "java8"}) {
+		t.Fatal(tagNames(page.Items))
+	}
+
+	// 游标和前缀、ignoreCase 绑定
+	if _, err = mgr.SearchByPrefix("JA", first.NextCursor, 2, true); err != ErrInvalidCursor {
+		t.Fatal(err)
+	}
+	if _, err = mgr.SearchByPrefix("JAVA", first.NextCursor, 2, false); err != ErrInvalidCursor {
+		t.Fatal(err)
+	}
+
+	// 锚点分类被删除之后，游标仍然从原来的位置继续，不会回到第一页
+	mgr.DeleteByName("java8")
+	page = search("JAVA", first.NextCursor, 2, true)
+	if !isEqual(tagNames(page.Items), []string{"javac", "JavaScript"}) {
+		t.Fatal(tagNames(page.Items))
+	}
+
+	// 改名和删除后索引也跟着变化
+	mgr.Add("java8")
+	mgr.Rename("java8", "Kotlin")
+	mgr.DeleteByName("javac")
+	page = search("ja", "", 10, true)
+	if !isEqual(tagNames(page.Items), []string{"jav", "Java", "JavaScript"}) {
+		t.Fatal(tagNames(page.Items))
+	}
+	page = search("k", "", 10, true)
+	if !isEqual(tagNames(page.Items), []string{"Kotlin"}) {
+		t.Fatal(tagNames(page.Items))
+	}
+	if len(search("", "", 100, false).Items) != 5 || len(search("", "", 100, true).Items) != 5 {
+		t.Fatal()
+	}
+
+	// 名称为空的分类（未分类）不会返回，也不影响是否有上一页
+	mgr.Add("")
+	for _, ignoreCase := range []bool{false, true} {
+		page = search("", "", 2, ignoreCase)
+		if len(page.Items) != 2 || page.Items[0].Name == "" || page.PrevCursor != "" {
+			t.Fatal(ignoreCase, tagNames(page.Items))
+		}
+		if page = search("", "", 100, ignoreCase); len(page.Items) != 5 {
+			t.Fatal(ignoreCase, tagNames(page.Items))
+		}
+	}
+}
+
+func tagNames(tags []*Tag) []string {
+	names := make([]string, 0)
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
@@ -0,0 +1,301 @@
+package gmodel
+
+import (
+	"fmt"
+	"sort"
+)
+
+// 分类法，比如分类、关键词、作者、系列等
+// 每个分类法都有独立的分类数据库和索引数据库，互不影响，所以不同分类法下可以有同名的分类
+// 默认的分类法就是 GModel 本身的分类（Article.TagIds），其他分类法保存在 Article.Terms 中
+type taxonomy struct {
+	name    string // 分类法名称，为空表示默认的分类法
+	tagMgr  *TagMgr
+	indexDB *KVStore
+}
+
+// 返回文章在该分类法下的分类ID
+func (this *taxonomy) getTermIds(article *Article) []uint64 {
+	if this.name == "" {
+		return article.TagIds
+	}
+	return article.Terms[this.name]
+}
+
+// 修改文章在该分类法下的分类ID，只修改article，不保存
+func (this *taxonomy) setTermIds(article *Article, termIds []uint64) {
+	if this.name == "" {
+		article.TagIds = termIds
+		return
+	}
+
+	if len(termIds) == 0 {
+		delete(article.Terms, this.name)
+		return
+	}
+	if article.Terms == nil {
+		article.Terms = make(map[string][]uint64)
+	}
+	article.Terms[this.name] = termIds
+}
+
+// 过滤掉空的分类名称，只用于默认的分类法以外的分类法
+func (this *taxonomy) filterTerms(terms []string) []string {
+	names := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if this.tagMgr.normalize(term) != "" {
+			names = append(names, term)
+		}
+	}
+	return names
+}
+
+// 打开一个分类法，使用两个数据库文件，分别存放分类、索引
+// name为分类法名称，比如 "keyword"、"author"、"series"，不能为空，不能重复打开
+// 需要在 Open 之后、使用之前调用，每次启动都需要重新打开所有用到的分类法
+func (this *GModel) OpenTaxonomy(name, tagDBPath, indexDBPath string) error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if name == "" {
+		return newError(ErrInvalidArgument, "GModel OpenTaxonomy name must not empty!")
+	}
+	if _, exist := this.taxonomies[name]; exist {
+		return newError(ErrExists, fmt.Sprintf("Taxonomy[%v] already opened", name))
+	}
+
+	tax := &taxonomy{
+		name:    name,
+		tagMgr:  &TagMgr{cursors: this.cursors},
+		indexDB: &KVStore{},
+	}
+	if err := tax.tagMgr.Open(tagDBPath); err != nil {
+		return err
+	}
+	if err := tax.indexDB.Open(indexDBPath); err != nil {
+		tax.tagMgr.Close()
+		return err
+	}
+	if err := this.upgradeIndex(tax.indexDB); err != nil {
+		tax.tagMgr.Close()
+		tax.indexDB.Close()
+		return err
+	}
+	tax.tagMgr.SetNormalizer(this.tagMgr.normalizer)
+
+	this.taxonomies[name] = tax
+	return nil
+}
+
+// 返回已经打开的分类法名称，按字典序排序
+func (this *GModel) GetTaxonomyNames() []string {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	names := make([]string, 0, len(this.taxonomies))
+	for name := range this.taxonomies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// 根据分类ID获取指定分类法下的分类
+func (this *GModel) GetTermById(taxonomyName string, id uint64) (*Tag, error) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	tax, err := this.getTaxonomy(taxonomyName)
+	if err != nil {
+		return nil, err
+	}
+	return tax.tagMgr.GetById(id)
+}
+
+// 批量获取指定分类法下的分类，返回分类ID到分类的映射，分类法不存在时返回空
+func (this *GModel) GetTermsByIds(taxonomyName string, ids []uint64) map[uint64]*Tag {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	tax, err := this.getTaxonomy(taxonomyName)
+	if err != nil {
+		return make(map[uint64]*Tag)
+	}
+	return tax.tagMgr.GetByIds(ids)
+}
+
+// 根据分类名称获取指定分类法下的分类
+func (this *GModel) GetTermByName(taxonomyName string, name string) (*Tag, error) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	tax, err := this.getTaxonomy(taxonomyName)
+	if err != nil {
+		return nil, err
+	}
+	return tax.tagMgr.GetByName(name)
+}
+
+// 返回指定分类法下的分类的文章数量
+func (this *GModel) GetArticleCountByTerm(taxonomyName string, term string) uint64 {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	tax, err := this.getTaxonomy(taxonomyName)
+	if err != nil {
+		return 0
+	}
+	return tax.tagMgr.GetArticleCountByName(term)
+}
+
+// 获取指定文章的后N篇（不包括当前这篇），保证这N篇文章属于分类法 taxonomyName 下的分类 term
+// 如果分类法或者分类不存在，则返回空数组
+// articleId为文章ID，如果articleId为0，则返回该分类最旧的N篇文章（id最小的N篇）
+//
+// Deprecated: 使用 ListArticlesByTerm 代替
+func (this *GModel) GetNextArticlesByTerm(taxonomyName string, term string, articleId uint64, n int) []*Article {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	tax, err := this.getTaxonomy(taxonomyName)
+	if err != nil {
+		return make([]*Article, 0)
+	}
+	return this.getNextArticlesByTerm(tax, term, articleId, n)
+}
+
+// 获取指定文章的前N篇（不包括当前这篇），保证这N篇文章属于分类法 taxonomyName 下的分类 term
+// 如果分类法或者分类不存在，则返回空数组
+// articleId为文章ID，如果 articleId 大于 最大的文章ID，则返回该分类最新的N篇文章（id最大的N篇）
+//
+// Deprecated: 使用 ListArticlesByTerm 代替
+func (this *GModel) GetPrevArticlesByTerm(taxonomyName string, term string, articleId uint64, n int) []*Article {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	tax, err := this.getTaxonomy(taxonomyName)
+	if err != nil {
+		return make([]*Article, 0)
+	}
+	return this.getPrevArticlesByTerm(tax, term, articleId, n)
+}
+
+// 按文章ID分页获取分类法 taxonomyName 下的分类 term 的文章，分类不存在时返回空页，详见 ListArticles
+func (this *GModel) ListArticlesByTerm(taxonomyName string, term string, cursor string, n int, order Order) (*Page[*Article], error) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	tax, err := this.getTaxonomy(taxonomyName)
+	if err != nil {
+		return nil, err
+	}
+	return this.listArticlesByTerm(tax, term, cursor, n, order)
+}
+
+// 返回指定分类法下的分类数量
+func (this *GModel) GetTermCount(taxonomyName string) (uint64, error) {
+	this.mutex.RLock()
+	defer this.mutex.RUnlock()
+
+	tax, err := this.getTaxonomy(taxonomyName)
+	if err != nil {
+		return 0, err
+	}
+	return tax.tagMgr.Count(), nil
+}
+
+// 增加指定分类法下的分类，详见 AddTag
+func (this *GModel) AddTerm(taxonomyName string, name string) (*Tag, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	tax, err := this.getTaxonomy(taxonomyName)
+	if err != nil {
+		return nil, err
+	}
+	return this.addTag(tax, name)
+}
+
+// 删除指定分类法下的分类，详见 DeleteTag
+func (this *GModel) DeleteTerm(taxonomyName string, name string) error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	tax, err := this.getTaxonomy(taxonomyName)
+	if err != nil {
+		return err
+	}
+	return this.deleteTag(tax, name)
+}
+
+// 修改文章在指定分类法下的分类，terms为空表示文章不再属于该分类法下的任何分类
+func (this *GModel) SetArticleTerms(articleId uint64, taxonomyName string, terms []string) error {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	tax, err := this.getTaxonomy(taxonomyName)
+	if err != nil {
+		return err
+	}
+
+	article, err := this.articleMgr.GetById(articleId)
+	if err != nil {
+		return err
+	}
+	if err = this.checkArticleTaxonomies(article); err != nil {
+		return err
+	}
+
+	sequences := this.tagSequences()
+	if err = this.updateArticle(article, []termUpdate{{tax, this.addTaxonomyTerms(tax, terms)}}); err != nil {
+		this.deleteNewEmptyTags(sequences)
+		return err
+	}
+	return nil
+}
+
+// 增加分类法下的分类，返回去重后的分类ID
+// 和默认的分类法不同，其他分类法下的分类可以为空，所以需要过滤掉空字符串
+func (this *GModel) addTaxonomyTerms(tax *taxonomy, terms []string) []uint64 {
+	return this.addTerms(tax.tagMgr, tax.filterTerms(terms))
+}
+
+// 返回默认的分类法
+func (this *GModel) defaultTaxonomy() *taxonomy {
+	return &taxonomy{
+		tagMgr:  this.tagMgr,
+		indexDB: this.indexDB,
+	}
+}
+
+// 返回所有的分类法，默认的分类法在最前面，其他的按名称排序
+func (this *GModel) allTaxonomies() []*taxonomy {
+	names := make([]string, 0, len(this.taxonomies))
+	for name := range this.taxonomies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	taxonomies := []*taxonomy{this.defaultTaxonomy()}
+	for _, name := range names {
+		taxonomies = append(taxonomies, this.taxonomies[name])
+	}
+	return taxonomies
+}
+
+// 文章用到的分类法都需要已经打开，否则删除、修改文章时这些分类的文章数和索引无法维护
+func (this *GModel) checkArticleTaxonomies(article *Article) error {
+	for name := range article.Terms {
+		if _, exist := this.taxonomies[name]; !exist {
+			return newError(ErrNotFound, fmt.Sprintf("Article ID[%v] has terms of Taxonomy[%v] which is not opened", article.Id, name))
+		}
+	}
+	return nil
+}
+
+func (this *GModel) getTaxonomy(name string) (*taxonomy, error) {
+	if tax, exist := this.taxonomies[name]; exist {
+		return tax, nil
+	}
+	return nil, newError(ErrNotFound, fmt.Sprintf("Taxonomy[%v] not found", name))
+}
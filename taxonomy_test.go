@@ -0,0 +1,219 @@
+package gmodel
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestTaxonomy(t *testing.T) {
+	paths := []string{
+		"test_article.db", "test_tag.db", "test_index.db",
+		"test_keyword_tag.db", "test_keyword_index.db",
+		"test_series_tag.db", "test_series_index.db",
+	}
+	defer func() {
+		for _, path := range paths {
+			os.RemoveAll(path)
+		}
+	}()
+
+	gmodel := &GModel{}
+	err := gmodel.Open(paths[0], paths[1], paths[2])
+	if err != nil {
+		t.Fatal()
+	}
+	defer gmodel.Close()
+
+	if err = gmodel.OpenTaxonomy("keyword", paths[3], paths[4]); err != nil {
+		t.Fatal(err)
+	}
+	if err = gmodel.OpenTaxonomy("series", paths[5], paths[6]); err != nil {
+		t.Fatal(err)
+	}
+	if gmodel.OpenTaxonomy("series", paths[5], paths[6]) == nil {
+		t.Fatal()
+	}
+	if !isEqual(gmodel.GetTaxonomyNames(), []string{"keyword", "series"}) {
+		t.Fatal()
+	}
+
+	// 不同分类法下可以有同名的分类
+	gmodel.AddArticleWithTerms([]string{"go"}, map[string][]string{
+		"keyword": {"go", "leveldb", ""},
+		"series":  {"learn go"},
+	}, "data_id_1")
+	gmodel.AddArticleWithTerms([]string{"go"}, map[string][]string{
+		"keyword": {"leveldb"},
+	}, "data_id_2")
+	gmodel.AddArticle([]string{"rust"}, "data_id_3")
+
+	if _, err = gmodel.AddArticleWithTerms(nil, map[string][]string{"none": {"a"}}, "data"); err == nil {
+		t.Fatal()
+	}
+
+	if gmodel.GetTagCount() != 2 || gmodel.GetArticleCountByTerm("keyword", "leveldb") != 2 {
+		t.Fatal()
+	}
+	if gmodel.GetArticleCountByTerm("series", "learn go") != 1 || gmodel.GetArticleCountByTerm("series", "go") != 0 {
+		t.Fatal()
+	}
+
+	articles := gmodel.GetNextArticlesByTerm("keyword", "leveldb", 0, 10)
+	if len(articles) != 2 || articles[0].Id != 1 || articles[1].Id != 2 {
+		t.Fatal()
+	}
+	articles = gmodel.GetPrevArticlesByTerm("keyword", "leveldb", gmodel.GetMaxArticleId()+1, 1)
+	if len(articles) != 1 || articles[0].Id != 2 {
+		t.Fatal()
+	}
+	if len(gmodel.GetNextArticlesByTerm("none", "leveldb", 0, 10)) != 0 {
+		t.Fatal()
+	}
+
+	article, _ := gmodel.GetArticle(1)
+	if len(article.Terms["keyword"]) != 2 || len(article.Terms["series"]) != 1 {
+		t.Fatal()
+	}
+	term, err := gmodel.GetTermById("series", article.Terms["series"][0])
+	if err != nil || term.Name != "learn go" {
+		t.Fatal(err)
+	}
+
+	// 修改分类不影响其他分类法
+	if err = gmodel.UpdateArticle(1, []string{"golang"}, "new_data_id_1"); err != nil {
+		t.Fatal(err)
+	}
+	if gmodel.GetArticleCountByTerm("keyword", "go") != 1 || gmodel.GetArticleCountByTag("golang") != 1 {
+		t.Fatal()
+	}
+
+	if err = gmodel.SetArticleTerms(1, "keyword", []string{"leveldb", "storage"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = gmodel.GetTermByName("keyword", "go"); err == nil {
+		t.Fatal()
+	}
+	if gmodel.GetArticleCountByTerm("keyword", "storage") != 1 {
+		t.Fatal()
+	}
+	if err = gmodel.SetArticleTerms(1, "series", nil); err != nil {
+		t.Fatal(err)
+	}
+	article, _ = gmodel.GetArticle(1)
+	if _, exist := article.Terms["series"]; exist {
+		t.Fatal()
+	}
+
+	// 一起修改分类、其他分类法下的分类和数据，有分类法没有打开时什么都不修改
+	if err = gmodel.UpdateArticleWithTerms(1, []string{"go"}, map[string][]string{"keyword": {"go"}, "none": {"a"}}, "data"); !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+	article, _ = gmodel.GetArticle(1)
+	if article.Data != "new_data_id_1" || gmodel.GetArticleCountByTag("golang") != 1 || gmodel.GetArticleCountByTerm("keyword", "storage") != 1 {
+		t.Fatal(article)
+	}
+	if err = gmodel.UpdateArticleWithTerms(1, []string{"golang"}, map[string][]string{"series": {"learn go"}}, "new_data_id_1"); err != nil {
+		t.Fatal(err)
+	}
+	if gmodel.GetArticleCountByTerm("series", "learn go") != 1 || gmodel.GetArticleCountByTerm("keyword", "storage") != 1 {
+		t.Fatal()
+	}
+	if err = gmodel.SetArticleTerms(1, "series", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = gmodel.DeleteArticle(2); err != nil {
+		t.Fatal(err)
+	}
+	if gmodel.GetArticleCountByTerm("keyword", "leveldb") != 1 {
+		t.Fatal()
+	}
+	if len(gmodel.GetNextArticlesByTerm("keyword", "leveldb", 0, 10)) != 1 {
+		t.Fatal()
+	}
+
+	// 增加、删除分类法下的分类，文章没有其他分类时不再属于该分类法
+	if _, err = gmodel.AddTerm("keyword", "storage"); !errors.Is(err, ErrExists) {
+		t.Fatal(err)
+	}
+	if term, err = gmodel.AddTerm("series", "learn rust"); err != nil || term.ArticleCount != 0 {
+		t.Fatal(err)
+	}
+	if count, err := gmodel.GetTermCount("series"); err != nil || count != 1 {
+		t.Fatal(err, count)
+	}
+	if err = gmodel.DeleteTerm("keyword", "leveldb"); err != nil {
+		t.Fatal(err)
+	}
+	if err = gmodel.DeleteTerm("keyword", "storage"); err != nil {
+		t.Fatal(err)
+	}
+	article, _ = gmodel.GetArticle(1)
+	if _, exist := article.Terms["keyword"]; exist {
+		t.Fatal()
+	}
+	if count, err := gmodel.GetTermCount("keyword"); err != nil || count != 0 {
+		t.Fatal(err, count)
+	}
+	if _, err = gmodel.GetTermCount("none"); !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+}
+
+// 文章用到的分类法没有打开时不能删除、修改文章，否则这些分类的文章数和索引会一直残留
+func TestUnopenedTaxonomy(t *testing.T) {
+	paths := []string{"test_article.db", "test_tag.db", "test_index.db", "test_keyword_tag.db", "test_keyword_index.db"}
+	defer func() {
+		for _, path := range paths {
+			os.RemoveAll(path)
+		}
+	}()
+
+	gmodel := &GModel{}
+	if err := gmodel.Open(paths[0], paths[1], paths[2]); err != nil {
+		t.Fatal(err)
+	}
+	if err := gmodel.OpenTaxonomy("keyword", paths[3], paths[4]); err != nil {
+		t.Fatal(err)
+	}
+	articleId, err := gmodel.AddArticleWithTerms([]string{"go"}, map[string][]string{"keyword": {"leveldb"}}, "data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gmodel.Close()
+
+	// 没有打开 keyword
+	gmodel = &GModel{}
+	if err = gmodel.Open(paths[0], paths[1], paths[2]); err != nil {
+		t.Fatal(err)
+	}
+	if err = gmodel.DeleteArticle(articleId); !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+	if err = gmodel.UpdateArticle(articleId, []string{"rust"}, "new data"); !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+	if _, _, err = gmodel.UpsertArticle(articleId, []string{"rust"}, nil, "new data"); !errors.Is(err, ErrNotFound) {
+		t.Fatal(err)
+	}
+	if article, err := gmodel.GetArticle(articleId); err != nil || article.Data != "data" {
+		t.Fatal(err)
+	}
+	gmodel.Close()
+
+	gmodel = &GModel{}
+	if err = gmodel.Open(paths[0], paths[1], paths[2]); err != nil {
+		t.Fatal(err)
+	}
+	defer gmodel.Close()
+	if err = gmodel.OpenTaxonomy("keyword", paths[3], paths[4]); err != nil {
+		t.Fatal(err)
+	}
+	if err = gmodel.DeleteArticle(articleId); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := gmodel.GetTermCount("keyword"); err != nil || count != 0 {
+		t.Fatal(err, count)
+	}
+}
@@ -1,33 +1,48 @@
 package gmodel
 
 import (
+	"crypto/rand"
 	"fmt"
-	"math/rand"
-	"time"
+	"math/big"
 )
 
-// 得到一个长度在区间[minLen, maxLen]内的随机字符数组
-// 字符内容为：a-zA-Z0-9，去掉 l L 1  0 o O 这6个不易识别的字符，每个字符有62-6=56种可能
-// 一般6位长度就够了：56^6 = 30840979456  =  308亿
+// 得到一个长度在区间[minLen, maxLen]内的随机字符数组，字符集为 DefaultIdAlphabet
+// 随机数来自 crypto/rand，minLen 大于等于 maxLen 时长度为 minLen
 func RandomBytes(minLen, maxLen int) []byte {
-	num := 0
+	num := minLen
 	if minLen < maxLen {
-		rand.Seed(time.Now().UnixNano())
-		num = rand.Intn(maxLen-minLen+1) + minLen
-	} else {
-		num = minLen
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(maxLen-minLen+1)))
+		if err != nil {
+			panic(err)
+		}
+		num += int(n.Int64())
 	}
 
-	byteArray := make([]byte, num)
-	const alphabet = "abcdefghijkmnpqrstuvwxyzABCDEFGHIJKMNPQRSTUVWXYZ23456789"
-	alphabetLen := len(alphabet)
-
-	for i := range byteArray {
-		rand.Seed(time.Now().UnixNano())
-		byteArray[i] = alphabet[rand.Intn(alphabetLen)]
+	s, err := randomString(DefaultIdAlphabet, num)
+	if err != nil {
+		panic(err)
 	}
+	return []byte(s)
+}
 
-	return byteArray
+// 用 crypto/rand 生成长度为n的随机字符串，每个字符在 alphabet 中均匀分布，alphabet 最多256个字符
+func randomString(alphabet string, n int) (string, error) {
+	// 丢掉大于等于 limit 的随机字节，否则取模之后前面的字符概率更大
+	limit := 256 - 256%len(alphabet)
+	result := make([]byte, 0, n)
+	buf := make([]byte, n+n/2+1)
+
+	for len(result) < n {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if int(b) < limit && len(result) < n {
+				result = append(result, alphabet[int(b)%len(alphabet)])
+			}
+		}
+	}
+	return string(result), nil
 }
 
 // 将uint64转成string，不足15位时左边补0，这样id递增的时候，对应的key也是字典序递增的
@@ -23,6 +23,33 @@ func TestRandomBytes(t *testing.T) {
 	}
 }
 
+func TestRandomString(t *testing.T) {
+	// 每个字符都在字符集中，而且都会出现
+	seen := make(map[rune]bool)
+	for i := 0; i < 100; i++ {
+		s, err := randomString("abc", 16)
+		if err != nil || len(s) != 16 || strings.Trim(s, "abc") != "" {
+			t.Fatal(err, s)
+		}
+		for _, c := range s {
+			seen[c] = true
+		}
+	}
+	if len(seen) != 3 {
+		t.Fatal(seen)
+	}
+
+	// 不会重复
+	ids := make(map[string]bool)
+	for i := 0; i < 10000; i++ {
+		s, _ := randomString(DefaultIdAlphabet, DefaultIdLength)
+		if ids[s] {
+			t.Fatal(s)
+		}
+		ids[s] = true
+	}
+}
+
 func TestStringKey(t *testing.T) {
 	var a uint64 = 1
 	s := GetStringKey(a)